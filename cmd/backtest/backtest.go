@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ticker, startTime, endTime, tickerConfig, rangeDuration, directionDuration, outFile string
+	entryBand, exitBand, commission, slippage, positionSize, initialCapital             float64
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&ticker, "ticker", "AAPL", "Enter stock ticker to backtest")
+	flag.StringVar(&ticker, "t", "AAPL", "Enter stock ticker to backtest")
+	flag.StringVar(&startTime, "startTime", "1 year ago", "Enter a time to start the backtest. "+
+		"Time must be formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC. The LONG duration's days "+
+		"before it are fetched to warm up the ranges and directions, but not traded.")
+	flag.StringVar(&endTime, "endTime", "Today", "Enter a time to end the backtest. "+
+		"Time must be formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
+	flag.StringVar(&rangeDuration, "range", "SHORT", "duration of the range to trade: SHORT, MEDIUM, or LONG")
+	flag.StringVar(&directionDuration, "direction", "MEDIUM",
+		"duration whose direction must be Bullish to enter: SHORT, MEDIUM, or LONG")
+	flag.Float64Var(&entryBand, "entryBand", 0.1, "buy when the close is within this fraction of the range "+
+		"width above the range low")
+	flag.Float64Var(&exitBand, "exitBand", 0.1, "sell when the close is within this fraction of the range "+
+		"width below the range high")
+	flag.Float64Var(&commission, "commission", 0, "flat commission charged on each fill")
+	flag.Float64Var(&slippage, "slippage", 0.001, "fraction of the price lost on each fill. Default is: .001")
+	flag.Float64Var(&positionSize, "size", 1, "fraction of equity committed to each entry. Default is: 1")
+	flag.Float64Var(&initialCapital, "capital", 10000, "starting account value. Default is: 10000")
+	flag.StringVar(&outFile, "o", "backtest.json", "output file for the equity curve, trades, and stats")
+}
+
+func main() {
+	flag.Parse()
+
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	var err error
+	if endTime != "Today" {
+		if end, err = time.Parse(time.RFC3339, endTime); err != nil {
+			log.Fatalf("unable to parse endTime %s: %v", endTime, err)
+		}
+	}
+	if startTime != "1 year ago" {
+		if start, err = time.Parse(time.RFC3339, startTime); err != nil {
+			log.Fatalf("unable to parse startTime %s: %v", startTime, err)
+		}
+	}
+
+	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	if _, err = os.Stat(tickerConfig); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error: config file %s does not exist. exiting", tickerConfig)
+	}
+	configFile, err := os.Open(tickerConfig)
+	if err != nil {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		log.Fatalf("error decoding the json config file: %v", err)
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Fatalf("error in the returns config: %v", err)
+	}
+
+	// Section fetches LONGDURATION days of warm-up before the start, so every duration has a direction by the time
+	// trading starts, and analyzes the whole series once
+	ticker = pkg.NormalizeTicker(ticker)
+	warmUp := start.AddDate(0, 0, -pkg.LONGDURATION)
+	var tickerData map[string]map[int64]pkg.SingleStockCandle
+	if stockDataConfig.AlpacaAPIKey != "" {
+		tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", warmUp, end, false)
+	} else {
+		tickerData, err = pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", warmUp, end)
+	}
+	if err != nil {
+		log.Fatalf("unable to retrieve stock data: %v", err)
+	}
+	candles := map[int64]pkg.SingleStockCandle{}
+	for _, analyzed := range pkg.RangePipeline(stockDataConfig)(tickerData) {
+		for date, c := range analyzed {
+			if !c.Timestamp.Before(start) {
+				candles[date] = c
+			}
+		}
+	}
+
+	strategy := pkg.RangeStrategy(durationFlag(rangeDuration), durationFlag(directionDuration), entryBand, exitBand)
+	conf := pkg.BacktestConf{
+		InitialCapital: initialCapital,
+		PositionSize:   positionSize,
+		Commission:     commission,
+		Slippage:       slippage,
+	}
+	identity := func(stockPrices map[string]map[int64]pkg.SingleStockCandle) map[string]map[int64]pkg.SingleStockCandle {
+		return stockPrices
+	}
+	result, err := pkg.Backtest(ticker, candles, identity, strategy, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(outFile, jsonData, 0600); err != nil {
+		log.Fatal(err)
+	}
+	s := result.Stats
+	fmt.Printf("%s: %d trades, win rate %.1f%%, total return %.2f%%, CAGR %.2f%%, max drawdown %.2f%%, "+
+		"exposure %.1f%%.\n", ticker, s.Trades, s.WinRate*100, s.TotalReturn*100, s.CAGR*100, s.MaxDrawdown*100,
+		s.Exposure*100)
+}
+
+// durationFlag maps SHORT, MEDIUM, and LONG to their durations, defaulting to SHORT like batchStocks' -t flag.
+func durationFlag(name string) int {
+	switch strings.ToUpper(name) {
+	case "MEDIUM":
+		return pkg.MEDIUMDURATION
+	case "LONG":
+		return pkg.LONGDURATION
+	}
+	return pkg.SHORTDURATION
+}
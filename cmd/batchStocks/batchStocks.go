@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"github.com/khrystoph/portfoliotools/pkg"
 	"io"
 	"log"
@@ -14,8 +15,9 @@ import (
 )
 
 var (
-	csvFile, outFile, tickerConfig, batchStockRangesFile, timeDuration string
-	debug, excelOut, noEmail, showTail                                 bool
+	csvFile, outFile, tickerConfig, batchStockRangesFile, timeDuration, indicatorList, benchmark, paramsFile string
+	historyFile, asOfDate, startDateFlag, endDateFlag                                                        string
+	debug, excelOut, noEmail, showTail, forceEmail                                                           bool
 )
 
 func init() {
@@ -31,8 +33,14 @@ func init() {
 		" of showing more information. Default value: false.")
 	flag.BoolVar(&noEmail, "n", false, "Toggles whether to send the email or not. For debug purposes.")
 	flag.BoolVar(&noEmail, "noemail", false, "Toggles whether to send the email or not. For debug purposes.")
-	flag.StringVar(&csvFile, "f", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl")
-	flag.StringVar(&csvFile, "file", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl")
+	flag.BoolVar(&forceEmail, "email", false, "send the email for an -asof or -end run too, which otherwise "+
+		"skips it")
+	flag.StringVar(&csvFile, "f", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl. "+
+		"Entries such as XLK/SPY or GLD-2*SLV analyze the ratio or spread of two tickers, and the name of a "+
+		"basket in the config file analyzes its index.")
+	flag.StringVar(&csvFile, "file", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl. "+
+		"Entries such as XLK/SPY or GLD-2*SLV analyze the ratio or spread of two tickers, and the name of a "+
+		"basket in the config file analyzes its index.")
 	flag.StringVar(&outFile, "outFile", "tickers_out.json", "output file for ")
 	flag.StringVar(&batchStockRangesFile, "o", "stockRanges.json",
 		"Set the value of the output file of the batch stock ranges.json")
@@ -46,17 +54,34 @@ func init() {
 		"give the duration in terms of number of candles, such as SHORT for the short-term trend duration")
 	flag.BoolVar(&showTail, "tail", false, "Include Tail Slope and Tail Dir columns in Excel output")
 	flag.BoolVar(&showTail, "tail-cols", false, "Include Tail Slope and Tail Dir columns in Excel output")
+	flag.StringVar(&benchmark, "benchmark", "", "ticker to measure beta and correlation against, e.g. SPY. "+
+		"Overrides the benchmark in the config file.")
+	flag.StringVar(&paramsFile, "params", "", "path to the per-ticker range parameters written by walkForward. "+
+		"Overrides the ticker-params file in the config file. A ticker's parameters are only used when they were "+
+		"picked for the duration -t selects.")
+	flag.StringVar(&historyFile, "history", "", "path to a json file that accumulates every direction change "+
+		"seen across runs, so they can be queried later with filterJSON -history.")
+	flag.StringVar(&asOfDate, "asof", "", "reproduce the report as it would have been run at the close of this "+
+		"date, formatted as YYYY-MM-DD. Candles after the date are ignored. Unless -o is passed the report is "+
+		"written to a file named for the date, such as stockRanges-2024-06-03.json, and no email is sent "+
+		"without -email.")
+	flag.StringVar(&startDateFlag, "start", "", "first date of candles to analyze, formatted as YYYY-MM-DD. "+
+		"Default is one year before the end date.")
+	flag.StringVar(&endDateFlag, "end", "", "last date of candles to analyze, formatted as YYYY-MM-DD. "+
+		"Default is today. Like -asof, it writes a dated report and sends no email by default.")
+	flag.StringVar(&indicatorList, "indicators", "", "comma-separated technical indicators to include in the "+
+		"output, e.g. sma-20,rsi-14,macd. Overrides the indicators list in the config file.")
 }
 
 func main() {
 	flag.Parse()
 	var (
-		tickerData          map[string]map[int64]pkg.SingleStockCandle
-		tickerBatch         = make(map[string]map[int64]pkg.SingleStockCandle)
-		batchStockRanges    = make(map[string]pkg.CondensedRangesJSON)
-		tickerArray         = make([]string, 0)
-		err                 error
-		userDir, resolution string
+		tickerData       map[string]map[int64]pkg.SingleStockCandle
+		tickerBatch      = make(map[string]map[int64]pkg.SingleStockCandle)
+		batchStockRanges = make(map[string]pkg.CondensedRangesJSON)
+		tickerArray      = make([]string, 0)
+		err              error
+		userDir          string
 	)
 
 	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
@@ -81,13 +106,108 @@ func main() {
 		log.Printf("error decoding the json config file: %v", err)
 		os.Exit(1)
 	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Printf("error in the returns config: %v", err)
+		os.Exit(1)
+	}
+	if err = pkg.RegisterBaskets(stockDataConfig.Baskets); err != nil {
+		log.Printf("error in the baskets config: %v", err)
+		os.Exit(1)
+	}
+
+	// Section selects the technical indicators to compute; the -indicators flag overrides the config file list
+	indicatorNames := stockDataConfig.Indicators
+	if indicatorList != "" {
+		indicatorNames = strings.Split(indicatorList, ",")
+	}
+	indicatorSpecs, err := pkg.ParseIndicatorSpecs(indicatorNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = stockDataConfig.VaR.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	// A sizing section with equity adds a suggested position size for each ticker to the report
+	sizePositions := stockDataConfig.Sizing.Equity != 0
+	if sizePositions {
+		if err = stockDataConfig.Sizing.Validate(); err != nil {
+			log.Fatalf("error in the sizing config: %v", err)
+		}
+	}
+
+	// Section loads the per-ticker range parameters picked by the walk-forward optimizer, when there are any
+	if paramsFile == "" {
+		paramsFile = stockDataConfig.TickerParams
+	}
+	tickerParams := map[string]pkg.TickerParams{}
+	if paramsFile != "" {
+		tickerParams, err = pkg.LoadTickerParams(strings.Replace(paramsFile, "~", userDir, 1))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// Section uses today's date in milliseconds, then subtracts a year for the start date for simplicity
-	// TODO: make the start and end dates configurable
+	// Section picks the window of candles: the year up to today unless -asof, -end, or -start move it
+	if asOfDate != "" && endDateFlag != "" {
+		log.Fatal("use either -asof or -end, not both")
+	}
 	endDate := time.Now()
-	endDateMilli := endDate.UnixMilli()
+	if asOfDate != "" {
+		endDateFlag = asOfDate
+	}
+	// A back-dated run leaves the live report alone, so neither it nor the next daily run is compared against the
+	// other, and is not emailed as if it were current
+	backDated := endDateFlag != ""
+	if backDated {
+		if endDate, err = time.Parse(time.DateOnly, endDateFlag); err != nil {
+			log.Fatalf("unable to parse end date %s: %v", endDateFlag, err)
+		}
+		// Include the whole end day, as a run at that day's close would have
+		endDate = endDate.AddDate(0, 0, 1).Add(-time.Millisecond)
+		outFileSet := false
+		flag.Visit(func(f *flag.Flag) {
+			outFileSet = outFileSet || f.Name == "o" || f.Name == "outfile"
+		})
+		if !outFileSet {
+			batchStockRangesFile = strings.TrimSuffix(batchStockRangesFile, ".json") + "-" + endDateFlag + ".json"
+		}
+		noEmail = noEmail || !forceEmail
+	}
+	startDateMilli := endDate.AddDate(-1, 0, 0)
+	if startDateFlag != "" {
+		if startDateMilli, err = time.Parse(time.DateOnly, startDateFlag); err != nil {
+			log.Fatalf("unable to parse start date %s: %v", startDateFlag, err)
+		}
+	}
+	if !startDateMilli.Before(endDate) {
+		log.Fatalf("start date %s must be before end date %s", startDateMilli.Format(time.DateOnly),
+			endDate.Format(time.DateOnly))
+	}
 
-	startDateMilli := time.UnixMilli(endDateMilli).AddDate(-1, 0, 0)
+	// Section fetches the benchmark once so each ticker's beta and correlation can be measured against it
+	if benchmark == "" {
+		benchmark = stockDataConfig.Benchmark
+	}
+	benchmark = pkg.NormalizeTicker(benchmark)
+	var benchmarkCandles map[int64]pkg.SingleStockCandle
+	if benchmark != "" {
+		benchmarkData, err := fetchCandles(stockDataConfig, benchmark, startDateMilli, endDate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, candles := range pkg.TruncateAsOf(benchmarkData, endDate) {
+			benchmarkCandles = candles
+		}
+	}
+
+	// Section maps -t to the duration the report's ranges, volume, and volatility columns are taken from
+	selectedDuration := pkg.SHORTDURATION
+	switch timeDuration {
+	case "MEDIUM":
+		selectedDuration = pkg.MEDIUMDURATION
+	case "LONG":
+		selectedDuration = pkg.LONGDURATION
+	}
 
 	// Section parses the list of tickers and then loops over them to create a single slice of stocks to iterate over
 	file, err := os.Open(csvFile)
@@ -113,32 +233,12 @@ func main() {
 		if strings.HasPrefix(tickerItem, "X:") {
 			isCrypto = true
 		}
-		if stockDataConfig.AlpacaAPIKey != "" {
-			switch resolution {
-			case "minute", "Minute", "MINUTE", "M", "m":
-				resolution = "1T"
-			case "hour", "Hour", "HOUR", "H", "h":
-				resolution = "1H"
-			case "Week", "week", "WEEK", "W", "w":
-				resolution = "1W"
-			case "Month", "month", "MONTH", "Mo", "mo":
-				resolution = "1M"
-			case "DAY", "day", "Day", "D", "d":
-				fallthrough
-			default:
-				resolution = "1D"
-			}
-
-			tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, tickerItem, resolution, startDateMilli, endDate, debug)
-			if err != nil {
-				log.Fatal(err)
-			}
-		} else {
-			tickerData, err = pkg.GetStockPrices(tickerItem, stockDataConfig.PolygonAPIToken, resolution, startDateMilli, endDate)
-			if err != nil {
-				log.Printf("unable to get stock prices for %s", tickerItem)
-			}
+		tickerData, err = fetchSeries(stockDataConfig, tickerItem, startDateMilli, endDate)
+		if err != nil {
+			log.Fatal(err)
 		}
+		// Keep only the candles up to the end date, so an -asof run sees what a run on that date would have seen
+		tickerData = pkg.TruncateAsOf(tickerData, endDate)
 
 		// Calculate realized vols, ranges, and adjusted ranges for each duration
 		durations := []int{pkg.SHORTDURATION, pkg.MEDIUMDURATION, pkg.LONGDURATION}
@@ -166,25 +266,54 @@ func main() {
 		for _, d := range durations {
 			tickerData = pkg.CalculateAccelerations(tickerData, d)
 		}
+		// Tuned parameters only apply to the duration they were picked for, so the row does not mix durations
+		params, hasParams := tickerParams[tickerItem]
+		if hasParams && params.Duration != selectedDuration {
+			log.Printf("skipping the tuned range parameters for %s: they were picked for %d-day ranges and -t "+
+				"selects %d-day ranges", tickerItem, params.Duration, selectedDuration)
+			hasParams = false
+		}
+		rangeAdjustment := stockDataConfig.RangeAdjustment
+		if hasParams {
+			rangeAdjustment = params.RangeAdjustment
+		}
 		for _, d := range durations {
-			tickerData = pkg.GetProbAdjRiskRanges(tickerData, d, stockDataConfig.RangeAdjustment)
+			tickerData = pkg.GetProbAdjRiskRanges(tickerData, d, rangeAdjustment)
 		}
-		tickerData = pkg.GetSimpleSlopes(tickerData, debug)
-		tickerData = pkg.CalculateTrendDirections(tickerData, debug)
-		//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.SHORTDURATION, debug)
-		//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.MEDIUMDURATION, debug)
-		//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.LONGDURATION, debug)
-		tickerStripped := tickerItem
-		if strings.HasPrefix(tickerStripped, "X:") {
-			tickerStripped = strings.Split(tickerStripped, ":")[1]
+		for _, d := range durations {
+			tickerData = pkg.CalculateQuantileRiskRanges(tickerData, d, stockDataConfig.RangeCoverage)
 		}
-		if _, ok := tickerData[tickerStripped]; !ok {
-			tickerBatch[tickerStripped] = make(map[int64]pkg.SingleStockCandle)
+		for _, d := range durations {
+			tickerData = pkg.GetLinearRegressionSlope(tickerData, d, stockDataConfig.Trend.LogPrices, debug)
 		}
-
+		for _, d := range durations {
+			tickerData = pkg.CalculateDrawdowns(tickerData, d)
+		}
+		for _, d := range durations {
+			tickerData = pkg.CalculateRiskAdjustedReturns(tickerData, d, stockDataConfig.RiskFreeRate)
+		}
+		for _, d := range durations {
+			tickerData = pkg.CalculateVaR(tickerData, d, stockDataConfig.VaR)
+		}
+		if benchmarkCandles != nil {
+			for _, d := range durations {
+				tickerData = pkg.CalculateBenchmarkStats(tickerData, benchmarkCandles, d)
+			}
+		}
+		tickerData = pkg.GetSimpleSlopes(tickerData, debug)
+		for _, d := range durations {
+			tickerData = pkg.NormalizeSlopes(tickerData, d)
+		}
+		tickerData = pkg.CalculateTrendStrength(tickerData)
+		tickerData = pkg.CalculateTrendDirections(tickerData, stockDataConfig.Trend, debug)
+		tickerData = pkg.ClassifyRegimes(tickerData)
+		tickerData = pkg.CalculateIndicators(tickerData, indicatorSpecs)
+		// Strip every crypto prefix, including both legs of a synthetic series such as X:ETHUSD/X:BTCUSD
+		tickerStripped := strings.ReplaceAll(tickerItem, "X:", "")
 		for ticker, stock := range tickerData {
 			latestDate := int64(0)
-			var rrHigh, rrLow, rvolpct, avgvolratio float64
+			var rrHigh, rrLow, rrCoverage, rvolpct, avgvolratio float64
+			var quantileRange map[string]float64
 			for date := range tickerData[ticker] {
 				// Looking for the "max" date to get the most recent datetime
 				if date > latestDate {
@@ -200,6 +329,7 @@ func main() {
 					rrHigh = stock[latestDate].PTrendRangeAdj["high"]
 					rrLow = stock[latestDate].PTrendRangeAdj["low"]
 				}
+				quantileRange = stock[latestDate].QTrendRange
 				rvolpct = stock[latestDate].RVolPercentMed
 				avgvolratio = stock[latestDate].AvgVolumeRatioMed
 			case "LONG":
@@ -210,6 +340,7 @@ func main() {
 					rrHigh = stock[latestDate].PTailRangeAdj["high"]
 					rrLow = stock[latestDate].PTailRangeAdj["low"]
 				}
+				quantileRange = stock[latestDate].QTailRange
 				rvolpct = stock[latestDate].RVolPercentLong
 				avgvolratio = stock[latestDate].AvgVolumeRatioLong
 			case "SHORT":
@@ -222,9 +353,20 @@ func main() {
 					rrHigh = stock[latestDate].PTradeRangeAdj["high"]
 					rrLow = stock[latestDate].PTradeRangeAdj["low"]
 				}
+				quantileRange = stock[latestDate].QTradeRange
 				rvolpct = stock[latestDate].RVolPercentShort
 				avgvolratio = stock[latestDate].AvgVolumeRatioShort
 			}
+			if hasParams {
+				band := params.Range(stock[latestDate])
+				rrHigh = band["high"]
+				rrLow = band["low"]
+			}
+			if stockDataConfig.RangeModel == pkg.QUANTILERANGEMODEL && quantileRange != nil {
+				rrHigh = quantileRange["high"]
+				rrLow = quantileRange["low"]
+				rrCoverage = quantileRange["coverage"]
+			}
 			batchStockRanges[tickerStripped] = pkg.CondensedRangesJSON{
 				Ticker:         tickerStripped,
 				Close:          stock[latestDate].Close,
@@ -232,18 +374,105 @@ func main() {
 				RVolPercent:    rvolpct,
 				RiskRangeHigh:  rrHigh,
 				RiskRangeLow:   rrLow,
+				RangeCoverage:  rrCoverage,
 				TradeSlope:     stock[latestDate].SlopeShortDuration,
 				TrendSlope:     stock[latestDate].SlopeMedDuration,
 				TailSlope:      stock[latestDate].SlopeLongDuration,
+				TradeSlopePct:  stock[latestDate].SlopePctShort,
+				TrendSlopePct:  stock[latestDate].SlopePctMed,
+				TailSlopePct:   stock[latestDate].SlopePctLong,
+				TradeSlopeVol:  stock[latestDate].SlopeVolShort,
+				TrendSlopeVol:  stock[latestDate].SlopeVolMed,
+				TailSlopeVol:   stock[latestDate].SlopeVolLong,
+				TrendStrength:  stock[latestDate].TrendStrength,
 				TradeDirection: stock[latestDate].TradeDirection,
 				TrendDirection: stock[latestDate].TrendDirection,
 				TailDirection:  stock[latestDate].TailDirection,
+				Regime:         stock[latestDate].Regime,
 				Timestamp:      stock[latestDate].Timestamp,
+				Indicators:     stock[latestDate].Indicators,
+				TradeDrawdown:  stock[latestDate].DrawdownShort,
+				TrendDrawdown:  stock[latestDate].DrawdownMed,
+				TailDrawdown:   stock[latestDate].DrawdownLong,
+				TradeRiskAdj:   stock[latestDate].RiskAdjustedShort,
+				TrendRiskAdj:   stock[latestDate].RiskAdjustedMed,
+				TailRiskAdj:    stock[latestDate].RiskAdjustedLong,
+				TradeVaR:       stock[latestDate].VaRShort,
+				TrendVaR:       stock[latestDate].VaRMed,
+				TailVaR:        stock[latestDate].VaRLong,
 			}
+			if sizePositions {
+				if size, err := pkg.SizePosition(stock, ticker, 0, false, stockDataConfig.Sizing); err != nil {
+					log.Printf("unable to size a position in %s: %v", ticker, err)
+				} else {
+					condensed := batchStockRanges[tickerStripped]
+					condensed.Position = &size
+					batchStockRanges[tickerStripped] = condensed
+				}
+			}
+			if benchmarkCandles != nil {
+				condensed := batchStockRanges[tickerStripped]
+				condensed.Benchmark = benchmark
+				condensed.TradeBeta = stock[latestDate].BetaShort
+				condensed.TrendBeta = stock[latestDate].BetaMed
+				condensed.TailBeta = stock[latestDate].BetaLong
+				condensed.TradeCorr = stock[latestDate].BenchCorrShort
+				condensed.TrendCorr = stock[latestDate].BenchCorrMed
+				condensed.TailCorr = stock[latestDate].BenchCorrLong
+				batchStockRanges[tickerStripped] = condensed
+			}
+			tickerBatch[tickerStripped] = stock
+		}
+	}
+
+	// Section correlates the whole watchlist over the selected duration and scores how past ranges held up
+	correlation := pkg.CalculateCorrelationMatrix(tickerBatch, selectedDuration)
+	calibration := pkg.CalculateRangeCalibration(tickerBatch)
+	report := pkg.BatchReport{Tickers: batchStockRanges, Correlation: &correlation, Calibration: &calibration}
+	if backDated {
+		report.AsOf = endDateFlag
+	}
+
+	// Section reports the direction changes since the previous run of this output file and records them in the history.
+	// A back-dated run has no previous run, so it reports the changes on its last session alone.
+	previous := pkg.BatchReport{}
+	if previousData, err := os.ReadFile(batchStockRangesFile); err == nil && !backDated {
+		if err = json.Unmarshal(previousData, &previous); err != nil {
+			log.Printf("unable to read the previous run from %s: %v", batchStockRangesFile, err)
+		}
+	}
+	changes := pkg.DetectDirectionChanges(tickerBatch)
+	report.Changes = pkg.DirectionChangesSince(changes, previous.Tickers, batchStockRanges)
+	for _, change := range report.Changes {
+		log.Printf("%s: %s", change.Ticker, change)
+	}
+	if historyFile != "" {
+		historyFile = strings.Replace(historyFile, "~", userDir, 1)
+		history, err := pkg.LoadDirectionHistory(historyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		historyData, err := json.MarshalIndent(pkg.MergeDirectionChanges(history, changes), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = os.WriteFile(historyFile, historyData, 0600); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Section aggregates value-at-risk across the configured holdings, keyed like the output tickers
+	if len(stockDataConfig.VaR.Holdings) > 0 {
+		varConf := stockDataConfig.VaR
+		varConf.Holdings = make(map[string]float64, len(stockDataConfig.VaR.Holdings))
+		for ticker, value := range stockDataConfig.VaR.Holdings {
+			varConf.Holdings[strings.TrimPrefix(pkg.NormalizeTicker(ticker), "X:")] += value
 		}
+		portfolio := pkg.CalculatePortfolioVaR(tickerBatch, varConf)
+		report.Portfolio = &portfolio
 	}
 
-	jsonData, err := json.MarshalIndent(batchStockRanges, "", "  ")
+	jsonData, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -254,7 +483,7 @@ func main() {
 
 	if excelOut {
 		excelOutFile := strings.Split(batchStockRangesFile, ".")[0] + ".xlsx"
-		pkg.GenerateStockReportXLSX(batchStockRanges, excelOutFile, showTail)
+		pkg.GenerateStockReportXLSX(report, excelOutFile, showTail)
 	}
 
 	if !noEmail {
@@ -264,3 +493,65 @@ func main() {
 		}
 	}
 }
+
+// fetchSeries pulls the daily candles for a tickers CSV entry: a basket from the config file built from its members'
+// candles, a synthetic ratio or spread of two tickers such as XLK/SPY or GLD-2*SLV built from both legs' candles, or
+// a plain ticker.
+func fetchSeries(stockDataConfig pkg.StockDataConf, item string, startDate, endDate time.Time) (map[string]map[int64]pkg.SingleStockCandle, error) {
+	if basket, ok := stockDataConfig.Basket(item); ok {
+		members := make(map[string]map[int64]pkg.SingleStockCandle, len(basket.Members))
+		for member := range basket.Members {
+			member = pkg.NormalizeTicker(member)
+			candles, err := fetchComponent(stockDataConfig, member, item, startDate, endDate)
+			if err != nil {
+				return nil, err
+			}
+			members[member] = candles
+		}
+		return pkg.BuildBasket(item, basket, members)
+	}
+	spec, ok, err := pkg.ParseSynthetic(item)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return fetchCandles(stockDataConfig, item, startDate, endDate)
+	}
+	legs := make([]map[int64]pkg.SingleStockCandle, 0, 2)
+	for _, leg := range []pkg.SyntheticLeg{spec.Left, spec.Right} {
+		candles, err := fetchComponent(stockDataConfig, leg.Ticker, spec.Name, startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, candles)
+	}
+	return pkg.BuildSynthetic(spec, legs[0], legs[1])
+}
+
+// fetchComponent pulls the daily candles of one ticker making up the series named parent.
+func fetchComponent(stockDataConfig pkg.StockDataConf, ticker, parent string, startDate, endDate time.Time) (map[int64]pkg.SingleStockCandle, error) {
+	tickerData, err := fetchCandles(stockDataConfig, ticker, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	var candles map[int64]pkg.SingleStockCandle
+	for _, c := range tickerData {
+		candles = c
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles for %s, part of %s", ticker, parent)
+	}
+	return candles, nil
+}
+
+// fetchCandles pulls daily candles for ticker from Alpaca when an Alpaca key is configured, otherwise from Polygon.
+func fetchCandles(stockDataConfig pkg.StockDataConf, ticker string, startDate, endDate time.Time) (map[string]map[int64]pkg.SingleStockCandle, error) {
+	if stockDataConfig.AlpacaAPIKey != "" {
+		return pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", startDate, endDate, debug)
+	}
+	tickerData, err := pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", startDate, endDate)
+	if err != nil {
+		log.Printf("unable to get stock prices for %s", ticker)
+	}
+	return tickerData, nil
+}
@@ -1,31 +1,33 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"github.com/khrystoph/portfoliotools/pkg"
-	"google.golang.org/appengine/log"
 	"time"
 )
 
 var (
-	startTime, endTime   string
-	costBasis, currPrice float64
-	short                bool
+	startTime, endTime, assetClass, returns string
+	costBasis, currPrice                    float64
+	short                                   bool
 )
 
 func init() {
 	flag.StringVar(&startTime, "startTime", "30 days ago", "Enter a time to start gathering data "+
 		"for the ticker. Time must be formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
 	flag.StringVar(&endTime, "endTime", "Today",
-		"Enter a time to end gathering data for the ticker. Time must be formatted as YYYY-MM-DDTHH:MM:SSZ."+
-			" Time will always assume UTC.")
+		"Enter the date the position is valued as of, or sold on. Time must be formatted as "+
+			"YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
 	flag.Float64Var(&costBasis, "costBasis", 1, "input the cost basis in decimal form. "+
 		"Example: 12.34")
 	flag.Float64Var(&currPrice, "currentPrice", 1, "input the current price in decimal form. "+
 		"Example: 12.34")
 	flag.BoolVar(&short, "short", false, "default: False. Presence of the flag means true.")
+	flag.StringVar(&assetClass, "assetClass", "", "asset class whose sessions count toward the holding period: "+
+		"equity, etf, index, commodity (exchange trading days), crypto (every day), or forex (weekdays). "+
+		"Default counts calendar days.")
+	flag.StringVar(&returns, "returns", "", "compounding used to annualize: simple or log. Default is simple.")
 }
 
 func main() {
@@ -40,18 +42,27 @@ func main() {
 		fmt.Printf("Unable to convert startTime to milliseconds.\nstartTime: %s\n", startTime)
 		return
 	}
-	//TODO: change GetTargetAnnualReturn in TechAnalysis.go to accept optional endTimeMilli, then uncomment below
-	/* endTimeMilli, err := time.Parse(time.RFC3339, endTime)
+	endTimeMilli, err := time.Parse(time.RFC3339, endTime)
 	if err != nil {
-		fmt.Printf("Unable to convert endTime to milliseconds.\n")
+		fmt.Printf("Unable to convert endTime to milliseconds.\nendTime: %s\n", endTime)
+		return
+	}
+
+	class, err := pkg.ParseAssetClass(assetClass)
+	if err != nil {
+		fmt.Printf("Unable to use -assetClass: %v\n", err)
+		return
+	}
+	if err = pkg.SetReturnConf(pkg.ReturnConf{Convention: returns}); err != nil {
+		fmt.Printf("Unable to use -returns: %v\n", err)
 		return
 	}
-	*/
 
 	fmt.Println("Current Annualized Returns Selected")
-	currAnnualReturn, err := pkg.GetCurrAnnualReturn(currPrice, costBasis, startTimeMilli, short)
+	currAnnualReturn, err := pkg.AnnualizedReturn(class, currPrice, costBasis, startTimeMilli, endTimeMilli, short)
 	if err != nil {
-		log.Errorf(context.TODO(), "unable to process the current annualized return.\n")
+		fmt.Printf("Unable to process the current annualized return: %v\n", err)
+		return
 	}
 	fmt.Printf("Current Annualized return is: %f.\n", currAnnualReturn)
 }
@@ -7,10 +7,11 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 var (
-	inFile, ticker string
+	inFile, ticker, historyFile, since, until, direction string
 )
 
 func init() {
@@ -18,6 +19,12 @@ func init() {
 	flag.StringVar(&inFile, "f", "stockRanges.json", "name of the json file to parse")
 	flag.StringVar(&ticker, "ticker", "ZZZ", "the ticker to filter for out of the data")
 	flag.StringVar(&ticker, "t", "ZZZ", "the ticker to filter for out of the data")
+	flag.StringVar(&historyFile, "history", "", "direction change history written by batchStocks -history. When "+
+		"set, prints the direction changes of -t, or of every ticker without -t, instead of its ranges.")
+	flag.StringVar(&since, "since", "", "earliest direction change to print, formatted as YYYY-MM-DD")
+	flag.StringVar(&until, "until", "", "latest direction change to print, formatted as YYYY-MM-DD")
+	flag.StringVar(&direction, "direction", "", "only print changes of one direction: TradeDirection, "+
+		"TrendDirection, or TailDirection")
 }
 
 func main() {
@@ -29,14 +36,27 @@ func main() {
 	if err != nil {
 		log.Printf("error reading user's homedir: %v", err)
 	}
+	if historyFile != "" {
+		queryHistory()
+		return
+	}
 	readFile, err := os.ReadFile(inFile)
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = json.Unmarshal(readFile, &data)
+	report := pkg.BatchReport{}
+	err = json.Unmarshal(readFile, &report)
 	if err != nil {
 		log.Fatal(err)
 	}
+	data = report.Tickers
+	// Files written before the batch report gained sections are a bare map of ticker to ranges
+	if data == nil {
+		err = json.Unmarshal(readFile, &data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	ticker = strings.ToUpper(ticker)
 	if _, ok := data[ticker]; ok {
@@ -47,3 +67,34 @@ func main() {
 		log.Printf("%s:\n%s\n", ticker, jsonData)
 	}
 }
+
+// queryHistory prints the direction changes in the history file that match the ticker, direction, and date flags.
+// Without -t it prints the changes of every ticker.
+func queryHistory() {
+	history, err := pkg.LoadDirectionHistory(historyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	query := pkg.DirectionChangeQuery{Field: direction}
+	// Only filter on a ticker the user asked for, so a plain history query covers the whole watchlist
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "t" || f.Name == "ticker" {
+			query.Ticker = strings.ToUpper(ticker)
+		}
+	})
+	if since != "" {
+		if query.Since, err = time.Parse(time.DateOnly, since); err != nil {
+			log.Fatalf("unable to parse -since %s: %v", since, err)
+		}
+	}
+	if until != "" {
+		if query.Until, err = time.Parse(time.DateOnly, until); err != nil {
+			log.Fatalf("unable to parse -until %s: %v", until, err)
+		}
+		// Include every change dated on the -until day itself
+		query.Until = query.Until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	for _, change := range pkg.FilterDirectionChanges(history, query) {
+		log.Printf("%s: %s\n", change.Ticker, change)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	transactionsFile, tickerConfig, csvFile, broker string
+	dryRun                                          bool
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing custom import mappings. Default is: .stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing custom import mappings. Default is: .stockclientconfig.json")
+	flag.StringVar(&csvFile, "csv", "", "path to the broker activity CSV export to import")
+	flag.StringVar(&broker, "broker", "generic", "import mapping to read the CSV with: a mapping under importers "+
+		"in the config, or one of alpaca, schwab, fidelity, vanguard, or generic")
+	flag.StringVar(&transactionsFile, "transactions", "transactions.json", "path to the json ledger the "+
+		"transactions are merged into; created if it does not exist")
+	flag.BoolVar(&dryRun, "n", false, "report what would be imported without writing the ledger")
+}
+
+func main() {
+	flag.Parse()
+	if csvFile == "" {
+		log.Fatal("-csv is required")
+	}
+
+	// Section loads custom import mappings from the config file, when there is one
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	stockDataConfig := pkg.StockDataConf{}
+	if configFile, err := os.Open(tickerConfig); err == nil {
+		defer configFile.Close()
+		if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+			log.Fatalf("error decoding the json config file: %v", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	mapping, err := pkg.ImportMappingFor(broker, stockDataConfig.Importers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	imported, skipped, err := pkg.ImportTransactions(strings.Replace(csvFile, "~", userDir, 1), mapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+	transactionsFile = strings.Replace(transactionsFile, "~", userDir, 1)
+	existing, err := pkg.LoadTransactions(transactionsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	merged, added := pkg.MergeTransactions(existing, imported)
+	fmt.Printf("Read %d transactions from %s: %d new, %d already in the ledger. Skipped %d other rows.\n",
+		len(imported), csvFile, added, len(imported)-added, skipped)
+
+	// Replaying the merged ledger catches sells of shares the ledger never saw bought
+	if _, err = pkg.BuildLedger(merged, pkg.LOTFIFO); err != nil {
+		log.Printf("warning: the merged ledger does not replay: %v", err)
+	}
+	if dryRun || added == 0 {
+		return
+	}
+	if err = pkg.SaveTransactions(transactionsFile, merged); err != nil {
+		log.Fatal(err)
+	}
+}
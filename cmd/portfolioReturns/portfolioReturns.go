@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	holdingsFile, transactionsFile, method, tickerConfig, outFile, fromDate, toDate string
+	targetAnnualizedRate                                                            float64
+	excelOut                                                                        bool
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&holdingsFile, "holdings", "holdings.csv", "path to the holdings csv file with the columns "+
+		"ticker,quantity,cost-basis,open-date,side")
+	flag.StringVar(&transactionsFile, "transactions", "", "path to a json ledger of buy and sell transactions. "+
+		"When set, each open lot is a position instead of the rows of -holdings.")
+	flag.StringVar(&method, "method", pkg.LOTFIFO, "how sells in -transactions are matched to lots: fifo, lifo, "+
+		"or specific-id")
+	flag.StringVar(&fromDate, "from", "", "start of the period (YYYY-MM-DD) the time- and money-weighted returns "+
+		"of -transactions are measured over. Default is the day before the first transaction.")
+	flag.StringVar(&toDate, "to", "", "date (YYYY-MM-DD) the portfolio is valued as of and the period ends on. "+
+		"Default is today.")
+	flag.Float64Var(&targetAnnualizedRate, "targetRate", .06,
+		"enter either the risk-free rate or the rate you want as your target return rate. Default is: .06 (6%).")
+	flag.StringVar(&outFile, "o", "portfolioReturns.json", "output file for the portfolio report")
+	flag.StringVar(&outFile, "outfile", "portfolioReturns.json", "output file for the portfolio report")
+	flag.BoolVar(&excelOut, "x", false, "Writes a file in excel format using same outfile name as -o "+
+		"except it swaps the file type")
+	flag.BoolVar(&excelOut, "excelfmt", false, "Writes a file in excel format using same outfile name as -o "+
+		"except it swaps the file type")
+}
+
+func main() {
+	flag.Parse()
+
+	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	if _, err = os.Stat(tickerConfig); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error: config file %s does not exist. exiting", tickerConfig)
+	}
+	configFile, err := os.Open(tickerConfig)
+	if err != nil {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		log.Fatalf("error decoding the json config file: %v", err)
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Fatalf("error in the returns config: %v", err)
+	}
+
+	asOf := time.Now()
+	if toDate != "" {
+		if asOf, err = time.Parse(time.DateOnly, toDate); err != nil {
+			log.Fatalf("error parsing -to: %v", err)
+		}
+	}
+	var from time.Time
+	if fromDate != "" {
+		if from, err = time.Parse(time.DateOnly, fromDate); err != nil {
+			log.Fatalf("error parsing -from: %v", err)
+		}
+	}
+
+	var (
+		holdings     []pkg.Holding
+		transactions []pkg.Transaction
+	)
+	if transactionsFile != "" {
+		transactions, err = pkg.LoadTransactions(strings.Replace(transactionsFile, "~", userDir, 1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ledger, err := pkg.BuildLedger(transactions, method)
+		if err != nil {
+			log.Fatal(err)
+		}
+		holdings = ledger.Holdings()
+	} else if holdings, err = pkg.LoadHoldings(strings.Replace(holdingsFile, "~", userDir, 1)); err != nil {
+		log.Fatal(err)
+	}
+
+	// Section fetches the latest close of every ticker held, once per ticker
+	prices := map[string]float64{}
+	for _, holding := range holdings {
+		if _, ok := prices[holding.Ticker]; ok {
+			continue
+		}
+		price, err := latestClose(stockDataConfig, holding.Ticker, asOf)
+		if err != nil {
+			log.Printf("unable to price %s: %v", holding.Ticker, err)
+			continue
+		}
+		prices[holding.Ticker] = price
+	}
+
+	report := pkg.EvaluateHoldings(holdings, prices, targetAnnualizedRate, asOf)
+	if len(transactions) > 0 {
+		performance, err := evaluatePerformance(stockDataConfig, transactions, from, asOf)
+		if err != nil {
+			log.Printf("unable to measure performance: %v", err)
+		} else {
+			report.Performance = &performance
+		}
+	}
+	for _, p := range report.Positions {
+		switch {
+		case p.Error != "":
+			fmt.Printf("%s: %s\n", p.Ticker, p.Error)
+		case p.AboveTarget:
+			fmt.Printf("%s: %.2f%% a year, above its %.2f target price.\n", p.Ticker, p.CurrentReturn*100,
+				p.TargetPrice)
+		default:
+			fmt.Printf("%s: %.2f%% a year, below its %.2f target price.\n", p.Ticker, p.CurrentReturn*100,
+				p.TargetPrice)
+		}
+	}
+	fmt.Printf("%d of %d positions are above the %.2f%% target rate.\n", report.AboveTarget, len(report.Positions),
+		targetAnnualizedRate*100)
+	if report.Performance != nil {
+		p := report.Performance.Portfolio
+		fmt.Printf("From %s to %s: time-weighted %.2f%% (%.2f%% a year), money-weighted %.2f%% a year.\n",
+			report.Performance.From.Format(time.DateOnly), report.Performance.To.Format(time.DateOnly),
+			p.TimeWeighted*100, p.AnnualizedTimeWeighted*100, p.MoneyWeighted*100)
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(outFile, jsonData, 0600); err != nil {
+		log.Fatal(err)
+	}
+	if excelOut {
+		excelOutFile := strings.Split(outFile, ".")[0] + ".xlsx"
+		if err = pkg.GenerateHoldingsReportXLSX(report, excelOutFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// dailyCandles fetches ticker's daily candles from start to end.
+func dailyCandles(stockDataConfig pkg.StockDataConf, ticker string,
+	start, end time.Time) (map[string]map[int64]pkg.SingleStockCandle, error) {
+	if stockDataConfig.AlpacaAPIKey != "" {
+		return pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", start, end, false)
+	}
+	return pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", start, end)
+}
+
+// latestClose returns the close of ticker's latest daily candle in the ten days up to asOf.
+func latestClose(stockDataConfig pkg.StockDataConf, ticker string, asOf time.Time) (float64, error) {
+	tickerData, err := dailyCandles(stockDataConfig, ticker, asOf.AddDate(0, 0, -10), asOf)
+	if err != nil {
+		return 0, err
+	}
+	for _, price := range pkg.LatestCloses(tickerData) {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no candles in the ten days to %s", asOf.Format(time.DateOnly))
+}
+
+// evaluatePerformance fetches the daily history of every ticker in the transactions over the period, from the
+// first transaction when from is zero, and measures the time- and money-weighted returns.
+func evaluatePerformance(stockDataConfig pkg.StockDataConf, transactions []pkg.Transaction, from,
+	to time.Time) (pkg.PerformanceReport, error) {
+	start := from
+	if start.IsZero() {
+		start = transactions[0].Date
+		for _, tx := range transactions {
+			if tx.Date.Before(start) {
+				start = tx.Date
+			}
+		}
+	}
+	prices := map[string]map[int64]pkg.SingleStockCandle{}
+	for _, tx := range transactions {
+		ticker := pkg.NormalizeTicker(tx.Ticker)
+		if _, ok := prices[ticker]; ok || ticker == "" {
+			continue
+		}
+		// Start a few days early so the start date has a close even if it fell on a holiday
+		tickerData, err := dailyCandles(stockDataConfig, ticker, start.AddDate(0, 0, -10), to)
+		if err != nil {
+			log.Printf("unable to fetch the history of %s, valuing it at its trade prices: %v", ticker, err)
+			prices[ticker] = nil
+			continue
+		}
+		// The single series returned is the ticker's, whatever the data source keyed it by
+		for _, candles := range tickerData {
+			prices[ticker] = candles
+		}
+	}
+	return pkg.EvaluatePerformance(transactions, prices, from, to)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ticker, tickerConfig, method          string
+	equity, riskPercent, entry            float64
+	volMultiple, atrMultiple, rangeAdjust float64
+	atrPeriod                             int
+	short                                 bool
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&ticker, "ticker", "", "ticker to size a position in")
+	flag.StringVar(&ticker, "t", "", "ticker to size a position in")
+	flag.Float64Var(&equity, "equity", 0, "account equity. Overrides the sizing section of the config file.")
+	flag.Float64Var(&riskPercent, "risk", 0, "fraction of equity to risk on the trade in decimal form, e.g. .01 "+
+		"for 1%. Overrides the sizing section of the config file.")
+	flag.Float64Var(&entry, "entry", 0, "entry price. Default is the latest close.")
+	flag.BoolVar(&short, "short", false, "default: False. Presence of the flag means true.")
+	flag.StringVar(&method, "method", "", "where to place the stop: range (the far side of the trade range), "+
+		"volatility, or atr. Overrides the sizing section of the config file; default is range.")
+	flag.Float64Var(&volMultiple, "volMultiple", 0, "trade-duration standard deviations between the entry and "+
+		"the stop for the volatility method. Default is 1.")
+	flag.IntVar(&atrPeriod, "atrPeriod", 0, "sessions in the average true range for the atr method. Default is 14.")
+	flag.Float64Var(&atrMultiple, "atrMultiple", 0, "average true ranges between the entry and the stop for the "+
+		"atr method. Default is 2.")
+	flag.Float64Var(&rangeAdjust, "rangeAdj", 0, "probability adjustment of the trade range for the range method. "+
+		"Overrides probable-range-adj in the config file.")
+}
+
+func main() {
+	flag.Parse()
+	if ticker == "" {
+		log.Fatal("-ticker is required")
+	}
+
+	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	if _, err = os.Stat(tickerConfig); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error: config file %s does not exist. exiting", tickerConfig)
+	}
+	configFile, err := os.Open(tickerConfig)
+	if err != nil {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		log.Fatalf("error decoding the json config file: %v", err)
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Fatalf("error in the returns config: %v", err)
+	}
+
+	// Section layers the flags over the sizing section of the config file
+	conf := stockDataConfig.Sizing
+	if equity != 0 {
+		conf.Equity = equity
+	}
+	if riskPercent != 0 {
+		conf.RiskPercent = riskPercent
+	}
+	if method != "" {
+		conf.Method = method
+	}
+	if volMultiple != 0 {
+		conf.VolMultiple = volMultiple
+	}
+	if atrPeriod != 0 {
+		conf.ATRPeriod = atrPeriod
+	}
+	if atrMultiple != 0 {
+		conf.ATRMultiple = atrMultiple
+	}
+	if rangeAdjust != 0 {
+		stockDataConfig.RangeAdjustment = rangeAdjust
+	}
+	if err = conf.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Section fetches a year of daily candles and computes the trade volatility and ranges the stop is placed from
+	ticker = pkg.NormalizeTicker(ticker)
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	var tickerData map[string]map[int64]pkg.SingleStockCandle
+	if stockDataConfig.AlpacaAPIKey != "" {
+		tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", start, end, false)
+	} else {
+		tickerData, err = pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", start, end)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	tickerData = pkg.RangePipeline(stockDataConfig)(tickerData)
+	var candles map[int64]pkg.SingleStockCandle
+	for _, c := range tickerData {
+		candles = c
+	}
+
+	size, err := pkg.SizePosition(candles, ticker, entry, short, conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	side := "long"
+	if size.Short {
+		side = "short"
+	}
+	fmt.Printf("%s %s: entry %.2f, stop %.2f (%s), %.2f at risk per share.\n", ticker, side, size.Entry, size.Stop,
+		size.Method, size.RiskPerShare)
+	if size.Target != 0 {
+		fmt.Printf("Target at the far side of the trade range: %.2f.\n", size.Target)
+	}
+	fmt.Printf("Suggested size: %g shares costing %.2f, risking %.2f of %.2f equity.\n", size.Shares,
+		size.PositionValue, size.RiskAmount, conf.Equity)
+}
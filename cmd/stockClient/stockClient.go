@@ -81,6 +81,24 @@ func main() {
 		log.Printf("error decoding the json config file: %v", err)
 		os.Exit(1)
 	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Printf("error in the returns config: %v", err)
+		os.Exit(1)
+	}
+	if err = pkg.RegisterBaskets(stockDataConfig.Baskets); err != nil {
+		log.Printf("error in the baskets config: %v", err)
+		os.Exit(1)
+	}
+
+	indicatorSpecs, err := pkg.ParseIndicatorSpecs(stockDataConfig.Indicators)
+	if err != nil {
+		log.Printf("error parsing indicators from the config file: %v", err)
+		os.Exit(1)
+	}
+	if err = stockDataConfig.VaR.Validate(); err != nil {
+		log.Printf("error in the var section of the config file: %v", err)
+		os.Exit(1)
+	}
 
 	startTimeMilli, err := time.Parse(time.RFC3339, startTime)
 	if err != nil {
@@ -95,7 +113,13 @@ func main() {
 	// retrieve stock ticker's prices and store in a map
 
 	ticker = pkg.NormalizeTicker(ticker)
-	if stockDataConfig.AlpacaAPIKey != "" {
+	if basket, ok := stockDataConfig.Basket(ticker); ok {
+		tickerData, err = fetchBasket(stockDataConfig, basket, startTimeMilli, endTimeMilli)
+		if err != nil {
+			log.Printf("unable to build basket %s: %v", ticker, err)
+			os.Exit(1)
+		}
+	} else if stockDataConfig.AlpacaAPIKey != "" {
 		switch resolution {
 		case "minute", "Minute", "MINUTE", "M", "m":
 			resolution = "1T"
@@ -154,14 +178,59 @@ func main() {
 	for _, d := range durations {
 		tickerData = pkg.GetProbAdjRiskRanges(tickerData, d, stockDataConfig.RangeAdjustment)
 	}
+	for _, d := range durations {
+		tickerData = pkg.CalculateQuantileRiskRanges(tickerData, d, stockDataConfig.RangeCoverage)
+	}
+	for _, d := range durations {
+		tickerData = pkg.GetLinearRegressionSlope(tickerData, d, stockDataConfig.Trend.LogPrices, debug)
+	}
+	for _, d := range durations {
+		tickerData = pkg.CalculateDrawdowns(tickerData, d)
+	}
+	for _, d := range durations {
+		tickerData = pkg.CalculateRiskAdjustedReturns(tickerData, d, stockDataConfig.RiskFreeRate)
+	}
+	for _, d := range durations {
+		tickerData = pkg.CalculateVaR(tickerData, d, stockDataConfig.VaR)
+	}
 	tickerData = pkg.GetSimpleSlopes(tickerData, debug)
-	tickerData = pkg.CalculateTrendDirections(tickerData, debug)
-	//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.SHORTDURATION, debug)
-	//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.MEDIUMDURATION, debug)
-	//tickerData = pkg.GetLinearRegressionSlope(tickerData, pkg.LONGDURATION, debug)
+	tickerData = pkg.CalculateTrendDirections(tickerData, stockDataConfig.Trend, debug)
+	tickerData = pkg.ClassifyRegimes(tickerData)
+	tickerData = pkg.CalculateIndicators(tickerData, indicatorSpecs)
 
 	if err != nil {
 		log.Printf("error occurred: %v", err)
 	}
 	pkg.PrintData(tickerData, debug)
 }
+
+// fetchBasket pulls daily candles for every member of the basket and builds its index series. Baskets are built from
+// daily bars only.
+func fetchBasket(stockDataConfig pkg.StockDataConf, basket pkg.BasketConf, startTime,
+	endTime time.Time) (map[string]map[int64]pkg.SingleStockCandle, error) {
+	switch resolution {
+	case "DAY", "day", "Day", "D", "d":
+	default:
+		return nil, fmt.Errorf("baskets support only the day resolution, got %s", resolution)
+	}
+	members := make(map[string]map[int64]pkg.SingleStockCandle, len(basket.Members))
+	for member := range basket.Members {
+		member = pkg.NormalizeTicker(member)
+		var (
+			memberData map[string]map[int64]pkg.SingleStockCandle
+			err        error
+		)
+		if stockDataConfig.AlpacaAPIKey != "" {
+			memberData, err = pkg.GetStockPricesAlpaca(stockDataConfig, member, "1D", startTime, endTime, debug)
+		} else {
+			memberData, err = pkg.GetStockPrices(member, stockDataConfig.PolygonAPIToken, "day", startTime, endTime)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, candles := range memberData {
+			members[member] = candles
+		}
+	}
+	return pkg.BuildBasket(ticker, basket, members)
+}
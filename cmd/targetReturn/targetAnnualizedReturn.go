@@ -1,31 +1,49 @@
 package main
 
 import (
-	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/khrystoph/portfoliotools/pkg"
-	"google.golang.org/appengine/log"
+	"os"
+	"strings"
 	"time"
 )
 
 var (
-	startTime, endTime              string
-	costBasis, targetAnnualizedRate float64
-	short                           bool
+	startTime, endTime, ticker, hitBy, tickerConfig string
+	assetClass, returns                             string
+	costBasis, targetAnnualizedRate, drift          float64
+	short                                           bool
+	paths                                           int
+	seed                                            uint64
 )
 
 func init() {
 	flag.StringVar(&startTime, "startTime", "30 days ago", "Enter a time to start gathering data "+
 		"for the ticker. Time must be formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
 	flag.StringVar(&endTime, "endTime", "Today",
-		"Enter a time to end gathering data for the ticker. Time must be formatted as YYYY-MM-DDTHH:MM:SSZ."+
-			" Time will always assume UTC.")
+		"Enter the exit date the target price must be reached by to earn the target rate. Time must be "+
+			"formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
 	flag.Float64Var(&costBasis, "costBasis", 1, "input the cost basis in decimal form. "+
 		"Example: 12.34")
 	flag.Float64Var(&targetAnnualizedRate, "targetRate", .06,
 		"enter either the risk-free rate or the rate you want as your target return rate. Default is: .06 (6%).")
 	flag.BoolVar(&short, "short", false, "default: False. Presence of the flag means true.")
+	flag.StringVar(&ticker, "ticker", "", "ticker to simulate. When set, estimates the probability of the price "+
+		"reaching the target price with Monte Carlo simulation.")
+	flag.StringVar(&hitBy, "by", "30 days from now", "date the target price must be reached by for the "+
+		"simulation. Time must be formatted as YYYY-MM-DDTHH:MM:SSZ. Time will always assume UTC.")
+	flag.IntVar(&paths, "paths", 10000, "number of simulated price paths.")
+	flag.Uint64Var(&seed, "seed", 0, "random seed for the simulation, so runs can be reproduced.")
+	flag.Float64Var(&drift, "drift", 0, "expected annual return in decimal form used to drift the GBM "+
+		"simulation. Default is 0.")
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data, used with -ticker.")
+	flag.StringVar(&assetClass, "assetClass", "", "asset class whose sessions count toward the holding period: "+
+		"equity, etf, index, commodity (exchange trading days), crypto (every day), or forex (weekdays). "+
+		"Default is the class of -ticker, or calendar days without one.")
+	flag.StringVar(&returns, "returns", "", "compounding used to annualize: simple or log. Default is simple.")
 }
 
 func main() {
@@ -40,17 +58,99 @@ func main() {
 		fmt.Printf("Unable to convert startTime to milliseconds.\nstartTime: %s\n", startTime)
 		return
 	}
-	//TODO: change GetTargetAnnualReturn in TechAnalysis.go to accept optional endTimeMilli, then uncomment below
-	/* endTimeMilli, err := time.Parse(time.RFC3339, endTime)
+	endTimeMilli, err := time.Parse(time.RFC3339, endTime)
 	if err != nil {
-		fmt.Printf("Unable to convert endTime to milliseconds.\n")
+		fmt.Printf("Unable to convert endTime to milliseconds.\nendTime: %s\n", endTime)
 		return
 	}
-	*/
 
-	targetAnnualReturn, err := pkg.GetTargetAnnualReturn(costBasis, targetAnnualizedRate, startTimeMilli, short)
+	class, err := pkg.ParseAssetClass(assetClass)
 	if err != nil {
-		log.Errorf(context.TODO(), "unable to process the target annualized return")
+		fmt.Printf("Unable to use -assetClass: %v\n", err)
+		return
+	}
+	if class == "" && ticker != "" {
+		class = pkg.AssetClassOf(ticker)
+	}
+	if err = pkg.SetReturnConf(pkg.ReturnConf{Convention: returns}); err != nil {
+		fmt.Printf("Unable to use -returns: %v\n", err)
+		return
+	}
+
+	targetAnnualReturn, err := pkg.AnnualTargetPrice(class, costBasis, targetAnnualizedRate, startTimeMilli,
+		endTimeMilli, short)
+	if err != nil {
+		fmt.Printf("Unable to process the target annualized return: %v\n", err)
+		return
 	}
 	fmt.Printf("Target Price is: %f.\n", targetAnnualReturn)
+
+	if ticker != "" {
+		if err = simulateTarget(targetAnnualReturn); err != nil {
+			fmt.Printf("Unable to simulate the target price: %v\n", err)
+		}
+	}
+}
+
+// simulateTarget fetches a year of daily candles for ticker and prints the GBM and bootstrap probabilities of
+// reaching target by the -by date.
+func simulateTarget(target float64) error {
+	by := time.Now().AddDate(0, 0, pkg.SHORTDURATION)
+	if hitBy != "30 days from now" {
+		var err error
+		if by, err = time.Parse(time.RFC3339, hitBy); err != nil {
+			return fmt.Errorf("unable to parse -by %s: %v", hitBy, err)
+		}
+	}
+
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	configFile, err := os.Open(strings.Replace(tickerConfig, "~", userDir, 1))
+	if err != nil {
+		return err
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		return err
+	}
+	if returns != "" {
+		stockDataConfig.Returns.Convention = returns
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		return err
+	}
+
+	ticker = pkg.NormalizeTicker(ticker)
+	end := time.Now()
+	start := end.AddDate(-1, 0, 0)
+	var tickerData map[string]map[int64]pkg.SingleStockCandle
+	if stockDataConfig.AlpacaAPIKey != "" {
+		tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", start, end, false)
+	} else {
+		tickerData, err = pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", start, end)
+	}
+	if err != nil {
+		return err
+	}
+	var candles map[int64]pkg.SingleStockCandle
+	for _, c := range tickerData {
+		candles = c
+	}
+
+	conf := pkg.SimulationConf{Paths: paths, Seed: seed, Drift: drift}
+	results, err := pkg.SimulateTargetHit(candles, ticker, target, by, conf)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		fmt.Printf("%s: %d paths over %d sessions from %.2f. P(touch %.2f) = %.1f%%, P(finish beyond) = %.1f%%.\n",
+			r.Method, r.Paths, r.Sessions, r.Spot, r.Target, r.TouchProbability*100, r.FinishProbability*100)
+		fmt.Printf("  terminal price: p5 %.2f, p25 %.2f, median %.2f, p75 %.2f, p95 %.2f, mean %.2f\n",
+			r.Terminal["p5"], r.Terminal["p25"], r.Terminal["p50"], r.Terminal["p75"], r.Terminal["p95"],
+			r.Terminal["mean"])
+	}
+	return nil
 }
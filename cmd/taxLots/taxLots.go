@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	transactionsFile, tickerConfig, outFile, method string
+	excelOut                                        bool
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&transactionsFile, "transactions", "transactions.json", "path to the json ledger of buy and "+
+		"sell transactions")
+	flag.StringVar(&method, "method", pkg.LOTFIFO, "how sells are matched to lots: fifo, lifo, or specific-id "+
+		"(every sell names its lot-id)")
+	flag.StringVar(&outFile, "o", "taxLots.json", "output file for the lot report")
+	flag.StringVar(&outFile, "outfile", "taxLots.json", "output file for the lot report")
+	flag.BoolVar(&excelOut, "x", false, "Writes a file in excel format using same outfile name as -o "+
+		"except it swaps the file type")
+	flag.BoolVar(&excelOut, "excelfmt", false, "Writes a file in excel format using same outfile name as -o "+
+		"except it swaps the file type")
+}
+
+func main() {
+	flag.Parse()
+
+	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	if _, err = os.Stat(tickerConfig); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error: config file %s does not exist. exiting", tickerConfig)
+	}
+	configFile, err := os.Open(tickerConfig)
+	if err != nil {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		log.Fatalf("error decoding the json config file: %v", err)
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Fatalf("error in the returns config: %v", err)
+	}
+
+	transactions, err := pkg.LoadTransactions(strings.Replace(transactionsFile, "~", userDir, 1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ledger, err := pkg.BuildLedger(transactions, method)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Section fetches the latest close of every ticker with an open lot, once per ticker
+	asOf := time.Now()
+	prices := map[string]float64{}
+	for _, lot := range ledger.Open {
+		if _, ok := prices[lot.Ticker]; ok {
+			continue
+		}
+		price, err := latestClose(stockDataConfig, lot.Ticker, asOf)
+		if err != nil {
+			log.Printf("unable to price %s, valuing it at cost: %v", lot.Ticker, err)
+			continue
+		}
+		prices[lot.Ticker] = price
+	}
+
+	report := ledger.Report(prices, asOf)
+	fmt.Printf("%d open lots: unrealized %.2f short-term, %.2f long-term.\n", len(report.Open),
+		report.Unrealized[pkg.SHORTTERM], report.Unrealized[pkg.LONGTERM])
+	fmt.Printf("%d closed lots: realized %.2f short-term, %.2f long-term.\n", len(report.Realized),
+		report.Gains[pkg.SHORTTERM], report.Gains[pkg.LONGTERM])
+	var dividends float64
+	for _, amount := range report.Dividends {
+		dividends += amount
+	}
+	if dividends != 0 || report.Fees != 0 {
+		fmt.Printf("Dividends %.2f, fees outside of trades %.2f.\n", dividends, report.Fees)
+	}
+
+	jsonData, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(outFile, jsonData, 0600); err != nil {
+		log.Fatal(err)
+	}
+	if excelOut {
+		excelOutFile := strings.Split(outFile, ".")[0] + ".xlsx"
+		if err = pkg.GenerateLotReportXLSX(report, excelOutFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// latestClose returns the close of ticker's latest daily candle in the ten days up to asOf.
+func latestClose(stockDataConfig pkg.StockDataConf, ticker string, asOf time.Time) (float64, error) {
+	var (
+		tickerData map[string]map[int64]pkg.SingleStockCandle
+		err        error
+	)
+	start := asOf.AddDate(0, 0, -10)
+	if stockDataConfig.AlpacaAPIKey != "" {
+		tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", start, asOf, false)
+	} else {
+		tickerData, err = pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", start, asOf)
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, price := range pkg.LatestCloses(tickerData) {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no candles in the ten days to %s", asOf.Format(time.DateOnly))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/khrystoph/portfoliotools/pkg"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	csvFile, tickerConfig, objective, estimators, durations, paramsFile, reportFile string
+	trainSessions, testSessions, years                                              int
+)
+
+func init() {
+	flag.StringVar(&tickerConfig, "config", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&tickerConfig, "c", ".stockclientconfig.json",
+		"path to the json config file containing credentials for ticker data. Default is: "+
+			".stockclientconfig.json")
+	flag.StringVar(&csvFile, "f", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl")
+	flag.StringVar(&csvFile, "file", "tickers.csv", "path to csv file of tickers in format: ABC,X:DEF,ghi,x:jkl")
+	flag.StringVar(&objective, "objective", "calibration", "what the optimizer maximizes: calibration "+
+		"(hit rate closest to the range-coverage in the config), return, or sharpe")
+	flag.IntVar(&trainSessions, "train", 126, "sessions in each training window")
+	flag.IntVar(&testSessions, "test", 21, "sessions in each out-of-sample test window")
+	flag.IntVar(&years, "years", 2, "years of history to optimize over")
+	flag.StringVar(&estimators, "estimators", pkg.VOLESTIMATORVOLUMEADJ, "comma-separated volatility "+
+		"estimators to choose between: volume-adjusted, realized")
+	flag.StringVar(&durations, "durations", "SHORT", "comma-separated range durations to choose between: "+
+		"SHORT, MEDIUM, LONG")
+	flag.StringVar(&paramsFile, "o", "tickerParams.json", "output file for the chosen per-ticker parameters, "+
+		"read by batchStocks -params")
+	flag.StringVar(&reportFile, "report", "walkForward.json", "output file for the fold by fold report")
+}
+
+func main() {
+	flag.Parse()
+
+	// Section parses the config file location, opens it, decodes the JSON and loads the API creds
+	userDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Printf("error reading user's homedir: %v", err)
+	}
+	tickerConfig = strings.Replace(tickerConfig, "~", userDir, 1)
+	if _, err = os.Stat(tickerConfig); errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("error: config file %s does not exist. exiting", tickerConfig)
+	}
+	configFile, err := os.Open(tickerConfig)
+	if err != nil {
+		log.Fatalf("error opening the config file: %v", err)
+	}
+	defer configFile.Close()
+	stockDataConfig := pkg.StockDataConf{}
+	if err = json.NewDecoder(configFile).Decode(&stockDataConfig); err != nil {
+		log.Fatalf("error decoding the json config file: %v", err)
+	}
+	if err = pkg.SetReturnConf(stockDataConfig.Returns); err != nil {
+		log.Fatalf("error in the returns config: %v", err)
+	}
+
+	conf := pkg.WalkForwardConf{
+		Objective:     objective,
+		TrainSessions: trainSessions,
+		TestSessions:  testSessions,
+		Estimators:    strings.Split(estimators, ","),
+		Coverage:      stockDataConfig.RangeCoverage,
+	}
+	for _, d := range strings.Split(durations, ",") {
+		switch strings.ToUpper(strings.TrimSpace(d)) {
+		case "SHORT":
+			conf.Durations = append(conf.Durations, pkg.SHORTDURATION)
+		case "MEDIUM":
+			conf.Durations = append(conf.Durations, pkg.MEDIUMDURATION)
+		case "LONG":
+			conf.Durations = append(conf.Durations, pkg.LONGDURATION)
+		default:
+			log.Fatalf("unknown duration %q", d)
+		}
+	}
+
+	file, err := os.Open(csvFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var tickers []string
+	for _, row := range rows {
+		tickers = append(tickers, row...)
+	}
+
+	end := time.Now()
+	start := end.AddDate(-years, 0, 0)
+	params := map[string]pkg.TickerParams{}
+	var report []pkg.WalkForwardResult
+	for _, ticker := range tickers {
+		ticker = pkg.NormalizeTicker(ticker)
+		var tickerData map[string]map[int64]pkg.SingleStockCandle
+		if stockDataConfig.AlpacaAPIKey != "" {
+			tickerData, err = pkg.GetStockPricesAlpaca(stockDataConfig, ticker, "1D", start, end, false)
+		} else {
+			tickerData, err = pkg.GetStockPrices(ticker, stockDataConfig.PolygonAPIToken, "day", start, end)
+		}
+		if err != nil {
+			log.Printf("unable to retrieve stock data for %s: %v", ticker, err)
+			continue
+		}
+		var candles map[int64]pkg.SingleStockCandle
+		for _, c := range tickerData {
+			candles = c
+		}
+		result, err := pkg.WalkForward(ticker, candles, stockDataConfig, conf)
+		if err != nil {
+			log.Printf("unable to optimize %s: %v", ticker, err)
+			continue
+		}
+		params[ticker] = result.Params
+		report = append(report, result)
+		fmt.Printf("%s: probable-range-adj %.2f, %s, %d day range. Out-of-sample %s %.4f over %d folds.\n",
+			ticker, result.Params.RangeAdjustment, result.Params.VolEstimator, result.Params.Duration,
+			result.Objective, result.OutOfSample, len(result.Folds))
+	}
+
+	for path, v := range map[string]any{paramsFile: params, reportFile: report} {
+		jsonData, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = os.WriteFile(path, jsonData, 0600); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"time"
 
@@ -9,13 +11,29 @@ import (
 )
 
 // GenerateStockReportXLSX writes the stock report to an Excel file.
-// showTail adds Tail Slope and Tail Dir columns (13 cols total vs default 11).
-func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath string, showTail bool) error {
+// showTail adds Tail Slope, Tail Slope %, Tail Slope σ, and Tail Dir columns (21 cols total vs default 17).
+// The header row carries a filter so every column can be sorted.
+// Stop and Shares columns follow Timestamp when any ticker carries a position size, then any indicators present on the
+// data are appended as one column each.
+// A Correlation sheet is added when the report carries benchmark or correlation data.
+func GenerateStockReportXLSX(report BatchReport, outputPath string, showTail bool) error {
+	data := report.Tickers
 	tickers := make([]string, 0, len(data))
+	indicatorSet := map[string]bool{}
+	hasSizing := false
 	for t := range data {
 		tickers = append(tickers, t)
+		hasSizing = hasSizing || data[t].Position != nil
+		for name := range data[t].Indicators {
+			indicatorSet[name] = true
+		}
 	}
 	sort.Strings(tickers)
+	indicatorCols := make([]string, 0, len(indicatorSet))
+	for name := range indicatorSet {
+		indicatorCols = append(indicatorCols, name)
+	}
+	sort.Strings(indicatorCols)
 
 	f := excelize.NewFile()
 	sheet := "Stock Report"
@@ -32,16 +50,32 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 	if showTail {
 		headers = append(headers, "Tail Slope")
 	}
-	headers = append(headers, "Trade Dir", "Trend Dir")
+	headers = append(headers, "Trade Slope %", "Trend Slope %")
+	if showTail {
+		headers = append(headers, "Tail Slope %")
+	}
+	headers = append(headers, "Trade Slope σ", "Trend Slope σ")
+	if showTail {
+		headers = append(headers, "Tail Slope σ")
+	}
+	headers = append(headers, "Strength", "Trade Dir", "Trend Dir")
 	if showTail {
 		headers = append(headers, "Tail Dir")
 	}
-	headers = append(headers, "Timestamp")
+	headers = append(headers, "Regime", "Timestamp")
+	if hasSizing {
+		headers = append(headers, "Stop", "Shares")
+	}
+	headers = append(headers, indicatorCols...)
 
 	lastCol, _ := excelize.ColumnNumberToName(len(headers))
 
 	title := "Stock Range Report"
 	subtitle := fmt.Sprintf("Generated on %s", time.Now().Format("January 2, 2006"))
+	if asOf, err := time.Parse(time.DateOnly, report.AsOf); err == nil {
+		subtitle = fmt.Sprintf("As of %s, generated on %s", asOf.Format("January 2, 2006"),
+			time.Now().Format("January 2, 2006"))
+	}
 	f.MergeCell(sheet, "A1", lastCol+"1")
 	f.MergeCell(sheet, "A2", lastCol+"2")
 
@@ -114,18 +148,10 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 		Border:    border,
 	})
 
-	// Direction column indices (0-based).
-	// Default (no tail): 0:Ticker 1:Close 2:AvgVolRatio 3:RVol% 4:VAPRLow 5:VAPRHigh
-	//   6:TradeSlope 7:TrendSlope 8:TradeDir 9:TrendDir 10:Timestamp
-	// With tail: same through 7:TrendSlope, then 8:TailSlope 9:TradeDir 10:TrendDir 11:TailDir 12:Timestamp
-	dirColTrade := 8
-	dirColTrend := 9
-	dirColTail := -1
-	if showTail {
-		dirColTrade = 9
-		dirColTrend = 10
-		dirColTail = 11
-	}
+	// Direction column indices (0-based); the tail columns are only present with showTail.
+	dirColTrade := slices.Index(headers, "Trade Dir")
+	dirColTrend := slices.Index(headers, "Trend Dir")
+	dirColTail := slices.Index(headers, "Tail Dir")
 
 	rowStart := headerRow + 1
 	rowIndex := rowStart
@@ -146,11 +172,34 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 		if showTail {
 			row = append(row, fmt.Sprintf("%.4f", s.TailSlope))
 		}
-		row = append(row, s.TradeDirection, s.TrendDirection)
+		// Normalized slopes and strength are written as numbers so the columns sort numerically.
+		row = append(row, roundTo(s.TradeSlopePct*100, 2), roundTo(s.TrendSlopePct*100, 2))
+		if showTail {
+			row = append(row, roundTo(s.TailSlopePct*100, 2))
+		}
+		row = append(row, roundTo(s.TradeSlopeVol, 2), roundTo(s.TrendSlopeVol, 2))
+		if showTail {
+			row = append(row, roundTo(s.TailSlopeVol, 2))
+		}
+		row = append(row, roundTo(s.TrendStrength, 1), s.TradeDirection, s.TrendDirection)
 		if showTail {
 			row = append(row, s.TailDirection)
 		}
-		row = append(row, s.Timestamp.Format("2006-01-02"))
+		row = append(row, s.Regime, s.Timestamp.Format("2006-01-02"))
+		if hasSizing {
+			if s.Position != nil {
+				row = append(row, roundTo(s.Position.Stop, 2), s.Position.Shares)
+			} else {
+				row = append(row, "", "")
+			}
+		}
+		for _, name := range indicatorCols {
+			if v, ok := s.Indicators[name]; ok {
+				row = append(row, fmt.Sprintf("%.4f", v))
+			} else {
+				row = append(row, "")
+			}
+		}
 
 		for i, val := range row {
 			cell, _ := excelize.CoordinatesToCellName(i+1, rowIndex)
@@ -174,6 +223,14 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 		rowIndex++
 	}
 
+	if rowIndex > rowStart {
+		firstCell, _ := excelize.CoordinatesToCellName(1, headerRow)
+		lastCell, _ := excelize.CoordinatesToCellName(len(headers), rowIndex-1)
+		if err := f.AutoFilter(sheet, firstCell+":"+lastCell, nil); err != nil {
+			return fmt.Errorf("failed to add filter to stock report: %v", err)
+		}
+	}
+
 	f.SetPanes(sheet, &excelize.Panes{
 		Freeze:      true,
 		YSplit:      headerRow,
@@ -192,15 +249,43 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 
 	var widths []float64
 	if showTail {
-		widths = []float64{12, 10, 14, 12, 12, 12, 12, 12, 12, 12, 12, 12, 18}
+		widths = []float64{12, 10, 14, 12, 12, 12, 12, 12, 12, 14, 14, 14, 14, 14, 14, 10, 12, 12, 12, 34, 18}
 	} else {
-		widths = []float64{12, 10, 14, 12, 12, 12, 12, 12, 12, 12, 18}
+		widths = []float64{12, 10, 14, 12, 12, 12, 12, 12, 14, 14, 14, 14, 10, 12, 12, 34, 18}
+	}
+	if hasSizing {
+		widths = append(widths, 10, 10)
+	}
+	for range indicatorCols {
+		widths = append(widths, 16)
 	}
 	for i, w := range widths {
 		col, _ := excelize.ColumnNumberToName(i + 1)
 		f.SetColWidth(sheet, col, col, w)
 	}
 
+	if err := addDrawdownSheet(f, tickers, data); err != nil {
+		return err
+	}
+	if report.Correlation != nil {
+		if err := addCorrelationSheet(f, report); err != nil {
+			return err
+		}
+	}
+	if err := addVaRSheet(f, tickers, report); err != nil {
+		return err
+	}
+	if report.Calibration != nil {
+		if err := addCalibrationSheet(f, tickers, report.Calibration); err != nil {
+			return err
+		}
+	}
+	if len(report.Changes) > 0 {
+		if err := addDirectionChangesSheet(f, report.Changes); err != nil {
+			return err
+		}
+	}
+
 	if err := f.SaveAs(outputPath); err != nil {
 		return fmt.Errorf("failed to save XLSX: %v", err)
 	}
@@ -208,6 +293,308 @@ func GenerateStockReportXLSX(data map[string]CondensedRangesJSON, outputPath str
 	return nil
 }
 
+// addDrawdownSheet writes the max and current drawdown, how long the current drawdown has lasted, and how long the
+// deepest drawdown took to recover, for each duration window.
+func addDrawdownSheet(f *excelize.File, tickers []string, data map[string]CondensedRangesJSON) error {
+	sheet := "Drawdowns"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create drawdown sheet: %v", err)
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	headers := []string{"Ticker"}
+	for _, d := range []string{"Trade", "Trend", "Tail"} {
+		headers = append(headers, d+" Max DD", d+" Cur DD", d+" DD Days", d+" Recovery")
+	}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	for r, ticker := range tickers {
+		s := data[ticker]
+		row := []interface{}{ticker}
+		for _, dd := range []Drawdown{s.TradeDrawdown, s.TrendDrawdown, s.TailDrawdown} {
+			recovery := "-"
+			switch {
+			case dd.RecoveryDays < 0:
+				recovery = "Not recovered"
+			case dd.RecoveryDays > 0:
+				recovery = fmt.Sprintf("%d", dd.RecoveryDays)
+			}
+			row = append(row,
+				fmt.Sprintf("%.2f%%", dd.Max*100),
+				fmt.Sprintf("%.2f%%", dd.Current*100),
+				dd.Duration,
+				recovery,
+			)
+		}
+		for i, v := range row {
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			f.SetCellValue(sheet, cell, v)
+		}
+	}
+	f.SetColWidth(sheet, "A", "A", 12)
+	f.SetColWidth(sheet, "B", "M", 14)
+	return nil
+}
+
+// addVaRSheet writes one row per ticker, duration, and confidence level with the historical, parametric, and
+// Cornish-Fisher VaR and expected shortfall, followed by the same rows for the portfolio when holdings are configured.
+func addVaRSheet(f *excelize.File, tickers []string, report BatchReport) error {
+	sheet := "Value at Risk"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create value at risk sheet: %v", err)
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	headers := []string{"Ticker", "Window", "Confidence", "Horizon", "Hist VaR", "Param VaR", "CF VaR",
+		"Hist ES", "Param ES", "CF ES"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	row := 2
+	writeRows := func(name string, windows map[string][]VaR) {
+		for _, window := range []string{"Trade", "Trend", "Tail"} {
+			for _, v := range windows[window] {
+				values := []interface{}{name, window,
+					fmt.Sprintf("%.1f%%", v.Confidence*100),
+					fmt.Sprintf("%.1f", v.Horizon),
+					fmt.Sprintf("%.2f%%", v.Historical*100),
+					fmt.Sprintf("%.2f%%", v.Parametric*100),
+					fmt.Sprintf("%.2f%%", v.CornishFisher*100),
+					fmt.Sprintf("%.2f%%", v.HistoricalES*100),
+					fmt.Sprintf("%.2f%%", v.ParametricES*100),
+					fmt.Sprintf("%.2f%%", v.CornishFisherES*100),
+				}
+				for i, value := range values {
+					cell, _ := excelize.CoordinatesToCellName(i+1, row)
+					f.SetCellValue(sheet, cell, value)
+				}
+				row++
+			}
+		}
+	}
+	for _, ticker := range tickers {
+		s := report.Tickers[ticker]
+		writeRows(ticker, map[string][]VaR{"Trade": s.TradeVaR, "Trend": s.TrendVaR, "Tail": s.TailVaR})
+	}
+	if p := report.Portfolio; p != nil {
+		writeRows(fmt.Sprintf("Portfolio (%.2f)", p.Value), map[string][]VaR{"Trade": p.Trade, "Trend": p.Trend,
+			"Tail": p.Tail})
+	}
+	f.SetColWidth(sheet, "A", "A", 20)
+	f.SetColWidth(sheet, "B", "J", 12)
+	return nil
+}
+
+// addCalibrationSheet writes how often each range contained the price over its duration, per ticker and then for
+// the whole batch, one row per range.
+func addCalibrationSheet(f *excelize.File, tickers []string, calibration *CalibrationReport) error {
+	sheet := "Range Calibration"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create range calibration sheet: %v", err)
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	headers := []string{"Ticker", "Range", "Horizon (Calendar Days)", "Samples", "Path Within", "Breach High",
+		"Breach Low", "Close Within", "Stated Coverage (Close)"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	var names []string
+	for _, cr := range calibratedRanges() {
+		names = append(names, cr.name)
+	}
+	row := 2
+	writeRows := func(label string, results map[string]RangeCalibration) {
+		for _, name := range names {
+			c, ok := results[name]
+			if !ok {
+				continue
+			}
+			coverage := "-"
+			if c.StatedCoverage > 0 {
+				coverage = fmt.Sprintf("%.1f%%", c.StatedCoverage*100)
+			}
+			values := []interface{}{label, name, c.HorizonDays, c.Samples,
+				fmt.Sprintf("%.1f%%", c.WithinRate*100),
+				fmt.Sprintf("%.1f%%", c.BreachHighRate*100),
+				fmt.Sprintf("%.1f%%", c.BreachLowRate*100),
+				fmt.Sprintf("%.1f%%", c.CloseWithinRate*100),
+				coverage,
+			}
+			for i, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				f.SetCellValue(sheet, cell, value)
+			}
+			row++
+		}
+	}
+	for _, ticker := range tickers {
+		writeRows(ticker, calibration.Tickers[ticker])
+	}
+	writeRows("All", calibration.Aggregate)
+	f.SetColWidth(sheet, "A", "A", 12)
+	f.SetColWidth(sheet, "B", "B", 24)
+	f.SetColWidth(sheet, "C", "C", 22)
+	f.SetColWidth(sheet, "D", "I", 14)
+	return nil
+}
+
+// addDirectionChangesSheet writes the direction changes since the last run, one row per change, with the new label
+// colored like the direction columns.
+func addDirectionChangesSheet(f *excelize.File, changes []DirectionChange) error {
+	sheet := "Direction Changes"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create direction changes sheet: %v", err)
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	bullishStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#70AD47"}},
+	})
+	bearishStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#FF0000"}},
+	})
+	neutralStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#A5A5A5"}},
+	})
+	indeterminateStyle, _ := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 7, Color: []string{"#FF0000", "#FFFFFF"}},
+	})
+	headers := []string{"Date", "Ticker", "Direction", "From", "To"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	for i, c := range changes {
+		row := i + 2
+		values := []interface{}{c.Date.Format(time.DateOnly), c.Ticker, c.Field, c.From, c.To}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+		cell, _ := excelize.CoordinatesToCellName(len(values), row)
+		f.SetCellStyle(sheet, cell, cell,
+			directionCellStyle(c.To, bullishStyle, bearishStyle, neutralStyle, indeterminateStyle))
+	}
+	f.SetColWidth(sheet, "A", "B", 12)
+	f.SetColWidth(sheet, "C", "C", 16)
+	f.SetColWidth(sheet, "D", "E", 14)
+	return nil
+}
+
+// addCorrelationSheet writes each ticker's beta and correlation against the benchmark followed by the pairwise
+// correlation matrix of the watchlist.
+func addCorrelationSheet(f *excelize.File, report BatchReport) error {
+	sheet := "Correlation"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create correlation sheet: %v", err)
+	}
+	matrix := report.Correlation
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	titleStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14, Color: "#004B87"},
+	})
+	// Matrix cells stay numeric so the colour scale below can read them.
+	twoDecimals := "0.00"
+	numberStyle, _ := f.NewStyle(&excelize.Style{
+		CustomNumFmt: &twoDecimals,
+		Alignment:    &excelize.Alignment{Horizontal: "center"},
+	})
+
+	benchmark := ""
+	for _, t := range matrix.Tickers {
+		if report.Tickers[t].Benchmark != "" {
+			benchmark = report.Tickers[t].Benchmark
+			break
+		}
+	}
+	row := 1
+	if benchmark != "" {
+		f.SetCellValue(sheet, "A1", fmt.Sprintf("Beta and correlation vs %s", benchmark))
+		f.SetCellStyle(sheet, "A1", "A1", titleStyle)
+		headers := []string{"Ticker", "Trade Beta", "Trend Beta", "Tail Beta", "Trade Corr", "Trend Corr", "Tail Corr"}
+		for i, h := range headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 3)
+			f.SetCellValue(sheet, cell, h)
+			f.SetCellStyle(sheet, cell, cell, headerStyle)
+		}
+		row = 4
+		for _, t := range matrix.Tickers {
+			s := report.Tickers[t]
+			values := []interface{}{t,
+				fmt.Sprintf("%.2f", s.TradeBeta), fmt.Sprintf("%.2f", s.TrendBeta), fmt.Sprintf("%.2f", s.TailBeta),
+				fmt.Sprintf("%.2f", s.TradeCorr), fmt.Sprintf("%.2f", s.TrendCorr), fmt.Sprintf("%.2f", s.TailCorr),
+			}
+			for i, v := range values {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				f.SetCellValue(sheet, cell, v)
+			}
+			row++
+		}
+		row++
+	}
+
+	titleCell := fmt.Sprintf("A%d", row)
+	f.SetCellValue(sheet, titleCell, fmt.Sprintf("Pairwise correlation of daily returns (%d days)", matrix.Duration))
+	f.SetCellStyle(sheet, titleCell, titleCell, titleStyle)
+	row += 2
+	for i, t := range matrix.Tickers {
+		cell, _ := excelize.CoordinatesToCellName(i+2, row)
+		f.SetCellValue(sheet, cell, t)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	for i, a := range matrix.Tickers {
+		cell, _ := excelize.CoordinatesToCellName(1, row+i+1)
+		f.SetCellValue(sheet, cell, a)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+		for j, b := range matrix.Tickers {
+			if corr, ok := matrix.Correlations[a][b]; ok {
+				cell, _ := excelize.CoordinatesToCellName(j+2, row+i+1)
+				f.SetCellValue(sheet, cell, corr)
+				f.SetCellStyle(sheet, cell, cell, numberStyle)
+			}
+		}
+	}
+	if len(matrix.Tickers) > 0 {
+		first, _ := excelize.CoordinatesToCellName(2, row+1)
+		last, _ := excelize.CoordinatesToCellName(len(matrix.Tickers)+1, row+len(matrix.Tickers))
+		f.SetConditionalFormat(sheet, first+":"+last, []excelize.ConditionalFormatOptions{{
+			Type:     "3_color_scale",
+			Criteria: "=",
+			MinType:  "num", MinValue: "-1", MinColor: "#5A8AC6",
+			MidType: "num", MidValue: "0", MidColor: "#FFFFFF",
+			MaxType: "num", MaxValue: "1", MaxColor: "#F8696B",
+		}})
+	}
+	f.SetColWidth(sheet, "A", "A", 12)
+	return nil
+}
+
 // directionCellStyle returns the excelize style ID for a given direction label.
 func directionCellStyle(direction string, bullish, bearish, neutral, indeterminate int) int {
 	switch direction {
@@ -221,3 +608,214 @@ func directionCellStyle(direction string, bullish, bearish, neutral, indetermina
 		return indeterminate
 	}
 }
+
+// roundTo rounds v to the given number of decimal places.
+func roundTo(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+// GenerateHoldingsReportXLSX writes the portfolio report to an Excel file, one row per position with the Above
+// Target column filled green for positions ahead of their target rate and red for those behind it.
+func GenerateHoldingsReportXLSX(report HoldingsReport, outputPath string) error {
+	f := excelize.NewFile()
+	sheet := "Positions"
+	index, err := f.NewSheet(sheet)
+	if err != nil {
+		return fmt.Errorf("failed to create positions sheet: %v", err)
+	}
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	aboveStyle, _ := f.NewStyle(&excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#70AD47"}},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	belowStyle, _ := f.NewStyle(&excelize.Style{
+		Fill:      excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#FF0000"}},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Portfolio returns as of %s against a %.2f%% target rate",
+		report.AsOf.Format(time.DateOnly), report.TargetRate*100))
+	headers := []string{"Ticker", "Side", "Quantity", "Cost Basis", "Open Date", "Price", "Market Value",
+		"Unrealized Gain", "Current Annual %", "Target Price", "Above Target", "Error"}
+	headerRow := 3
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, headerRow)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	aboveCol := slices.Index(headers, "Above Target") + 1
+	row := headerRow + 1
+	for _, p := range report.Positions {
+		side := "Long"
+		if p.Short {
+			side = "Short"
+		}
+		values := []interface{}{p.Ticker, side, p.Quantity, roundTo(p.CostBasis, 2), p.OpenDate.Format(time.DateOnly)}
+		if p.Price == 0 {
+			values = append(values, "", "", "", "", "", "", p.Error)
+		} else if p.Error != "" {
+			values = append(values, roundTo(p.Price, 2), roundTo(p.MarketValue, 2), roundTo(p.UnrealizedGain, 2), "",
+				"", "", p.Error)
+		} else {
+			values = append(values, roundTo(p.Price, 2), roundTo(p.MarketValue, 2), roundTo(p.UnrealizedGain, 2),
+				roundTo(p.CurrentReturn*100, 2), roundTo(p.TargetPrice, 2), p.AboveTarget, "")
+		}
+		for i, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(i+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+		if p.Error == "" {
+			cell, _ := excelize.CoordinatesToCellName(aboveCol, row)
+			if p.AboveTarget {
+				f.SetCellStyle(sheet, cell, cell, aboveStyle)
+			} else {
+				f.SetCellStyle(sheet, cell, cell, belowStyle)
+			}
+		}
+		row++
+	}
+	totals := []interface{}{"Total", "", "", roundTo(report.CostBasis, 2), "", "", roundTo(report.MarketValue, 2),
+		roundTo(report.UnrealizedGain, 2), "", "", fmt.Sprintf("%d of %d", report.AboveTarget, len(report.Positions))}
+	for i, value := range totals {
+		cell, _ := excelize.CoordinatesToCellName(i+1, row)
+		f.SetCellValue(sheet, cell, value)
+	}
+	if row > headerRow+1 {
+		firstCell, _ := excelize.CoordinatesToCellName(1, headerRow)
+		lastCell, _ := excelize.CoordinatesToCellName(len(headers), row-1)
+		if err := f.AutoFilter(sheet, firstCell+":"+lastCell, nil); err != nil {
+			return fmt.Errorf("failed to add filter to positions: %v", err)
+		}
+	}
+	f.SetColWidth(sheet, "A", "A", 14)
+	f.SetColWidth(sheet, "B", "K", 14)
+	f.SetColWidth(sheet, "L", "L", 40)
+
+	if report.Performance != nil {
+		if err := writePerformanceSheet(f, *report.Performance, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SaveAs(outputPath); err != nil {
+		return fmt.Errorf("failed to save XLSX: %v", err)
+	}
+	fmt.Printf("XLSX generated successfully at: %s\n", outputPath)
+	return nil
+}
+
+// writePerformanceSheet adds a Performance sheet with the time- and money-weighted returns of the portfolio and each
+// position.
+func writePerformanceSheet(f *excelize.File, performance PerformanceReport, headerStyle int) error {
+	sheet := "Performance"
+	if _, err := f.NewSheet(sheet); err != nil {
+		return fmt.Errorf("failed to create performance sheet: %v", err)
+	}
+	f.SetCellValue(sheet, "A1", fmt.Sprintf("Performance from %s to %s", performance.From.Format(time.DateOnly),
+		performance.To.Format(time.DateOnly)))
+	headers := []string{"Ticker", "Start Value", "Invested", "Withdrawn", "End Value", "TWR %", "Annualized TWR %",
+		"MWR (XIRR) %", "Error"}
+	headerRow := 3
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, headerRow)
+		f.SetCellValue(sheet, cell, h)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+	portfolio := performance.Portfolio
+	portfolio.Ticker = "Portfolio"
+	for r, p := range append([]PeriodReturn{portfolio}, performance.Positions...) {
+		values := []interface{}{p.Ticker, roundTo(p.StartValue, 2), roundTo(p.Invested, 2), roundTo(p.Withdrawn, 2),
+			roundTo(p.EndValue, 2), roundTo(p.TimeWeighted*100, 2), roundTo(p.AnnualizedTimeWeighted*100, 2),
+			roundTo(p.MoneyWeighted*100, 2), p.Error}
+		if p.Error != "" {
+			values[7] = ""
+		}
+		for i, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(i+1, headerRow+1+r)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+	f.SetColWidth(sheet, "A", "H", 16)
+	f.SetColWidth(sheet, "I", "I", 40)
+	return nil
+}
+
+// GenerateLotReportXLSX writes the tax-lot report to an Excel file: an Open Lots sheet with each lot's unrealized
+// gain and a Realized Lots sheet with each closed lot's realized gain, each ending in short- and long-term totals.
+func GenerateLotReportXLSX(report LotReport, outputPath string) error {
+	f := excelize.NewFile()
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Color: "#FFFFFF"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#1F4E79"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+	writeSheet := func(sheet string, headers []string, rows [][]interface{}, totals map[string]float64) error {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return fmt.Errorf("failed to create %s sheet: %v", sheet, err)
+		}
+		for i, h := range headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheet, cell, h)
+			f.SetCellStyle(sheet, cell, cell, headerStyle)
+		}
+		for r, values := range rows {
+			for i, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+				f.SetCellValue(sheet, cell, value)
+			}
+		}
+		if len(rows) > 0 {
+			lastCell, _ := excelize.CoordinatesToCellName(len(headers), len(rows)+1)
+			if err := f.AutoFilter(sheet, "A1:"+lastCell, nil); err != nil {
+				return fmt.Errorf("failed to add filter to %s: %v", sheet, err)
+			}
+		}
+		gainCol := slices.Index(headers, "Gain") + 1
+		for i, term := range []string{SHORTTERM, LONGTERM} {
+			row := len(rows) + 3 + i
+			label, _ := excelize.CoordinatesToCellName(gainCol-1, row)
+			total, _ := excelize.CoordinatesToCellName(gainCol, row)
+			f.SetCellValue(sheet, label, "Total "+term)
+			f.SetCellValue(sheet, total, roundTo(totals[term], 2))
+		}
+		lastCol, _ := excelize.ColumnNumberToName(len(headers))
+		f.SetColWidth(sheet, "A", lastCol, 14)
+		return nil
+	}
+
+	var open [][]interface{}
+	for _, lot := range report.Open {
+		open = append(open, []interface{}{lot.ID, lot.Ticker, lot.OpenDate.Format(time.DateOnly), lot.Quantity,
+			roundTo(lot.CostBasis, 4), roundTo(lot.Price, 2), roundTo(lot.MarketValue, 2), lot.Term,
+			roundTo(lot.Gain, 2), roundTo(lot.AnnualReturn*100, 2)})
+	}
+	if err := writeSheet("Open Lots", []string{"Lot", "Ticker", "Open Date", "Quantity", "Cost Basis", "Price",
+		"Market Value", "Term", "Gain", "Annual %"}, open, report.Unrealized); err != nil {
+		return err
+	}
+	var realized [][]interface{}
+	for _, lot := range report.Realized {
+		realized = append(realized, []interface{}{lot.LotID, lot.Ticker, lot.OpenDate.Format(time.DateOnly),
+			lot.CloseDate.Format(time.DateOnly), lot.Quantity, roundTo(lot.CostBasis, 2), roundTo(lot.Proceeds, 2),
+			lot.Term, roundTo(lot.Gain, 2), roundTo(lot.AnnualReturn*100, 2)})
+	}
+	if err := writeSheet("Realized Lots", []string{"Lot", "Ticker", "Open Date", "Close Date", "Quantity",
+		"Cost Basis", "Proceeds", "Term", "Gain", "Annual %"}, realized, report.Gains); err != nil {
+		return err
+	}
+	f.DeleteSheet("Sheet1")
+
+	if err := f.SaveAs(outputPath); err != nil {
+		return fmt.Errorf("failed to save XLSX: %v", err)
+	}
+	fmt.Printf("XLSX generated successfully at: %s\n", outputPath)
+	return nil
+}
@@ -75,6 +75,10 @@ func PrepareToPrintData(stockPrices map[string]map[int64]SingleStockCandle) (con
 				AvgVolumeShort:      stockPrices[ticker][dateInt64].AvgVolumeShort,
 				AvgVolumeRatioShort: stockPrices[ticker][dateInt64].AvgVolumeRatioShort,
 				TradeSlope:          stockPrices[ticker][dateInt64].SlopeShortDuration,
+				TradeRegression:     stockPrices[ticker][dateInt64].RegressionShort,
+				TradeDrawdown:       stockPrices[ticker][dateInt64].DrawdownShort,
+				TradeRiskAdjusted:   stockPrices[ticker][dateInt64].RiskAdjustedShort,
+				TradeVaR:            stockPrices[ticker][dateInt64].VaRShort,
 				RVolShort:           stockPrices[ticker][dateInt64].RealizedVolatilityShort,
 				RVolShortVel:        stockPrices[ticker][dateInt64].VelocityRealizedVolShort,
 				RVolShortAccel:      stockPrices[ticker][dateInt64].RealizedVolAccelShort,
@@ -83,10 +87,15 @@ func PrepareToPrintData(stockPrices map[string]map[int64]SingleStockCandle) (con
 				RVolLowShort:        stockPrices[ticker][dateInt64].RVolLowShort,
 				TradeRangeAdj:       stockPrices[ticker][dateInt64].TradeRangeAdj,
 				PtradeRangeAdj:      stockPrices[ticker][dateInt64].PTradeRangeAdj,
+				QTradeRange:         stockPrices[ticker][dateInt64].QTradeRange,
 				// medium duration
 				AvgVolumeMed:      stockPrices[ticker][dateInt64].AvgVolumeMed,
 				AvgVolumeRatioMed: stockPrices[ticker][dateInt64].AvgVolumeRatioMed,
 				TrendSlope:        stockPrices[ticker][dateInt64].SlopeMedDuration,
+				TrendRegression:   stockPrices[ticker][dateInt64].RegressionMed,
+				TrendDrawdown:     stockPrices[ticker][dateInt64].DrawdownMed,
+				TrendRiskAdjusted: stockPrices[ticker][dateInt64].RiskAdjustedMed,
+				TrendVaR:          stockPrices[ticker][dateInt64].VaRMed,
 				RVolMed:           stockPrices[ticker][dateInt64].RealizedVolatilityMed,
 				RVolMedVel:        stockPrices[ticker][dateInt64].VelocityRealizedVolMed,
 				RVolMedAccel:      stockPrices[ticker][dateInt64].RealizedVolAccelMed,
@@ -95,10 +104,15 @@ func PrepareToPrintData(stockPrices map[string]map[int64]SingleStockCandle) (con
 				RVolLowMed:        stockPrices[ticker][dateInt64].RVolLowMed,
 				TrendRangeAdj:     stockPrices[ticker][dateInt64].TrendRangeAdj,
 				PTrendRangeAdj:    stockPrices[ticker][dateInt64].PTrendRangeAdj,
+				QTrendRange:       stockPrices[ticker][dateInt64].QTrendRange,
 				// long duration
 				AvgVolumeLong:      stockPrices[ticker][dateInt64].AvgVolumeLong,
 				AvgVolumeRatioLong: stockPrices[ticker][dateInt64].AvgVolumeRatioLong,
 				TailSlope:          stockPrices[ticker][dateInt64].SlopeLongDuration,
+				TailRegression:     stockPrices[ticker][dateInt64].RegressionLong,
+				TailDrawdown:       stockPrices[ticker][dateInt64].DrawdownLong,
+				TailRiskAdjusted:   stockPrices[ticker][dateInt64].RiskAdjustedLong,
+				TailVaR:            stockPrices[ticker][dateInt64].VaRLong,
 				RVolLong:           stockPrices[ticker][dateInt64].RealizedVolatilityLong,
 				RVolLongVel:        stockPrices[ticker][dateInt64].VelocityRealizedVolLong,
 				RVolLongAccel:      stockPrices[ticker][dateInt64].RealizedVolAccelLong,
@@ -107,9 +121,12 @@ func PrepareToPrintData(stockPrices map[string]map[int64]SingleStockCandle) (con
 				RVolLowLong:        stockPrices[ticker][dateInt64].RVolLowLong,
 				TailRangeAdj:       stockPrices[ticker][dateInt64].TailRangeAdj,
 				PTailRangeAdj:      stockPrices[ticker][dateInt64].PTailRangeAdj,
+				QTailRange:         stockPrices[ticker][dateInt64].QTailRange,
 				TradeDirection:     stockPrices[ticker][dateInt64].TradeDirection,
 				TrendDirection:     stockPrices[ticker][dateInt64].TrendDirection,
 				TailDirection:      stockPrices[ticker][dateInt64].TailDirection,
+				Regime:             stockPrices[ticker][dateInt64].Regime,
+				Indicators:         stockPrices[ticker][dateInt64].Indicators,
 			}
 		}
 	}
@@ -120,54 +137,23 @@ func truncateToDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
 
+// annualization is the number of sessions per year of the ticker's asset class.
 func annualization(ticker string) (daysInYear float64) {
-	if strings.HasPrefix(strings.ToUpper(ticker), "X:") {
-		return YEAR
-	} else {
-		return float64(TRADINGDAYSPERYEAR)
-	}
+	return SessionsPerYear(AssetClassOf(ticker))
 }
 
-func GetCurrAnnualReturn(currentPrice, costBasis float64, purchaseDate time.Time, isShort bool) (currentAnnualizedReturn float64, err error) {
-	hoursOwned := truncateToDay(time.Now()).Sub(truncateToDay(purchaseDate)).Hours()
-	daysOwned := hoursOwned / DAY
-	var totReturn float64
-	if !isShort {
-		totReturn = currentPrice - costBasis
-	} else {
-		totReturn = costBasis - currentPrice
-	}
-
-	fmt.Printf("Hours owned: %f.\n", hoursOwned)
-	currentAnnualizedReturn = math.Pow((totReturn+costBasis)/costBasis, YEAR/daysOwned) - 1
-
-	return currentAnnualizedReturn, nil
+// GetCurrAnnualReturn is AnnualizedReturn over the calendar days from purchaseDate to asOf, for positions without an
+// asset class. It compounds by the configured returns convention; use AnnualizedReturn to count the sessions of the
+// position's asset class instead.
+func GetCurrAnnualReturn(currentPrice, costBasis float64, purchaseDate, asOf time.Time, isShort bool) (currentAnnualizedReturn float64, err error) {
+	return AnnualizedReturn("", currentPrice, costBasis, purchaseDate, asOf, isShort)
 }
 
-/* GetTargetAnnualReturn uses the formula: AP = ((P + G) / P) ^ (365 / n) - 1 solving for G given
- * AP, P, and n. N is days owned, P is costBasis, and AP is the risk-free rate/target rate
- * The new formula should look like this: G = (365/n)√(AP + 1) * P - P
- * We can drop the "-P" at the end because we don't just want the gains, we want the new price as the price target.
- * Additionally, since a root of a number is an inverse power, we can flip the 365/n to be n/365 and use math.Pow.
- */
-func GetTargetAnnualReturn(costBasis, riskFreeRate float64, purchaseDate time.Time, isShort bool) (targetAnnualReturnPrice float64, err error) {
-	//TODO: Implement changes in the function to accept optional "endTimeMilli"
-	hoursOwned := truncateToDay(time.Now()).Sub(truncateToDay(purchaseDate)).Hours()
-	daysOwned := hoursOwned / DAY
-
-	baseReturn := math.Pow(riskFreeRate+1, daysOwned/YEAR) * costBasis
-
-	if math.IsNaN(baseReturn) {
-		err = errors.New("result was NaN")
-		return math.NaN(), err
-	}
-
-	if !isShort {
-		targetAnnualReturnPrice = baseReturn
-	} else {
-		targetAnnualReturnPrice = 2*costBasis - baseReturn
-	}
-	return targetAnnualReturnPrice, nil
+// GetTargetAnnualReturn is AnnualTargetPrice over the calendar days from purchaseDate to exitDate, for positions
+// without an asset class: the price a position bought at costBasis must reach by exitDate to have earned riskFreeRate
+// a year. Use AnnualTargetPrice to count the sessions of the position's asset class instead.
+func GetTargetAnnualReturn(costBasis, riskFreeRate float64, purchaseDate, exitDate time.Time, isShort bool) (targetAnnualReturnPrice float64, err error) {
+	return AnnualTargetPrice("", costBasis, riskFreeRate, purchaseDate, exitDate, isShort)
 }
 
 // GetStockPricesAlpaca retrieves stock prices using Alpaca's stock API. It does NOT gather crypto data using the stock
@@ -374,7 +360,7 @@ that has impacted price and compare with Implied Volatility to evaluate the risk
 enter a trade.
 */
 func RealizedVolatility(prices []float64, ticker string) (realizedVol float64) {
-	returns := calculateDailyReturn(prices)
+	returns := periodReturns(prices)
 	variance := calculateVariance(returns)
 	daysInYear := annualization(ticker)
 	return math.Sqrt(variance * daysInYear)
@@ -424,7 +410,8 @@ func CalculateRiskRanges(stockPrices map[string]map[int64]SingleStockCandle, dur
 			dailyTicker := stockPrices[ticker][day]
 			if rv := getRVol(stockPrices[ticker][day], duration); rv != 0.0 {
 				setRiskRange(&dailyTicker, duration,
-					calculateRiskRange(stockPrices[ticker][day].WeightedVolume, rv, float64(duration), ticker))
+					calculateRiskRange(stockPrices[ticker][day].WeightedVolume, rv,
+						float64(durationSessions(duration, ticker, time.UnixMilli(day))), ticker))
 			}
 			stockPrices[ticker][day] = dailyTicker
 		}
@@ -432,7 +419,8 @@ func CalculateRiskRanges(stockPrices map[string]map[int64]SingleStockCandle, dur
 	return stockPrices
 }
 
-// todo: add test for riskRange["low"]
+// calculateRiskRange scales the annualized volatility to riskRangeDuration, counted in sessions of the ticker's asset
+// class so it matches the sessions per year it is divided by, and places the range around price.
 func calculateRiskRange(price, volatility, riskRangeDuration float64, ticker string) (riskRange map[string]float64) {
 	riskRange = make(map[string]float64)
 	daysInYear := annualization(ticker)
@@ -555,7 +543,8 @@ func CalculateVolumeAdjustedRiskRanges(stockPrices map[string]map[int64]SingleSt
 			if rv != 0.0 {
 				adjVol := rv / ratio
 				setAdjRiskRange(&dailyTicker, duration,
-					calculateRiskRange(stockPrices[ticker][day].WeightedVolume, adjVol, float64(duration), ticker))
+					calculateRiskRange(stockPrices[ticker][day].WeightedVolume, adjVol,
+						float64(durationSessions(duration, ticker, time.UnixMilli(day))), ticker))
 			}
 			stockPrices[ticker][day] = dailyTicker
 		}
@@ -634,39 +623,104 @@ func calculateRVolPercentRange(rVolHigh, rVolLow, rVol float64) (rvolPercent flo
 	return (rVol - rVolLow) / (rVolHigh - rVolLow), nil
 }
 
-func GetLinearRegressionSlope(stockPrices map[string]map[int64]SingleStockCandle, duration int, isDebug bool) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+// GetLinearRegressionSlope fits an ordinary least squares line through each day's duration window of closes (the
+// prices stored by StoreRealizedVols), ordered oldest to newest, and stores the fit for that duration. When
+// useLogPrices is set the fit is made on ln(close), so the slope reads as an average log return per session.
+//
+// Must be called after StoreRealizedVols so that the price windows are populated.
+func GetLinearRegressionSlope(stockPrices map[string]map[int64]SingleStockCandle, duration int, useLogPrices,
+	isDebug bool) (stockPricesMap map[string]map[int64]SingleStockCandle) {
 	for ticker := range stockPrices {
 		for dateInt64 := range stockPrices[ticker] {
 			singleTickerData := stockPrices[ticker][dateInt64]
 			prices := getPrices(singleTickerData, duration)
-			if len(prices) > 0 {
-				var xVals []float64
-				var yVals []float64
-				i := 0
-				for dateString := range prices {
-					yVals = append(yVals, prices[dateString])
-					i++
-					xVals = append(xVals, float64(i))
+			// Keys are time.DateOnly strings, so a lexical sort is chronological.
+			dateStrings := make([]string, 0, len(prices))
+			for dateString := range prices {
+				dateStrings = append(dateStrings, dateString)
+			}
+			sort.Strings(dateStrings)
+
+			xVals := make([]float64, 0, len(dateStrings))
+			yVals := make([]float64, 0, len(dateStrings))
+			for i, dateString := range dateStrings {
+				y := prices[dateString]
+				if useLogPrices {
+					y = math.Log(y)
 				}
-				slope, intercept, err := calcLinearRegression(xVals, yVals)
-				if err != nil {
+				xVals = append(xVals, float64(i))
+				yVals = append(yVals, y)
+			}
+			fit, err := calcRegressionFit(xVals, yVals)
+			if err != nil {
+				if isDebug {
 					log.Printf("error getting linear regression: %v", err)
-				} else {
-					if isDebug {
-						fmt.Printf("Date: %s duration: %d intercept: %f\n",
-							stockPrices[ticker][dateInt64].Timestamp, duration, intercept)
-					}
-					setSlope(&singleTickerData, duration, slope)
 				}
-			} else {
-				setSlope(&singleTickerData, duration, 0.0)
+				fit = RegressionFit{}
+			} else if isDebug {
+				fmt.Printf("Date: %s duration: %d slope: %f r2: %f t: %f\n",
+					stockPrices[ticker][dateInt64].Timestamp, duration, fit.Slope, fit.RSquared, fit.TStat)
 			}
+			setRegression(&singleTickerData, duration, fit)
 			stockPrices[ticker][dateInt64] = singleTickerData
 		}
 	}
 	return stockPrices
 }
 
+// calcRegressionFit extends calcLinearRegression with the goodness-of-fit statistics for the slope. A perfect fit
+// reports a StdErr of 0 and a TStat of 0 since the t-statistic is unbounded; see RegressionFit.direction.
+func calcRegressionFit(xValues, yValues []float64) (fit RegressionFit, err error) {
+	if len(xValues) < 3 {
+		return RegressionFit{}, errors.New("invalid input: regression statistics need at least 3 data points")
+	}
+	for _, y := range yValues {
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			return RegressionFit{}, errors.New("invalid input: y values must be finite")
+		}
+	}
+	slope, intercept, err := calcLinearRegression(xValues, yValues)
+	if err != nil {
+		return RegressionFit{}, err
+	}
+
+	n := float64(len(xValues))
+	meanX := gonum.Mean(xValues, nil)
+	meanY := gonum.Mean(yValues, nil)
+	var sxx, sst, sse float64
+	for i := range xValues {
+		residual := yValues[i] - (intercept + slope*xValues[i])
+		sxx += (xValues[i] - meanX) * (xValues[i] - meanX)
+		sst += (yValues[i] - meanY) * (yValues[i] - meanY)
+		sse += residual * residual
+	}
+	if sxx == 0 {
+		return RegressionFit{}, errors.New("invalid input: x values must not all be equal")
+	}
+
+	fit = RegressionFit{Slope: slope, Intercept: intercept, N: len(xValues)}
+	if sst > 0 {
+		fit.RSquared = 1 - sse/sst
+	}
+	fit.StdErr = math.Sqrt(sse / (n - 2) / sxx)
+	if fit.StdErr > 0 {
+		fit.TStat = slope / fit.StdErr
+	}
+	return fit, nil
+}
+
+// direction returns +1, -1, or 0 depending on whether the fitted slope is significantly positive, significantly
+// negative, or indistinguishable from flat at the given t-statistic threshold.
+func (f RegressionFit) direction(minTStat float64) float64 {
+	if f.N < 3 || f.Slope == 0 {
+		return 0
+	}
+	if f.StdErr == 0 || math.Abs(f.TStat) >= minTStat {
+		return math.Copysign(1, f.Slope)
+	}
+	return 0
+}
+
 func calcLinearRegression(xValues, yValues []float64) (slope, intercept float64, err error) {
 	if len(xValues) != len(yValues) || len(xValues) < 2 {
 		return 0, 0, errors.New("invalid input: x and y slices must have the same length and at least 2 data points")
@@ -762,99 +816,182 @@ func GetSimpleSlopes(stockPrices map[string]map[int64]SingleStockCandle, isDebug
 }
 
 // CalculateTrendDirections assigns TradeDirection, TrendDirection, and TailDirection
-// to each day based on whether the three most recent consecutive slopes (today,
-// yesterday, day-before) are all positive (Bullish), all negative (Bearish),
+// to each day based on whether the most recent consecutive slopes (three by default:
+// today, yesterday, day-before) are all positive (Bullish), all negative (Bearish),
 // mixed (Neutral), or unavailable (Indeterminate).
 //
-// Must be called after GetSimpleSlopes so that validity flags are set.
-func CalculateTrendDirections(stockPrices map[string]map[int64]SingleStockCandle, isDebug bool) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+// With conf.UseRegression the slope for each day is replaced by the sign of its
+// regression slope when that slope is significant at conf.MinTStat, and by zero
+// otherwise, so an insignificant fit counts towards Neutral.
+//
+// Each duration's DirectionRule in conf sets how many slopes confirm a direction,
+// the dead-band below which a slope counts as flat, and the hysteresis that keeps
+// a Bullish or Bearish label until enough opposite sessions have passed.
+//
+// Must be called after GetSimpleSlopes so that validity flags are set, and after
+// GetLinearRegressionSlope when conf.UseRegression is set.
+func CalculateTrendDirections(stockPrices map[string]map[int64]SingleStockCandle, conf TrendConf, isDebug bool) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	minTStat := conf.MinTStat
+	if minTStat == 0.0 {
+		minTStat = 2.0
+	}
+	durations := []int{SHORTDURATION, MEDIUMDURATION, LONGDURATION}
 	for ticker := range stockPrices {
 		var dateKeys []int64
 		for dateKey := range stockPrices[ticker] {
 			dateKeys = append(dateKeys, dateKey)
 		}
-		// Sort ascending so index i-1 and i-2 are the prior trading days.
+		// Sort ascending so the confirmation window runs back from index i.
 		sort.Slice(dateKeys, func(i, j int) bool {
 			return dateKeys[i] < dateKeys[j]
 		})
 
-		for i, currentDate := range dateKeys {
-			stockCandle := stockPrices[ticker][currentDate]
-			if i < 2 {
-				stockCandle.TradeDirection = "Indeterminate"
-				stockCandle.TrendDirection = "Indeterminate"
-				stockCandle.TailDirection = "Indeterminate"
-			} else {
-				prev1 := stockPrices[ticker][dateKeys[i-1]]
-				prev2 := stockPrices[ticker][dateKeys[i-2]]
-
-				stockCandle.TradeDirection = trendLabel(
-					stockCandle.SlopeShortDuration, stockCandle.SlopeShortValid,
-					prev1.SlopeShortDuration, prev1.SlopeShortValid,
-					prev2.SlopeShortDuration, prev2.SlopeShortValid,
-				)
-				stockCandle.TrendDirection = trendLabel(
-					stockCandle.SlopeMedDuration, stockCandle.SlopeMedValid,
-					prev1.SlopeMedDuration, prev1.SlopeMedValid,
-					prev2.SlopeMedDuration, prev2.SlopeMedValid,
-				)
-				stockCandle.TailDirection = trendLabel(
-					stockCandle.SlopeLongDuration, stockCandle.SlopeLongValid,
-					prev1.SlopeLongDuration, prev1.SlopeLongValid,
-					prev2.SlopeLongDuration, prev2.SlopeLongValid,
-				)
-				if isDebug {
-					fmt.Printf("ticker=%s date=%d tradeDir=%s trendDir=%s tailDir=%s\n",
-						ticker, currentDate,
-						stockCandle.TradeDirection, stockCandle.TrendDirection, stockCandle.TailDirection)
-				}
+		for _, d := range durations {
+			rule := conf.rule(d)
+			signals := make([]float64, len(dateKeys))
+			valid := make([]bool, len(dateKeys))
+			for i, date := range dateKeys {
+				signals[i], valid[i] = trendSignal(stockPrices[ticker][date], d, conf, minTStat, rule.DeadBand)
+			}
+			state := "Indeterminate"
+			for i, currentDate := range dateKeys {
+				state = trendState(state, signals[:i+1], valid[:i+1], rule)
+				stockCandle := stockPrices[ticker][currentDate]
+				setDirection(&stockCandle, d, state)
+				stockPrices[ticker][currentDate] = stockCandle
+			}
+		}
+		if isDebug {
+			for _, currentDate := range dateKeys {
+				stockCandle := stockPrices[ticker][currentDate]
+				fmt.Printf("ticker=%s date=%d tradeDir=%s trendDir=%s tailDir=%s\n",
+					ticker, currentDate,
+					stockCandle.TradeDirection, stockCandle.TrendDirection, stockCandle.TailDirection)
 			}
-			stockPrices[ticker][currentDate] = stockCandle
 		}
 	}
 	return stockPrices
 }
 
-// trendLabel returns the direction label for one duration given three consecutive
-// slope values and their validity flags.
-func trendLabel(s0 float64, v0 bool, s1 float64, v1 bool, s2 float64, v2 bool) string {
-	if !v0 || !v1 || !v2 {
-		return "Indeterminate"
+// rule returns the DirectionRule for duration d with defaults filled in.
+func (c TrendConf) rule(d int) DirectionRule {
+	var rule DirectionRule
+	switch d {
+	case SHORTDURATION:
+		rule = c.Trade
+	case MEDIUMDURATION:
+		rule = c.Trend
+	case LONGDURATION:
+		rule = c.Tail
 	}
-	if s0 > 0 && s1 > 0 && s2 > 0 {
-		return "Bullish"
+	if rule.Confirmation <= 0 {
+		rule.Confirmation = 3
 	}
-	if s0 < 0 && s1 < 0 && s2 < 0 {
+	return rule
+}
+
+// trendSignal returns the value trendLabel reads for one day and duration along
+// with whether it is usable. Moves smaller than deadBand, as a fraction of the
+// close, read as zero. For a regression fit the move is the fitted change across
+// its window.
+func trendSignal(c SingleStockCandle, d int, conf TrendConf, minTStat, deadBand float64) (float64, bool) {
+	if conf.UseRegression {
+		fit := getRegression(c, d)
+		signal := fit.direction(minTStat)
+		move := fit.Slope * float64(fit.N-1)
+		if !conf.LogPrices && c.Close > 0 {
+			move /= c.Close
+		}
+		if deadBand > 0 && math.Abs(move) < deadBand {
+			signal = 0
+		}
+		return signal, fit.N >= 3
+	}
+	slope := getSlope(c, d)
+	if deadBand > 0 && c.Close > 0 && math.Abs(slope/c.Close) < deadBand {
+		slope = 0
+	}
+	return slope, getSlopeValid(c, d)
+}
+
+// trendLabel returns the direction label for one duration given its most recent
+// consecutive slope values and their validity flags.
+func trendLabel(signals []float64, valid []bool) string {
+	up, down := true, true
+	for i, s := range signals {
+		if !valid[i] {
+			return "Indeterminate"
+		}
+		up = up && s > 0
+		down = down && s < 0
+	}
+	switch {
+	case up:
+		return "Bullish"
+	case down:
 		return "Bearish"
 	}
 	return "Neutral"
 }
 
-// CalculateTrend takes the end date and returns the difference between the input date's close price and the close price
-// of the date that is back a number of days prior to the input date
-func CalculateTrend(stockPrices map[string]map[int64]SingleStockCandle, ticker string, endDate time.Time, tickerDuration int64) (stockPricesMap map[string]map[int64]SingleStockCandle) {
-	/*
-	* Take the Close price from the input date and subtract a number of days equal to the duration. If a date does not
-	* exist, but is not prior to the start date, subtract another day and try again until hitting a number that exists.
-	 */
-	var trendInt float64
-	endDateMilli := endDate.UnixMilli()
-	startDateMilli := endDateMilli - time.Hour.Milliseconds()*DAY*tickerDuration
-
-	trendInt = stockPrices[ticker][endDateMilli].Close - stockPrices[ticker][startDateMilli].Close
-
-	trendRatio := trendInt / stockPrices[ticker][endDateMilli].Close
-	stockPrice := stockPrices[ticker][endDateMilli]
+// trendState advances one duration's direction from prev given every signal up
+// to and including the current session. Without enough sessions to fill the
+// confirmation window the direction is Indeterminate. With rule.Hysteresis set,
+// a Bullish or Bearish prev is kept until the last Hysteresis signals all point
+// the other way, unless the window turns Indeterminate.
+func trendState(prev string, signals []float64, valid []bool, rule DirectionRule) string {
+	n := len(signals)
+	if n < rule.Confirmation {
+		return "Indeterminate"
+	}
+	label := trendLabel(signals[n-rule.Confirmation:], valid[n-rule.Confirmation:])
+	if rule.Hysteresis <= 0 || label == "Indeterminate" || label == prev || (prev != "Bullish" && prev != "Bearish") {
+		return label
+	}
+	if n < rule.Hysteresis {
+		return prev
+	}
+	for i := n - rule.Hysteresis; i < n; i++ {
+		if !valid[i] || (prev == "Bullish" && signals[i] >= 0) || (prev == "Bearish" && signals[i] <= 0) {
+			return prev
+		}
+	}
+	return label
+}
 
-	switch trendRatio {
-	case SHORTDURATION:
+// CalculateTrend computes the slope for tickerDuration as of endDate, the way GetSimpleSlopes would have on that day:
+// the close of the last trading day at or before endDate less the close of the last trading day at or before
+// tickerDuration calendar days earlier. The slope is stored on the end day's candle. Nothing is stored when there is
+// no trading day at or before endDate, or not enough history before it.
+func CalculateTrend(stockPrices map[string]map[int64]SingleStockCandle, ticker string, endDate time.Time, tickerDuration int64) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	var dateKeys []int64
+	for dateKey := range stockPrices[ticker] {
+		dateKeys = append(dateKeys, dateKey)
+	}
+	// Sort descending so the first key at or before a target is the nearest prior trading day.
+	sort.Slice(dateKeys, func(i, j int) bool {
+		return dateKeys[i] > dateKeys[j]
+	})
+	nearestAtOrBefore := func(target int64) (int64, bool) {
+		for _, dateKey := range dateKeys {
+			if dateKey <= target {
+				return dateKey, true
+			}
+		}
+		return 0, false
+	}
 
-		stockPrice.SlopeShortDuration = trendRatio
-	case MEDIUMDURATION:
-		stockPrice.SlopeMedDuration = trendRatio
-	case LONGDURATION:
-		stockPrice.SlopeLongDuration = trendRatio
+	endDateMilli, ok := nearestAtOrBefore(endDate.UnixMilli())
+	if !ok {
+		return stockPrices
 	}
+	startDateMilli, ok := nearestAtOrBefore(time.UnixMilli(endDateMilli).AddDate(0, 0, -int(tickerDuration)).UnixMilli())
+	if !ok {
+		return stockPrices
+	}
+	stockPrice := stockPrices[ticker][endDateMilli]
+	setSlope(&stockPrice, int(tickerDuration), stockPrice.Close-stockPrices[ticker][startDateMilli].Close)
+	setSlopeValid(&stockPrice, int(tickerDuration), true)
 	stockPrices[ticker][endDateMilli] = stockPrice
 
 	return stockPrices
@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -383,7 +384,7 @@ func TestCalculateTrendDirections(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := CalculateTrendDirections(tt.stockPrices, false)
+			result := CalculateTrendDirections(tt.stockPrices, TrendConf{}, false)
 			got := result[tt.ticker][tt.checkDate]
 			if got.TradeDirection != tt.wantTradeDirection {
 				t.Errorf("TradeDirection = %q, want %q", got.TradeDirection, tt.wantTradeDirection)
@@ -546,6 +547,24 @@ func TestCalculateAvgVolumeRatios_PopulatesOnlyTargetDuration(t *testing.T) {
 	}
 }
 
+func TestCalculateRiskRanges_SessionsByAssetClass(t *testing.T) {
+	day := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	candle := SingleStockCandle{WeightedVolume: 100, RealizedVolatilityShort: 0.5}
+	data := map[string]map[int64]SingleStockCandle{"SPY": {day: candle}, "X:BTCUSD": {day: candle}}
+	result := CalculateRiskRanges(data, SHORTDURATION)
+
+	// The 30 calendar days from April 1 2025 are 21 exchange sessions for equity, skipping Good Friday, and 30 sessions
+	// for crypto.
+	equity := 100 * (1 + 0.5/TRADINGDAYSPERYEAR*21)
+	crypto := 100 * (1 + 0.5/YEAR*30)
+	if got := result["SPY"][day].TradeRange["high"]; math.Abs(got-equity) > 1e-9 {
+		t.Errorf("equity high = %v, want %v", got, equity)
+	}
+	if got := result["X:BTCUSD"][day].TradeRange["high"]; math.Abs(got-crypto) > 1e-9 {
+		t.Errorf("crypto high = %v, want %v", got, crypto)
+	}
+}
+
 func TestCalculateVolumeAdjustedRiskRanges_PopulatesOnlyTargetDuration(t *testing.T) {
 	data := makeTestData("AAPL", 90)
 	data = StoreRealizedVols(data, SHORTDURATION)
@@ -644,3 +663,302 @@ func TestCalculateAccelerations_PopulatesOnlyTargetDuration(t *testing.T) {
 		t.Error("expected at least one candle with RealizedVolAccelShort populated")
 	}
 }
+
+func TestCalcRegressionFit(t *testing.T) {
+	x := []float64{0, 1, 2, 3, 4, 5}
+	y := []float64{10, 12, 11, 15, 14, 17}
+	fit, err := calcRegressionFit(x, y)
+	if err != nil {
+		t.Fatalf("calcRegressionFit() error = %v", err)
+	}
+	want := RegressionFit{
+		Slope:     1.2857142857142858,
+		Intercept: 9.952380952380953,
+		RSquared:  0.8304853041695148,
+		StdErr:    0.2904371558471791,
+		TStat:     4.426824391541683,
+		N:         6,
+	}
+	const eps = 1e-9
+	if math.Abs(fit.Slope-want.Slope) > eps || math.Abs(fit.Intercept-want.Intercept) > eps ||
+		math.Abs(fit.RSquared-want.RSquared) > eps || math.Abs(fit.StdErr-want.StdErr) > eps ||
+		math.Abs(fit.TStat-want.TStat) > eps || fit.N != want.N {
+		t.Errorf("calcRegressionFit() = %+v, want %+v", fit, want)
+	}
+
+	if _, err := calcRegressionFit([]float64{0, 1}, []float64{1, 2}); err == nil {
+		t.Error("expected error for fewer than 3 points")
+	}
+	if _, err := calcRegressionFit([]float64{1, 1, 1}, []float64{1, 2, 3}); err == nil {
+		t.Error("expected error when all x values are equal")
+	}
+}
+
+func TestGetLinearRegressionSlope(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	prices := map[string]float64{}
+	logPrices := map[string]float64{}
+	for i := 0; i < 20; i++ {
+		date := day.AddDate(0, 0, -i).Format(time.DateOnly)
+		// Oldest close is lowest, so a chronological fit must have a positive slope of exactly 1.
+		prices[date] = 100.0 - float64(i)
+		logPrices[date] = math.Exp(5.0 - 0.01*float64(i))
+	}
+
+	tests := []struct {
+		name      string
+		prices    map[string]float64
+		useLog    bool
+		wantSlope float64
+	}{
+		{name: "closes fit in date order", prices: prices, useLog: false, wantSlope: 1.0},
+		{name: "log closes fit in date order", prices: logPrices, useLog: true, wantSlope: 0.01},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]map[int64]SingleStockCandle{
+				"AAPL": {day.UnixMilli(): {Ticker: "AAPL", ShortPrices: tt.prices}},
+			}
+			got := GetLinearRegressionSlope(data, SHORTDURATION, tt.useLog, false)["AAPL"][day.UnixMilli()]
+			if math.Abs(got.RegressionShort.Slope-tt.wantSlope) > 1e-9 {
+				t.Errorf("RegressionShort.Slope = %v, want %v", got.RegressionShort.Slope, tt.wantSlope)
+			}
+			if math.Abs(got.RegressionShort.RSquared-1.0) > 1e-9 {
+				t.Errorf("RegressionShort.RSquared = %v, want 1", got.RegressionShort.RSquared)
+			}
+			if got.RegressionShort.N != 20 {
+				t.Errorf("RegressionShort.N = %d, want 20", got.RegressionShort.N)
+			}
+			if got.RegressionMed.N != 0 {
+				t.Errorf("RegressionMed should not be set by SHORTDURATION call: got %+v", got.RegressionMed)
+			}
+		})
+	}
+}
+
+func TestCalculateTrendDirections_Regression(t *testing.T) {
+	day1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	day3 := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	// withFit builds a candle whose raw slopes are positive but whose regression fit is the one given,
+	// so the test proves the regression fit is what drives the label.
+	withFit := func(fit RegressionFit) SingleStockCandle {
+		return SingleStockCandle{
+			SlopeShortDuration: 1.0, SlopeShortValid: true,
+			SlopeMedDuration: 1.0, SlopeMedValid: true,
+			SlopeLongDuration: 1.0, SlopeLongValid: true,
+			RegressionShort: fit, RegressionMed: fit, RegressionLong: fit,
+		}
+	}
+	significantUp := RegressionFit{Slope: 0.5, StdErr: 0.1, TStat: 5.0, N: 30}
+	significantDown := RegressionFit{Slope: -0.5, StdErr: 0.1, TStat: -5.0, N: 30}
+	insignificant := RegressionFit{Slope: 0.5, StdErr: 0.5, TStat: 1.0, N: 30}
+
+	tests := []struct {
+		name string
+		fits [3]RegressionFit
+		conf TrendConf
+		want string
+	}{
+		{"significant positive fits → Bullish", [3]RegressionFit{significantUp, significantUp, significantUp}, TrendConf{UseRegression: true}, "Bullish"},
+		{"significant negative fits → Bearish", [3]RegressionFit{significantDown, significantDown, significantDown}, TrendConf{UseRegression: true}, "Bearish"},
+		{"insignificant fit → Neutral", [3]RegressionFit{significantUp, insignificant, significantUp}, TrendConf{UseRegression: true}, "Neutral"},
+		{"lower threshold admits weaker fit → Bullish", [3]RegressionFit{significantUp, insignificant, significantUp}, TrendConf{UseRegression: true, MinTStat: 0.5}, "Bullish"},
+		{"missing fit → Indeterminate", [3]RegressionFit{significantUp, {}, significantUp}, TrendConf{UseRegression: true}, "Indeterminate"},
+		{"regression disabled uses raw slopes", [3]RegressionFit{significantDown, significantDown, significantDown}, TrendConf{}, "Bullish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]map[int64]SingleStockCandle{
+				"AAPL": {
+					day1.UnixMilli(): withFit(tt.fits[0]),
+					day2.UnixMilli(): withFit(tt.fits[1]),
+					day3.UnixMilli(): withFit(tt.fits[2]),
+				},
+			}
+			got := CalculateTrendDirections(data, tt.conf, false)["AAPL"][day3.UnixMilli()]
+			if got.TradeDirection != tt.want || got.TrendDirection != tt.want || got.TailDirection != tt.want {
+				t.Errorf("directions = %q/%q/%q, want %q",
+					got.TradeDirection, got.TrendDirection, got.TailDirection, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateTrendDirections_Rules(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// series builds consecutive days closing at 100 whose slopes are all valid and set to the given values.
+	series := func(slopes ...float64) map[string]map[int64]SingleStockCandle {
+		days := map[int64]SingleStockCandle{}
+		for i, slope := range slopes {
+			days[start.AddDate(0, 0, i).UnixMilli()] = SingleStockCandle{
+				Close:              100,
+				SlopeShortDuration: slope, SlopeShortValid: true,
+				SlopeMedDuration: slope, SlopeMedValid: true,
+				SlopeLongDuration: slope, SlopeLongValid: true,
+			}
+		}
+		return map[string]map[int64]SingleStockCandle{"AAPL": days}
+	}
+	const (
+		I = "Indeterminate"
+		U = "Bullish"
+		D = "Bearish"
+		N = "Neutral"
+	)
+
+	tests := []struct {
+		name      string
+		slopes    []float64
+		rule      DirectionRule
+		wantTrade []string
+	}{
+		{"default rule matches three-slope labels", []float64{1, 1, 1, -1, -1, -1},
+			DirectionRule{}, []string{I, I, U, N, N, D}},
+		{"shorter confirmation calls direction sooner", []float64{1, 1, -1, -1},
+			DirectionRule{Confirmation: 2}, []string{I, U, N, D}},
+		{"longer confirmation waits for more history", []float64{1, 1, 1, 1, 1},
+			DirectionRule{Confirmation: 5}, []string{I, I, I, I, U}},
+		{"moves inside the dead-band count as flat", []float64{0.5, 2, 2, 2, 0.5},
+			DirectionRule{DeadBand: 0.01}, []string{I, I, N, U, N}},
+		{"hysteresis holds through a single opposite day", []float64{1, 1, 1, -1, 1, 1},
+			DirectionRule{Hysteresis: 2}, []string{I, I, U, U, U, U}},
+		{"without hysteresis the same series flips", []float64{1, 1, 1, -1, 1, 1},
+			DirectionRule{}, []string{I, I, U, N, N, N}},
+		{"hysteresis exits after enough opposite days", []float64{1, 1, 1, -1, -1, -1},
+			DirectionRule{Hysteresis: 2}, []string{I, I, U, U, N, D}},
+		{"hysteresis longer than the reversal keeps the label", []float64{1, 1, 1, -1, -1, -1},
+			DirectionRule{Hysteresis: 4}, []string{I, I, U, U, U, U}},
+		{"flat days do not count towards an exit", []float64{-1, -1, -1, 0, 0, 0},
+			DirectionRule{Hysteresis: 2}, []string{I, I, D, D, D, D}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := TrendConf{Trade: tt.rule}
+			got := CalculateTrendDirections(series(tt.slopes...), conf, false)["AAPL"]
+			for i, want := range tt.wantTrade {
+				day := got[start.AddDate(0, 0, i).UnixMilli()]
+				if day.TradeDirection != want {
+					t.Errorf("day %d TradeDirection = %q, want %q", i, day.TradeDirection, want)
+				}
+			}
+			// The rule only applies to the trade duration; trend keeps the default three-slope labels.
+			defaults := CalculateTrendDirections(series(tt.slopes...), TrendConf{}, false)["AAPL"]
+			for date, day := range got {
+				if day.TrendDirection != defaults[date].TradeDirection {
+					t.Errorf("TrendDirection = %q, want the default %q", day.TrendDirection,
+						defaults[date].TradeDirection)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateTrend(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := map[string]map[int64]SingleStockCandle{"AAPL": {}}
+	// Trading days fall on every other day and close at 100 plus the day number.
+	for i := 0; i <= 40; i += 2 {
+		data["AAPL"][start.AddDate(0, 0, i).UnixMilli()] = SingleStockCandle{Close: 100 + float64(i)}
+	}
+
+	tests := []struct {
+		name      string
+		endDate   time.Time
+		wantDate  time.Time
+		wantSlope float64
+		wantValid bool
+	}{
+		{"end date on a trading day", start.AddDate(0, 0, 40), start.AddDate(0, 0, 40), 30, true},
+		{"end date between trading days rolls back", start.AddDate(0, 0, 39), start.AddDate(0, 0, 38), 30, true},
+		{"not enough history", start.AddDate(0, 0, 20), start.AddDate(0, 0, 20), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateTrend(data, "AAPL", tt.endDate, SHORTDURATION)["AAPL"][tt.wantDate.UnixMilli()]
+			if got.SlopeShortValid != tt.wantValid || got.SlopeShortDuration != tt.wantSlope {
+				t.Errorf("slope = %v (valid %v), want %v (valid %v)", got.SlopeShortDuration, got.SlopeShortValid,
+					tt.wantSlope, tt.wantValid)
+			}
+			if got.SlopeMedValid || got.SlopeLongValid {
+				t.Error("only the requested duration should be set")
+			}
+		})
+	}
+}
+
+func TestGetCurrAnnualReturn(t *testing.T) {
+	bought := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		price, cost    float64
+		asOf           time.Time
+		short, wantErr bool
+		want           float64
+	}{
+		{"long over a leap year", 110, 100, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false, false,
+			math.Pow(1.1, YEAR/366) - 1},
+		{"short gain", 90, 100, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true, false,
+			math.Pow(1.1, YEAR/366) - 1},
+		{"short wiped out", 250, 100, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true, false, -1},
+		{"zero cost basis", 110, 0, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), false, true, 0},
+		{"future purchase", 110, 100, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), false, true, 0},
+		{"same day", 110, 100, bought.Add(6 * time.Hour), false, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetCurrAnnualReturn(tt.price, tt.cost, bought, tt.asOf, tt.short)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetCurrAnnualReturn() = %v, expected an error", got)
+				}
+				return
+			}
+			if err != nil || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("GetCurrAnnualReturn() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTargetAnnualReturn(t *testing.T) {
+	bought := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exit := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := 100 * math.Pow(1.06, 366/YEAR)
+	got, err := GetTargetAnnualReturn(100, .06, bought, exit, false)
+	if err != nil || math.Abs(got-want) > 1e-9 {
+		t.Errorf("GetTargetAnnualReturn() = %v, %v, want %v", got, err, want)
+	}
+	if got, err = GetTargetAnnualReturn(100, .06, bought, exit, true); err != nil || math.Abs(got-(200-want)) > 1e-9 {
+		t.Errorf("GetTargetAnnualReturn() short = %v, %v, want %v", got, err, 200-want)
+	}
+	for _, bad := range []struct {
+		cost float64
+		exit time.Time
+	}{{0, exit}, {100, bought.AddDate(0, 0, -1)}, {100, bought}} {
+		if _, err = GetTargetAnnualReturn(bad.cost, .06, bought, bad.exit, false); err == nil {
+			t.Errorf("GetTargetAnnualReturn(%v, %v) expected an error", bad.cost, bad.exit)
+		}
+	}
+}
+
+func TestGetAnnualReturns_MatchCalendarAnnualizedReturn(t *testing.T) {
+	bought := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	asOf := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	for _, convention := range []string{SIMPLERETURNS, LOGRETURNS} {
+		t.Run(convention, func(t *testing.T) {
+			withReturnConf(t, ReturnConf{Convention: convention})
+			current, err := GetCurrAnnualReturn(120, 100, bought, asOf, false)
+			want, wantErr := AnnualizedReturn("", 120, 100, bought, asOf, false)
+			if err != nil || wantErr != nil || current != want {
+				t.Errorf("GetCurrAnnualReturn() = %v, %v, want %v", current, err, want)
+			}
+			target, err := GetTargetAnnualReturn(100, .06, bought, asOf, true)
+			want, wantErr = AnnualTargetPrice("", 100, .06, bought, asOf, true)
+			if err != nil || wantErr != nil || target != want {
+				t.Errorf("GetTargetAnnualReturn() = %v, %v, want %v", target, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+// Return conventions accepted by ReturnConf.Convention.
+const (
+	LOGRETURNS    = "log"
+	SIMPLERETURNS = "simple"
+)
+
+// ReturnConf controls how returns are measured and annualized. Convention picks log or simple period returns for
+// realized volatility and continuous or discrete compounding for annualized returns and target prices; left empty,
+// volatility uses log returns and annualized returns compound discretely, as they always have. AssetClasses
+// overrides the asset class inferred from a ticker's prefix, for tickers such as commodity ETFs or forex pairs quoted
+// without one.
+type ReturnConf struct {
+	Convention   string                      `json:"convention"`
+	AssetClasses map[string]store.AssetClass `json:"asset-classes"`
+}
+
+// returnConf is the package-wide return convention, set once from the config file by SetReturnConf.
+var returnConf ReturnConf
+
+// SetReturnConf validates conf and makes it the convention used by every volatility, range, and return calculation.
+func SetReturnConf(conf ReturnConf) error {
+	switch conf.Convention {
+	case "", LOGRETURNS, SIMPLERETURNS:
+	default:
+		return fmt.Errorf("unknown return convention %q: must be %q or %q", conf.Convention, LOGRETURNS, SIMPLERETURNS)
+	}
+	classes := make(map[string]store.AssetClass, len(conf.AssetClasses))
+	for ticker, class := range conf.AssetClasses {
+		parsed, err := ParseAssetClass(string(class))
+		if err != nil {
+			return fmt.Errorf("asset class for %s: %w", ticker, err)
+		}
+		classes[NormalizeTicker(ticker)] = parsed
+	}
+	conf.AssetClasses = classes
+	returnConf = conf
+	return nil
+}
+
+// ParseAssetClass converts a case-insensitive asset class name into a store.AssetClass. An empty name is returned
+// unchanged and means calendar days.
+func ParseAssetClass(name string) (store.AssetClass, error) {
+	class := store.AssetClass(strings.ToLower(strings.TrimSpace(name)))
+	switch class {
+	case "", store.AssetClassEquity, store.AssetClassETF, store.AssetClassCrypto, store.AssetClassCommodity,
+		store.AssetClassForex, store.AssetClassIndex:
+		return class, nil
+	}
+	return "", fmt.Errorf("unknown asset class %q", name)
+}
+
+// AssetClassOf returns the asset class of ticker: the configured override if there is one, the members' class for a
+// registered basket, the legs' shared class for a synthetic series, otherwise crypto for the X: prefix, forex for C:,
+// index for I:, and equity for everything else.
+func AssetClassOf(ticker string) store.AssetClass {
+	ticker = NormalizeTicker(ticker)
+	if class, ok := returnConf.AssetClasses[ticker]; ok {
+		return class
+	}
+	if class, ok := basketClasses[ticker]; ok {
+		return class
+	}
+	if spec, ok, err := ParseSynthetic(ticker); ok && err == nil {
+		return syntheticAssetClass(spec)
+	}
+	switch {
+	case strings.HasPrefix(ticker, "X:"):
+		return store.AssetClassCrypto
+	case strings.HasPrefix(ticker, "C:"):
+		return store.AssetClassForex
+	case strings.HasPrefix(ticker, "I:"):
+		return store.AssetClassIndex
+	}
+	return store.AssetClassEquity
+}
+
+// periodReturns converts prices into period-over-period returns using the configured convention, log unless simple
+// returns were asked for.
+func periodReturns(prices []float64) []float64 {
+	if returnConf.Convention != SIMPLERETURNS {
+		return calculateDailyReturn(prices)
+	}
+	var returns []float64
+	for i := 1; i < len(prices); i++ {
+		returns = append(returns, prices[i]/prices[i-1]-1)
+	}
+	return returns
+}
+
+// holdingPeriod validates a position and returns its length in years of the asset class's sessions.
+func holdingPeriod(class store.AssetClass, costBasis float64, from, to time.Time) (float64, error) {
+	if costBasis <= 0 {
+		return 0, errors.New("cost basis must be greater than zero")
+	}
+	if truncateToDay(from).After(truncateToDay(to)) {
+		return 0, fmt.Errorf("purchase date %s is after %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	years := YearFraction(class, from, to)
+	if years <= 0 {
+		return 0, fmt.Errorf("no %s sessions between %s and %s", classOrCalendar(class),
+			from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	return years, nil
+}
+
+func classOrCalendar(class store.AssetClass) string {
+	if class == "" {
+		return "calendar"
+	}
+	return string(class)
+}
+
+// AnnualizedReturn is the annualized return of a position opened at costBasis on from and valued at currentPrice on
+// to, with the holding period counted in the asset class's sessions. With log returns configured it is the
+// continuously compounded rate ln(P/C)/t; otherwise the discrete rate (P/C)^(1/t) - 1. A short position's gain is
+// the fall in price, and a position that has lost everything returns -1.
+func AnnualizedReturn(class store.AssetClass, currentPrice, costBasis float64, from, to time.Time,
+	isShort bool) (float64, error) {
+	years, err := holdingPeriod(class, costBasis, from, to)
+	if err != nil {
+		return math.NaN(), err
+	}
+	value := currentPrice
+	if isShort {
+		value = 2*costBasis - currentPrice
+	}
+	if value <= 0 {
+		return -1, nil
+	}
+	if returnConf.Convention == LOGRETURNS {
+		return math.Log(value/costBasis) / years, nil
+	}
+	return math.Pow(value/costBasis, 1/years) - 1, nil
+}
+
+// AnnualTargetPrice is the price a position opened at costBasis on from must reach by to to have earned rate a year,
+// with the holding period counted in the asset class's sessions and compounded the same way as AnnualizedReturn. A
+// short position's target is below its cost basis.
+func AnnualTargetPrice(class store.AssetClass, costBasis, rate float64, from, to time.Time,
+	isShort bool) (float64, error) {
+	years, err := holdingPeriod(class, costBasis, from, to)
+	if err != nil {
+		return math.NaN(), err
+	}
+	var target float64
+	if returnConf.Convention == LOGRETURNS {
+		target = costBasis * math.Exp(rate*years)
+	} else {
+		target = costBasis * math.Pow(1+rate, years)
+	}
+	if math.IsNaN(target) {
+		return math.NaN(), errors.New("result was NaN")
+	}
+	if isShort {
+		return 2*costBasis - target, nil
+	}
+	return target, nil
+}
@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+// withReturnConf sets conf for the duration of the test.
+func withReturnConf(t *testing.T, conf ReturnConf) {
+	t.Helper()
+	previous := returnConf
+	if err := SetReturnConf(conf); err != nil {
+		t.Fatalf("SetReturnConf() error = %v", err)
+	}
+	t.Cleanup(func() { returnConf = previous })
+}
+
+func TestAssetClassOf(t *testing.T) {
+	withReturnConf(t, ReturnConf{AssetClasses: map[string]store.AssetClass{"gld": "Commodity"}})
+	tests := []struct {
+		ticker string
+		want   store.AssetClass
+	}{
+		{"SPY", store.AssetClassEquity},
+		{"x:btcusd", store.AssetClassCrypto},
+		{"C:EURUSD", store.AssetClassForex},
+		{"I:SPX", store.AssetClassIndex},
+		{"GLD", store.AssetClassCommodity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			if got := AssetClassOf(tt.ticker); got != tt.want {
+				t.Errorf("AssetClassOf(%q) = %q, want %q", tt.ticker, got, tt.want)
+			}
+		})
+	}
+	if got := annualization("C:EURUSD"); got != FOREXSESSIONSPERYEAR {
+		t.Errorf("annualization(C:EURUSD) = %v, want %v", got, FOREXSESSIONSPERYEAR)
+	}
+}
+
+func TestSetReturnConf_Invalid(t *testing.T) {
+	previous := returnConf
+	t.Cleanup(func() { returnConf = previous })
+	tests := []struct {
+		name string
+		conf ReturnConf
+	}{
+		{"unknown convention", ReturnConf{Convention: "geometric"}},
+		{"unknown asset class", ReturnConf{AssetClasses: map[string]store.AssetClass{"GLD": "metal"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetReturnConf(tt.conf); err == nil {
+				t.Error("SetReturnConf() expected an error")
+			}
+		})
+	}
+}
+
+func TestPeriodReturns(t *testing.T) {
+	prices := []float64{100, 110, 99}
+	tests := []struct {
+		convention string
+		want       []float64
+	}{
+		{"", []float64{math.Log(1.1), math.Log(0.9)}},
+		{LOGRETURNS, []float64{math.Log(1.1), math.Log(0.9)}},
+		{SIMPLERETURNS, []float64{0.1, -0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.convention, func(t *testing.T) {
+			withReturnConf(t, ReturnConf{Convention: tt.convention})
+			got := periodReturns(prices)
+			for i := range tt.want {
+				if math.Abs(got[i]-tt.want[i]) > 1e-12 {
+					t.Errorf("periodReturns()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnnualizedReturn(t *testing.T) {
+	from := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		convention string
+		class      store.AssetClass
+		price      float64
+		isShort    bool
+		want       float64
+	}{
+		{"equity year is 252 sessions", "", store.AssetClassEquity, 110, false, 0.1},
+		{"calendar days", "", "", 110, false, math.Pow(1.1, YEAR/366) - 1},
+		{"continuous compounding", LOGRETURNS, store.AssetClassEquity, 110, false, math.Log(1.1)},
+		{"short gains when price falls", "", store.AssetClassEquity, 90, true, 0.1},
+		{"short wiped out", "", store.AssetClassEquity, 250, true, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withReturnConf(t, ReturnConf{Convention: tt.convention})
+			got, err := AnnualizedReturn(tt.class, tt.price, 100, from, to, tt.isShort)
+			if err != nil {
+				t.Fatalf("AnnualizedReturn() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("AnnualizedReturn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := AnnualizedReturn(store.AssetClassEquity, 110, 0, from, to, false); err == nil {
+		t.Error("expected an error for a zero cost basis")
+	}
+	weekend := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)
+	if _, err := AnnualizedReturn(store.AssetClassEquity, 110, 100, weekend, weekend.AddDate(0, 0, 1), false); err == nil {
+		t.Error("expected an error for a holding period with no sessions")
+	}
+}
+
+func TestAnnualTargetPrice(t *testing.T) {
+	from := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		convention string
+		isShort    bool
+		want       float64
+	}{
+		{"discrete", "", false, 106},
+		{"continuous", LOGRETURNS, false, 100 * math.Exp(0.06)},
+		{"short", "", true, 94},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withReturnConf(t, ReturnConf{Convention: tt.convention})
+			got, err := AnnualTargetPrice(store.AssetClassEquity, 100, 0.06, from, to, tt.isShort)
+			if err != nil {
+				t.Fatalf("AnnualTargetPrice() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("AnnualTargetPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Signal is what a Strategy asks the backtester to do at the next session's open.
+type Signal int
+
+const (
+	Hold Signal = iota
+	Buy
+	Sell
+)
+
+// Strategy decides on a Signal from one day's analyzed candle and whether a position is currently held.
+type Strategy func(day SingleStockCandle, holding bool) Signal
+
+// BacktestConf sets the account the backtester trades. InitialCapital defaults to 10,000 and PositionSize, the
+// fraction of equity committed on each entry, to 1. Commission is a flat charge per fill and Slippage a fraction of
+// the price each fill loses. RerunPipeline reruns the pipeline on each session's history instead of once over all the
+// candles, which checks that a pipeline does not look ahead at the cost of a run per session.
+type BacktestConf struct {
+	InitialCapital float64 `json:"initial-capital"`
+	PositionSize   float64 `json:"position-size"`
+	Commission     float64 `json:"commission"`
+	Slippage       float64 `json:"slippage"`
+	RerunPipeline  bool    `json:"rerun-pipeline"`
+}
+
+// BacktestTrade is one round trip. PnL and Return are net of commission and slippage. Open trades were still held at
+// the end of the test and are marked to the last close without exit costs.
+type BacktestTrade struct {
+	EntryDate  time.Time `json:"entry-date"`
+	ExitDate   time.Time `json:"exit-date"`
+	EntryPrice float64   `json:"entry-price"`
+	ExitPrice  float64   `json:"exit-price"`
+	Shares     float64   `json:"shares"`
+	PnL        float64   `json:"pnl"`
+	Return     float64   `json:"return"`
+	Open       bool      `json:"open"`
+}
+
+// EquityPoint is the account value at one session's close.
+type EquityPoint struct {
+	Date   time.Time `json:"date"`
+	Equity float64   `json:"equity"`
+}
+
+// BacktestStats summarizes a backtest. WinRate is over closed trades and Exposure is the share of sessions a position
+// was held at the close.
+type BacktestStats struct {
+	StartEquity float64 `json:"start-equity"`
+	EndEquity   float64 `json:"end-equity"`
+	TotalReturn float64 `json:"total-return"`
+	CAGR        float64 `json:"cagr"`
+	MaxDrawdown float64 `json:"max-drawdown"`
+	WinRate     float64 `json:"win-rate"`
+	Trades      int     `json:"trades"`
+	Exposure    float64 `json:"exposure"`
+}
+
+// BacktestResult is the equity curve, trade list, and summary of one backtest.
+type BacktestResult struct {
+	Ticker string          `json:"ticker"`
+	Stats  BacktestStats   `json:"stats"`
+	Trades []BacktestTrade `json:"trades"`
+	Equity []EquityPoint   `json:"equity"`
+}
+
+// RangeStrategy buys when the close is within entryBand of the range width above the low of rangeDuration's
+// probability- and volume-adjusted range while directionDuration's direction is Bullish, and sells within exitBand of
+// the high. With rangeDuration SHORTDURATION and directionDuration MEDIUMDURATION it buys near the PTradeRangeAdj low
+// in a Bullish TrendDirection.
+func RangeStrategy(rangeDuration, directionDuration int, entryBand, exitBand float64) Strategy {
+	return rangeStrategy(getProbAdjRiskRange, rangeDuration, directionDuration, entryBand, exitBand)
+}
+
+// rangeStrategy is RangeStrategy trading the band get returns.
+func rangeStrategy(get func(c SingleStockCandle, d int) map[string]float64, rangeDuration, directionDuration int,
+	entryBand, exitBand float64) Strategy {
+	return func(day SingleStockCandle, holding bool) Signal {
+		band := get(day, rangeDuration)
+		width := band["high"] - band["low"]
+		if width <= 0 {
+			return Hold
+		}
+		switch {
+		case !holding && getDirection(day, directionDuration) == "Bullish" && day.Close <= band["low"]+entryBand*width:
+			return Buy
+		case holding && day.Close >= band["high"]-exitBand*width:
+			return Sell
+		}
+		return Hold
+	}
+}
+
+// Backtest replays candles one session at a time. After each close the strategy reads the session's analyzed candle
+// and its signal fills at the next session's open. The pipeline runs once over all the candles, which no decision can
+// see past as its steps only look back from each day; with RerunPipeline it is run on the history up to and including
+// each session instead.
+func Backtest(ticker string, candles map[int64]SingleStockCandle, pipeline Pipeline, strategy Strategy,
+	conf BacktestConf) (result BacktestResult, err error) {
+	if len(candles) < 2 {
+		return BacktestResult{}, errors.New("backtest needs at least two candles")
+	}
+	if conf.InitialCapital <= 0 {
+		conf.InitialCapital = 10000
+	}
+	if conf.PositionSize <= 0 || conf.PositionSize > 1 {
+		conf.PositionSize = 1
+	}
+	var dateKeys []int64
+	for dateKey := range candles {
+		dateKeys = append(dateKeys, dateKey)
+	}
+	sort.Slice(dateKeys, func(i, j int) bool {
+		return dateKeys[i] < dateKeys[j]
+	})
+
+	result.Ticker = ticker
+	cash := conf.InitialCapital
+	var (
+		shares, entryCost float64
+		trade             BacktestTrade
+		pending           = Hold
+		heldSessions      int
+	)
+	history := make(map[int64]SingleStockCandle, len(candles))
+	var analyzed map[int64]SingleStockCandle
+	if !conf.RerunPipeline {
+		for date, c := range candles {
+			history[date] = c
+		}
+		analyzed = pipeline(map[string]map[int64]SingleStockCandle{ticker: history})[ticker]
+	}
+	for i, date := range dateKeys {
+		c := candles[date]
+		open := c.Open
+		if open <= 0 {
+			open = c.Close
+		}
+		switch {
+		case pending == Buy && shares == 0:
+			fill := open * (1 + conf.Slippage)
+			budget := cash * conf.PositionSize
+			if qty := (budget - conf.Commission) / fill; qty > 0 {
+				shares = qty
+				entryCost = shares*fill + conf.Commission
+				cash -= entryCost
+				trade = BacktestTrade{EntryDate: c.Timestamp, EntryPrice: fill, Shares: shares}
+			}
+		case pending == Sell && shares > 0:
+			fill := open * (1 - conf.Slippage)
+			proceeds := shares*fill - conf.Commission
+			cash += proceeds
+			trade.ExitDate, trade.ExitPrice = c.Timestamp, fill
+			trade.PnL = proceeds - entryCost
+			trade.Return = trade.PnL / entryCost
+			result.Trades = append(result.Trades, trade)
+			shares = 0
+		}
+		if shares > 0 {
+			heldSessions++
+		}
+		result.Equity = append(result.Equity, EquityPoint{Date: c.Timestamp, Equity: cash + shares*c.Close})
+
+		if i == len(dateKeys)-1 {
+			break
+		}
+		if conf.RerunPipeline {
+			history[date] = c
+			window := make(map[int64]SingleStockCandle, len(history))
+			for d, h := range history {
+				window[d] = h
+			}
+			analyzed = pipeline(map[string]map[int64]SingleStockCandle{ticker: window})[ticker]
+		}
+		pending = strategy(analyzed[date], shares > 0)
+	}
+	if shares > 0 {
+		last := candles[dateKeys[len(dateKeys)-1]]
+		trade.ExitDate, trade.ExitPrice, trade.Open = last.Timestamp, last.Close, true
+		trade.PnL = shares*last.Close - entryCost
+		trade.Return = trade.PnL / entryCost
+		result.Trades = append(result.Trades, trade)
+	}
+	result.Stats = backtestStats(result, conf.InitialCapital, dateKeys, heldSessions)
+	return result, nil
+}
+
+func backtestStats(result BacktestResult, initialCapital float64, dateKeys []int64, heldSessions int) BacktestStats {
+	stats := BacktestStats{StartEquity: initialCapital, EndEquity: result.Equity[len(result.Equity)-1].Equity}
+	stats.TotalReturn = stats.EndEquity/stats.StartEquity - 1
+	days := float64(dateKeys[len(dateKeys)-1]-dateKeys[0]) / float64(time.Hour.Milliseconds()*DAY)
+	if days > 0 && stats.EndEquity > 0 {
+		stats.CAGR = math.Pow(stats.EndEquity/stats.StartEquity, YEAR/days) - 1
+	}
+	equity := make([]float64, len(result.Equity))
+	for i, point := range result.Equity {
+		equity[i] = point.Equity
+	}
+	stats.MaxDrawdown = calculateDrawdown(equity).Max
+	var wins int
+	for _, trade := range result.Trades {
+		if trade.Open {
+			continue
+		}
+		stats.Trades++
+		if trade.PnL > 0 {
+			wins++
+		}
+	}
+	if stats.Trades > 0 {
+		stats.WinRate = float64(wins) / float64(stats.Trades)
+	}
+	stats.Exposure = float64(heldSessions) / float64(len(dateKeys))
+	return stats
+}
@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// makeBacktestCandles builds consecutive daily candles from the given opens and closes.
+func makeBacktestCandles(opens, closes []float64) map[int64]SingleStockCandle {
+	candles := map[int64]SingleStockCandle{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range closes {
+		ts := start.AddDate(0, 0, i)
+		candles[ts.UnixMilli()] = SingleStockCandle{Open: opens[i], Close: closes[i], Timestamp: ts}
+	}
+	return candles
+}
+
+func identityPipeline(stockPrices map[string]map[int64]SingleStockCandle) map[string]map[int64]SingleStockCandle {
+	return stockPrices
+}
+
+func TestBacktest_FillsAtNextOpenWithCosts(t *testing.T) {
+	candles := makeBacktestCandles([]float64{10, 10, 11, 12, 13}, []float64{10, 11, 12, 13, 14})
+	day := 0
+	// Buy after the first close and sell after the third.
+	strategy := func(c SingleStockCandle, holding bool) Signal {
+		day++
+		switch {
+		case day == 1 && !holding:
+			return Buy
+		case day == 3 && holding:
+			return Sell
+		}
+		return Hold
+	}
+	conf := BacktestConf{InitialCapital: 1000, PositionSize: 0.5, Commission: 1, Slippage: 0.01}
+	result, err := Backtest("TEST", candles, identityPipeline, strategy, conf)
+	if err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+	if len(result.Trades) != 1 {
+		t.Fatalf("expected one trade, got %+v", result.Trades)
+	}
+	trade := result.Trades[0]
+	// Entry at day 1's open of 10 plus 1% slippage with (500-1)/10.1 shares; exit at day 3's open of 12 less 1%.
+	shares := 499 / 10.1
+	wantPnL := (shares*11.88 - 1) - 500
+	if math.Abs(trade.EntryPrice-10.1) > 1e-12 || math.Abs(trade.ExitPrice-11.88) > 1e-12 ||
+		math.Abs(trade.Shares-shares) > 1e-12 || math.Abs(trade.PnL-wantPnL) > 1e-9 || trade.Open {
+		t.Errorf("trade = %+v, want entry 10.1, exit 11.88, shares %v, pnl %v", trade, shares, wantPnL)
+	}
+	if len(result.Equity) != 5 {
+		t.Fatalf("expected an equity point per session, got %d", len(result.Equity))
+	}
+	if got, want := result.Equity[1].Equity, 500+shares*11; math.Abs(got-want) > 1e-9 {
+		t.Errorf("equity after entry = %v, want %v", got, want)
+	}
+	stats := result.Stats
+	if stats.Trades != 1 || stats.WinRate != 1 || math.Abs(stats.EndEquity-(1000+wantPnL)) > 1e-9 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.Exposure != 0.4 {
+		t.Errorf("Exposure = %v, want 0.4 (held at the close of sessions 1 and 2)", stats.Exposure)
+	}
+}
+
+func TestBacktest_NoLookAhead(t *testing.T) {
+	candles := makeBacktestCandles([]float64{1, 2, 3, 4, 5, 6}, []float64{1, 2, 3, 4, 5, 6})
+	var latestSeen int64
+	pipeline := func(stockPrices map[string]map[int64]SingleStockCandle) map[string]map[int64]SingleStockCandle {
+		latestSeen = 0
+		for date := range stockPrices["TEST"] {
+			latestSeen = max(latestSeen, date)
+		}
+		return stockPrices
+	}
+	strategy := func(c SingleStockCandle, holding bool) Signal {
+		if c.Timestamp.UnixMilli() != latestSeen {
+			t.Errorf("strategy for %v ran on history through %v", c.Timestamp, time.UnixMilli(latestSeen))
+		}
+		return Hold
+	}
+	if _, err := Backtest("TEST", candles, pipeline, strategy, BacktestConf{RerunPipeline: true}); err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+}
+
+func TestBacktest_OpenPositionMarkedToLastClose(t *testing.T) {
+	candles := makeBacktestCandles([]float64{10, 10, 8}, []float64{10, 9, 8})
+	buy := func(c SingleStockCandle, holding bool) Signal { return Buy }
+	result, err := Backtest("TEST", candles, identityPipeline, buy, BacktestConf{InitialCapital: 100})
+	if err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+	if len(result.Trades) != 1 || !result.Trades[0].Open || result.Trades[0].ExitPrice != 8 {
+		t.Fatalf("expected one open trade marked at 8, got %+v", result.Trades)
+	}
+	if result.Stats.Trades != 0 || result.Stats.WinRate != 0 {
+		t.Errorf("open trades should not count towards closed trade stats: %+v", result.Stats)
+	}
+	if math.Abs(result.Stats.MaxDrawdown-0.2) > 1e-12 || math.Abs(result.Stats.TotalReturn+0.2) > 1e-12 {
+		t.Errorf("MaxDrawdown = %v, TotalReturn = %v, want 0.2 and -0.2", result.Stats.MaxDrawdown,
+			result.Stats.TotalReturn)
+	}
+
+	if _, err := Backtest("TEST", makeBacktestCandles([]float64{1}, []float64{1}), identityPipeline, buy,
+		BacktestConf{}); err == nil {
+		t.Error("expected error for a single candle")
+	}
+}
+
+func TestRangeStrategy(t *testing.T) {
+	strategy := RangeStrategy(SHORTDURATION, MEDIUMDURATION, 0.1, 0.2)
+	band := map[string]float64{"high": 110, "low": 90}
+	tests := []struct {
+		name    string
+		candle  SingleStockCandle
+		holding bool
+		want    Signal
+	}{
+		{"near low in a bullish trend", SingleStockCandle{Close: 91, TrendDirection: "Bullish", PTradeRangeAdj: band},
+			false, Buy},
+		{"near low without a bullish trend", SingleStockCandle{Close: 91, TrendDirection: "Neutral",
+			PTradeRangeAdj: band}, false, Hold},
+		{"mid range", SingleStockCandle{Close: 100, TrendDirection: "Bullish", PTradeRangeAdj: band}, false, Hold},
+		{"near high while holding", SingleStockCandle{Close: 106, PTradeRangeAdj: band}, true, Sell},
+		{"below exit band while holding", SingleStockCandle{Close: 105, PTradeRangeAdj: band}, true, Hold},
+		{"no range yet", SingleStockCandle{Close: 91, TrendDirection: "Bullish"}, false, Hold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strategy(tt.candle, tt.holding); got != tt.want {
+				t.Errorf("RangeStrategy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBacktest_RangePipeline(t *testing.T) {
+	data := makeTestData("AAPL", 60)
+	strategy := RangeStrategy(SHORTDURATION, SHORTDURATION, 0.1, 0.1)
+	result, err := Backtest("AAPL", data["AAPL"], RangePipeline(StockDataConf{}), strategy, BacktestConf{})
+	if err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+	// makeTestData declines every day, so the short direction is never Bullish and nothing is bought.
+	if len(result.Trades) != 0 || result.Stats.EndEquity != 10000 {
+		t.Errorf("expected no trades on a steady decline, got %+v", result.Stats)
+	}
+}
+
+func TestBacktest_RerunPipelineMatchesSingleRun(t *testing.T) {
+	data := makeTestData("AAPL", 60)
+	var once, rerun []SingleStockCandle
+	record := func(seen *[]SingleStockCandle) Strategy {
+		return func(c SingleStockCandle, holding bool) Signal {
+			*seen = append(*seen, c)
+			return Hold
+		}
+	}
+	pipeline := RangePipeline(StockDataConf{})
+	if _, err := Backtest("AAPL", data["AAPL"], pipeline, record(&once), BacktestConf{}); err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+	conf := BacktestConf{RerunPipeline: true}
+	if _, err := Backtest("AAPL", data["AAPL"], pipeline, record(&rerun), conf); err != nil {
+		t.Fatalf("Backtest() error = %v", err)
+	}
+	if len(once) != len(rerun) {
+		t.Fatalf("strategy ran %d times on a single run and %d on reruns", len(once), len(rerun))
+	}
+	for i := range once {
+		if !reflect.DeepEqual(once[i], rerun[i]) {
+			t.Fatalf("session %d differs:\nsingle run %+v\nrerun      %+v", i, once[i], rerun[i])
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+// Rebalancing schedules accepted by BasketConf.Rebalance.
+const (
+	REBALANCENONE      = "none"
+	REBALANCEDAILY     = "daily"
+	REBALANCEWEEKLY    = "weekly"
+	REBALANCEMONTHLY   = "monthly"
+	REBALANCEQUARTERLY = "quarterly"
+	REBALANCEANNUALLY  = "annually"
+)
+
+// BASKETBASELEVEL is the level a basket index starts at when its config does not set one.
+const BASKETBASELEVEL = 100
+
+// BasketConf defines a custom index as target weights of member tickers. Weights are relative and scaled to sum to
+// one. Rebalance sets how often the members are reset to their target weights, at the close of the last session of
+// each period: none (buy and hold), daily, weekly, monthly, quarterly (the default), or annually. Base is the
+// starting index level, 100 by default.
+type BasketConf struct {
+	Members   map[string]float64 `json:"members"`
+	Rebalance string             `json:"rebalance"`
+	Base      float64            `json:"base"`
+}
+
+// Validate reports a basket without members, with a weight that is not positive, or with an unknown schedule.
+func (b BasketConf) Validate() error {
+	if len(b.Members) == 0 {
+		return errors.New("basket has no members")
+	}
+	for ticker, weight := range b.Members {
+		if weight <= 0 {
+			return fmt.Errorf("weight of %s must be greater than zero, got %g", ticker, weight)
+		}
+	}
+	switch b.Rebalance {
+	case "", REBALANCENONE, REBALANCEDAILY, REBALANCEWEEKLY, REBALANCEMONTHLY, REBALANCEQUARTERLY, REBALANCEANNUALLY:
+	default:
+		return fmt.Errorf("unknown rebalance schedule %q", b.Rebalance)
+	}
+	if b.Base < 0 {
+		return fmt.Errorf("base must not be negative, got %g", b.Base)
+	}
+	return nil
+}
+
+// AssetClass is the asset class shared by every member, or equity when they differ, since the index only has a
+// session when every member trades.
+func (b BasketConf) AssetClass() store.AssetClass {
+	var class store.AssetClass
+	for ticker := range b.Members {
+		memberClass := AssetClassOf(ticker)
+		if class != "" && memberClass != class {
+			return store.AssetClassEquity
+		}
+		class = memberClass
+	}
+	return class
+}
+
+// Basket looks up the basket configured under name, ignoring case.
+func (c StockDataConf) Basket(name string) (BasketConf, bool) {
+	name = NormalizeTicker(name)
+	for basketName, basket := range c.Baskets {
+		if NormalizeTicker(basketName) == name {
+			return basket, true
+		}
+	}
+	return BasketConf{}, false
+}
+
+// basketClasses holds the asset class of each registered basket, by normalized name. It is kept apart from returnConf
+// so a later SetReturnConf does not drop it; a class set for the basket's name in the returns config still wins.
+var basketClasses = map[string]store.AssetClass{}
+
+// RegisterBaskets validates the baskets and annualizes each by its members' asset class, unless the returns config
+// sets one for the basket's name.
+func RegisterBaskets(baskets map[string]BasketConf) error {
+	classes := make(map[string]store.AssetClass, len(baskets))
+	for name, basket := range baskets {
+		if err := basket.Validate(); err != nil {
+			return fmt.Errorf("basket %s: %w", name, err)
+		}
+		classes[NormalizeTicker(name)] = basket.AssetClass()
+	}
+	basketClasses = classes
+	return nil
+}
+
+// newRebalancePeriod reports whether next falls in a later rebalancing period than day.
+func newRebalancePeriod(schedule string, day, next time.Time) bool {
+	switch schedule {
+	case REBALANCENONE:
+		return false
+	case REBALANCEDAILY:
+		return true
+	case REBALANCEWEEKLY:
+		year, week := day.ISOWeek()
+		nextYear, nextWeek := next.ISOWeek()
+		return year != nextYear || week != nextWeek
+	case REBALANCEMONTHLY:
+		return day.Year() != next.Year() || day.Month() != next.Month()
+	case REBALANCEANNUALLY:
+		return day.Year() != next.Year()
+	}
+	return day.Year() != next.Year() || (day.Month()-1)/3 != (next.Month()-1)/3
+}
+
+// BuildBasket derives the daily index series of the basket from its members' candles, keyed by name. Days are
+// aligned on the UTC date and only days every member traded are kept. The index holds shares of each member sized
+// to the target weights at the first close and at the close of each rebalance, so between rebalances the weights
+// drift with prices. Open, high, low, close, and the volume-weighted price are the holdings valued at each member's
+// price; the high and low are bounds, as members need not peak together. Volume is the members' dollar volume in
+// index units, and transactions are summed.
+func BuildBasket(name string, basket BasketConf, members map[string]map[int64]SingleStockCandle) (map[string]map[int64]SingleStockCandle, error) {
+	if err := basket.Validate(); err != nil {
+		return nil, fmt.Errorf("basket %s: %w", name, err)
+	}
+	name = NormalizeTicker(name)
+	base := basket.Base
+	if base == 0 {
+		base = BASKETBASELEVEL
+	}
+	var totalWeight float64
+	for _, weight := range basket.Members {
+		totalWeight += weight
+	}
+
+	// Index each member's candles by UTC day and keep the days every member has
+	byDay := map[string]map[int64]SingleStockCandle{}
+	counts := map[int64]int{}
+	for ticker := range basket.Members {
+		candles, ok := members[NormalizeTicker(ticker)]
+		if !ok || len(candles) == 0 {
+			return nil, fmt.Errorf("basket %s: no candles for member %s", name, ticker)
+		}
+		byDay[ticker] = make(map[int64]SingleStockCandle, len(candles))
+		for _, c := range candles {
+			day := truncateToDay(c.Timestamp).UnixMilli()
+			if _, seen := byDay[ticker][day]; !seen {
+				counts[day]++
+			}
+			byDay[ticker][day] = c
+		}
+	}
+	var days []int64
+	for day, count := range counts {
+		if count == len(basket.Members) {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+
+	series := map[int64]SingleStockCandle{}
+	shares := map[string]float64{}
+	rebalance := func(level float64, day int64) {
+		for ticker, weight := range basket.Members {
+			shares[ticker] = weight / totalWeight * level / byDay[ticker][day].Close
+		}
+	}
+	for i, day := range days {
+		if i == 0 {
+			rebalance(base, day)
+		}
+		candle := SingleStockCandle{Ticker: name, Timestamp: time.UnixMilli(day).UTC()}
+		var dollarVolume float64
+		for ticker, held := range shares {
+			c := byDay[ticker][day]
+			candle.Open += held * c.Open
+			candle.High += held * c.High
+			candle.Low += held * c.Low
+			candle.Close += held * c.Close
+			candle.WeightedVolume += held * c.WeightedVolume
+			candle.Transactions += c.Transactions
+			dollarVolume += c.Volume * c.Close
+		}
+		if candle.Close <= 0 {
+			return nil, fmt.Errorf("basket %s has no value on %s", name, candle.Timestamp.Format(time.DateOnly))
+		}
+		candle.Volume = dollarVolume / candle.Close
+		series[day] = candle
+		if i+1 < len(days) && newRebalancePeriod(basket.Rebalance, candle.Timestamp, time.UnixMilli(days[i+1]).UTC()) {
+			rebalance(candle.Close, day)
+		}
+	}
+	return map[string]map[int64]SingleStockCandle{name: series}, nil
+}
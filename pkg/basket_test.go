@@ -0,0 +1,164 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+func TestBasketConfValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		basket  BasketConf
+		wantErr bool
+	}{
+		{"valid", BasketConf{Members: map[string]float64{"A": 1, "B": 3}, Rebalance: REBALANCEMONTHLY}, false},
+		{"default schedule", BasketConf{Members: map[string]float64{"A": 1}}, false},
+		{"no members", BasketConf{}, true},
+		{"zero weight", BasketConf{Members: map[string]float64{"A": 0}}, true},
+		{"unknown schedule", BasketConf{Members: map[string]float64{"A": 1}, Rebalance: "hourly"}, true},
+		{"negative base", BasketConf{Members: map[string]float64{"A": 1}, Base: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.basket.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRebalancePeriod(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		schedule  string
+		day, next time.Time
+		want      bool
+	}{
+		{REBALANCENONE, day(3, 31), day(4, 1), false},
+		{REBALANCEDAILY, day(3, 3), day(3, 4), true},
+		{REBALANCEWEEKLY, day(3, 4), day(3, 7), false},
+		{REBALANCEWEEKLY, day(3, 7), day(3, 10), true},
+		{REBALANCEMONTHLY, day(3, 28), day(3, 31), false},
+		{REBALANCEMONTHLY, day(3, 31), day(4, 1), true},
+		{REBALANCEQUARTERLY, day(2, 28), day(3, 3), false},
+		{REBALANCEQUARTERLY, day(3, 31), day(4, 1), true},
+		{"", day(3, 31), day(4, 1), true},
+		{REBALANCEANNUALLY, day(6, 30), day(7, 1), false},
+	}
+	for _, tt := range tests {
+		if got := newRebalancePeriod(tt.schedule, tt.day, tt.next); got != tt.want {
+			t.Errorf("newRebalancePeriod(%q, %s, %s) = %v, want %v", tt.schedule, tt.day.Format(time.DateOnly),
+				tt.next.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestBuildBasket(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 21, 0, 0, 0, time.UTC) }
+	series := func(closes ...float64) map[int64]SingleStockCandle {
+		candles := map[int64]SingleStockCandle{}
+		for i, c := range closes {
+			candles[day(i+1).UnixMilli()] = SingleStockCandle{Timestamp: day(i + 1), Open: c, High: c, Low: c, Close: c,
+				Volume: 10}
+		}
+		return candles
+	}
+	// A doubles on the 2nd and stays flat; B stays flat. B has no bar on the 4th, so the basket skips it.
+	b := series(50, 50, 50, 50)
+	delete(b, day(4).UnixMilli())
+	members := map[string]map[int64]SingleStockCandle{"A": series(10, 20, 20, 40), "B": b}
+	tests := []struct {
+		rebalance string
+		want      []float64
+	}{
+		// Buy and hold: half in each, so A's doubling lifts the index by half.
+		{REBALANCENONE, []float64{100, 150, 150}},
+		// Rebalancing back to half and half each day leaves the index where it was after the move.
+		{REBALANCEDAILY, []float64{100, 150, 150}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rebalance, func(t *testing.T) {
+			basket := BasketConf{Members: map[string]float64{"a": 1, "B": 1}, Rebalance: tt.rebalance}
+			built, err := BuildBasket("my basket", basket, members)
+			if err != nil {
+				t.Fatalf("BuildBasket() error = %v", err)
+			}
+			got := built["MY BASKET"]
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d days, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				c := got[time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC).UnixMilli()]
+				if math.Abs(c.Close-want) > 1e-9 {
+					t.Errorf("day %d close = %v, want %v", i+1, c.Close, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildBasket_RebalanceResetsWeights(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	candles := func(closes ...float64) map[int64]SingleStockCandle {
+		m := map[int64]SingleStockCandle{}
+		for i, c := range closes {
+			m[day(i+1).UnixMilli()] = SingleStockCandle{Timestamp: day(i + 1), Close: c, Open: c, High: c, Low: c}
+		}
+		return m
+	}
+	// A doubles, then halves. Held, the index returns to 100; rebalanced daily, it ends lower.
+	members := map[string]map[int64]SingleStockCandle{"A": candles(10, 20, 10), "B": candles(50, 50, 50)}
+	held, _ := BuildBasket("H", BasketConf{Members: map[string]float64{"A": 1, "B": 1}, Rebalance: REBALANCENONE},
+		members)
+	daily, _ := BuildBasket("D", BasketConf{Members: map[string]float64{"A": 1, "B": 1}, Rebalance: REBALANCEDAILY},
+		members)
+	if got := held["H"][day(3).UnixMilli()].Close; math.Abs(got-100) > 1e-9 {
+		t.Errorf("buy and hold close = %v, want 100", got)
+	}
+	// After day 2 the index is 150, reset to 75 in each; A halving takes its half to 37.5.
+	if got := daily["D"][day(3).UnixMilli()].Close; math.Abs(got-112.5) > 1e-9 {
+		t.Errorf("daily rebalanced close = %v, want 112.5", got)
+	}
+}
+
+func TestBuildBasket_MissingMember(t *testing.T) {
+	basket := BasketConf{Members: map[string]float64{"A": 1, "B": 1}}
+	members := map[string]map[int64]SingleStockCandle{"A": {1: {Close: 1}}}
+	if _, err := BuildBasket("X", basket, members); err == nil {
+		t.Error("expected an error for a member without candles")
+	}
+}
+
+func TestRegisterBaskets(t *testing.T) {
+	withReturnConf(t, ReturnConf{AssetClasses: map[string]store.AssetClass{"FX": store.AssetClassForex}})
+	baskets := map[string]BasketConf{
+		"coins": {Members: map[string]float64{"X:BTCUSD": 1, "X:ETHUSD": 1}},
+		"mixed": {Members: map[string]float64{"X:BTCUSD": 1, "SPY": 1}},
+		"fx":    {Members: map[string]float64{"X:BTCUSD": 1}},
+	}
+	previous := basketClasses
+	t.Cleanup(func() { basketClasses = previous })
+	if err := RegisterBaskets(baskets); err != nil {
+		t.Fatalf("RegisterBaskets() error = %v", err)
+	}
+	for ticker, want := range map[string]store.AssetClass{
+		"COINS": store.AssetClassCrypto, "MIXED": store.AssetClassEquity, "FX": store.AssetClassForex,
+	} {
+		if got := AssetClassOf(ticker); got != want {
+			t.Errorf("AssetClassOf(%q) = %q, want %q", ticker, got, want)
+		}
+	}
+	// Setting the returns config again keeps the registered baskets.
+	if err := SetReturnConf(ReturnConf{Convention: SIMPLERETURNS}); err != nil {
+		t.Fatal(err)
+	}
+	if got := AssetClassOf("coins"); got != store.AssetClassCrypto {
+		t.Errorf("AssetClassOf(coins) after SetReturnConf = %q, want %q", got, store.AssetClassCrypto)
+	}
+	if err := RegisterBaskets(map[string]BasketConf{"bad": {}}); err == nil {
+		t.Error("expected an error for an invalid basket")
+	}
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	gonum "gonum.org/v1/gonum/stat"
+)
+
+// CorrelationMatrix is the pairwise correlation of daily log returns across a watchlist over one duration window
+// ending at the latest date in the batch. Pairs without at least three overlapping returns are omitted.
+type CorrelationMatrix struct {
+	Duration     int                           `json:"duration"`
+	Tickers      []string                      `json:"tickers"`
+	Correlations map[string]map[string]float64 `json:"correlations"`
+}
+
+// closesByDate re-keys a ticker's closes by time.DateOnly so series from different feeds (which stamp daily bars at
+// different times of day) line up by session.
+func closesByDate(candles map[int64]SingleStockCandle) map[string]float64 {
+	closes := make(map[string]float64, len(candles))
+	for dateMilli, c := range candles {
+		closes[time.UnixMilli(dateMilli).UTC().Format(time.DateOnly)] = c.Close
+	}
+	return closes
+}
+
+// alignedReturns returns the log returns of a and b between consecutive dates that both series have, restricted to
+// dates in [from, to].
+func alignedReturns(a, b map[string]float64, from, to string) (ra, rb []float64) {
+	var dates []string
+	for date := range a {
+		if _, ok := b[date]; ok && date >= from && date <= to {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	for i := 1; i < len(dates); i++ {
+		ra = append(ra, math.Log(a[dates[i]]/a[dates[i-1]]))
+		rb = append(rb, math.Log(b[dates[i]]/b[dates[i-1]]))
+	}
+	return ra, rb
+}
+
+// calculateBeta returns the beta and correlation of returns against benchmark returns. ok is false when there are
+// fewer than three returns or the benchmark did not move.
+func calculateBeta(returns, benchReturns []float64) (beta, correlation float64, ok bool) {
+	if len(returns) < 3 || len(returns) != len(benchReturns) {
+		return 0, 0, false
+	}
+	benchVar := gonum.Variance(benchReturns, nil)
+	if benchVar == 0 || gonum.Variance(returns, nil) == 0 {
+		return 0, 0, false
+	}
+	beta = gonum.Covariance(returns, benchReturns, nil) / benchVar
+	correlation = gonum.Correlation(returns, benchReturns, nil)
+	return beta, correlation, true
+}
+
+// CalculateBenchmarkStats stores the rolling beta and correlation of each ticker's daily log returns against the
+// benchmark's over each day's duration window.
+func CalculateBenchmarkStats(stockPrices map[string]map[int64]SingleStockCandle, benchmark map[int64]SingleStockCandle,
+	duration int) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	benchCloses := closesByDate(benchmark)
+	for ticker := range stockPrices {
+		closes := closesByDate(stockPrices[ticker])
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		reverseDateKeys := dateKeys
+		sort.Slice(reverseDateKeys, func(i, j int) bool {
+			return reverseDateKeys[i] > reverseDateKeys[j]
+		})
+		for index, date := range reverseDateKeys {
+			windowDates, ok := collectWindowDates(reverseDateKeys, index, duration)
+			if !ok {
+				continue
+			}
+			from := time.UnixMilli(windowDates[len(windowDates)-1]).UTC().Format(time.DateOnly)
+			to := time.UnixMilli(date).UTC().Format(time.DateOnly)
+			beta, corr, ok := calculateBeta(alignedReturns(closes, benchCloses, from, to))
+			if !ok {
+				continue
+			}
+			stockCandle := stockPrices[ticker][date]
+			setBeta(&stockCandle, duration, beta)
+			setBenchCorr(&stockCandle, duration, corr)
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
+
+// CalculateCorrelationMatrix correlates every pair of tickers in the batch over the duration calendar days ending at
+// the latest date found in any of the series.
+func CalculateCorrelationMatrix(batch map[string]map[int64]SingleStockCandle, duration int) CorrelationMatrix {
+	matrix := CorrelationMatrix{Duration: duration, Correlations: map[string]map[string]float64{}}
+	closes := map[string]map[string]float64{}
+	latest := ""
+	for ticker, candles := range batch {
+		matrix.Tickers = append(matrix.Tickers, ticker)
+		closes[ticker] = closesByDate(candles)
+		for date := range closes[ticker] {
+			if date > latest {
+				latest = date
+			}
+		}
+	}
+	sort.Strings(matrix.Tickers)
+	if latest == "" {
+		return matrix
+	}
+	end, _ := time.Parse(time.DateOnly, latest)
+	from := end.AddDate(0, 0, -duration).Format(time.DateOnly)
+
+	for i, a := range matrix.Tickers {
+		for _, b := range matrix.Tickers[i:] {
+			ra, rb := alignedReturns(closes[a], closes[b], from, latest)
+			if len(ra) < 3 || gonum.Variance(ra, nil) == 0 || gonum.Variance(rb, nil) == 0 {
+				continue
+			}
+			corr := gonum.Correlation(ra, rb, nil)
+			if matrix.Correlations[a] == nil {
+				matrix.Correlations[a] = map[string]float64{}
+			}
+			if matrix.Correlations[b] == nil {
+				matrix.Correlations[b] = map[string]float64{}
+			}
+			matrix.Correlations[a][b] = corr
+			matrix.Correlations[b][a] = corr
+		}
+	}
+	return matrix
+}
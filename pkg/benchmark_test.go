@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// makeBenchmarkPair builds a benchmark with alternating daily moves and a ticker whose closes are the benchmark's
+// squared, so its log returns are exactly twice the benchmark's.
+func makeBenchmarkPair(numDays int) (ticker, bench map[int64]SingleStockCandle) {
+	ticker = map[int64]SingleStockCandle{}
+	bench = map[int64]SingleStockCandle{}
+	start := time.Date(2025, 1, 1, 5, 0, 0, 0, time.UTC)
+	price := 100.0
+	for i := 0; i < numDays; i++ {
+		if i%3 == 0 {
+			price *= 0.99
+		} else {
+			price *= 1.01
+		}
+		ts := start.AddDate(0, 0, i)
+		bench[ts.UnixMilli()] = SingleStockCandle{Ticker: "SPY", Close: price, Timestamp: ts}
+		// Offset the ticker's bar time to prove alignment is by session date, not timestamp.
+		tts := ts.Add(-time.Hour)
+		ticker[tts.UnixMilli()] = SingleStockCandle{Ticker: "TQQQ", Close: price * price, Timestamp: tts}
+	}
+	return ticker, bench
+}
+
+func TestCalculateBeta(t *testing.T) {
+	beta, corr, ok := calculateBeta([]float64{0.02, -0.04, 0.06, 0.01}, []float64{0.01, -0.02, 0.03, 0.005})
+	if !ok || math.Abs(beta-2) > 1e-9 || math.Abs(corr-1) > 1e-9 {
+		t.Errorf("calculateBeta() = %v, %v, %v; want 2, 1, true", beta, corr, ok)
+	}
+	if _, _, ok := calculateBeta([]float64{0.01, 0.02}, []float64{0.01, 0.02}); ok {
+		t.Error("expected ok=false for fewer than 3 returns")
+	}
+	if _, _, ok := calculateBeta([]float64{0.01, 0.02, 0.03}, []float64{0, 0, 0}); ok {
+		t.Error("expected ok=false for a flat benchmark")
+	}
+}
+
+func TestCalculateBenchmarkStats(t *testing.T) {
+	ticker, bench := makeBenchmarkPair(60)
+	data := map[string]map[int64]SingleStockCandle{"TQQQ": ticker}
+	result := CalculateBenchmarkStats(data, bench, SHORTDURATION)
+
+	populated := 0
+	for _, c := range result["TQQQ"] {
+		if c.BetaShort == 0 {
+			continue
+		}
+		populated++
+		if math.Abs(c.BetaShort-2) > 1e-9 || math.Abs(c.BenchCorrShort-1) > 1e-9 {
+			t.Errorf("%s: beta=%v corr=%v, want 2 and 1", c.Timestamp, c.BetaShort, c.BenchCorrShort)
+		}
+		if c.BetaMed != 0 || c.BetaLong != 0 {
+			t.Errorf("Med/Long beta should not be set by SHORTDURATION call")
+		}
+	}
+	if populated == 0 {
+		t.Error("expected at least one candle with BetaShort populated")
+	}
+}
+
+func TestCalculateCorrelationMatrix(t *testing.T) {
+	ticker, bench := makeBenchmarkPair(60)
+	inverse := map[int64]SingleStockCandle{}
+	for date, c := range bench {
+		inverse[date] = SingleStockCandle{Close: 10000 / c.Close, Timestamp: c.Timestamp}
+	}
+	batch := map[string]map[int64]SingleStockCandle{"SPY": bench, "TQQQ": ticker, "SH": inverse}
+	matrix := CalculateCorrelationMatrix(batch, SHORTDURATION)
+
+	if len(matrix.Tickers) != 3 || matrix.Tickers[0] != "SH" || matrix.Tickers[2] != "TQQQ" {
+		t.Fatalf("Tickers = %v, want sorted [SH SPY TQQQ]", matrix.Tickers)
+	}
+	checks := []struct {
+		a, b string
+		want float64
+	}{
+		{"SPY", "SPY", 1},
+		{"SPY", "TQQQ", 1},
+		{"TQQQ", "SPY", 1},
+		{"SPY", "SH", -1},
+		{"SH", "TQQQ", -1},
+	}
+	for _, c := range checks {
+		if got, ok := matrix.Correlations[c.a][c.b]; !ok || math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("corr(%s,%s) = %v (present=%v), want %v", c.a, c.b, got, ok, c.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RangeCalibration counts how a risk range held up over the sessions after it was published, those in the
+// following HorizonDays calendar days. Within is path-based: a sample stays Within when no high or low in those
+// sessions crossed the band, and a sample can breach both sides. CloseWithin is terminal: the close of the last of
+// those sessions was inside the band. Rates are fractions of Samples. StatedCoverage is the mean coverage the range
+// claimed, when it states one; the quantile ranges state it for the close at the horizon, so it compares with
+// CloseWithinRate.
+type RangeCalibration struct {
+	HorizonDays     int     `json:"horizon-days"`
+	Samples         int     `json:"samples"`
+	Within          int     `json:"within"`
+	BreachHigh      int     `json:"breach-high"`
+	BreachLow       int     `json:"breach-low"`
+	BreachBoth      int     `json:"breach-both"`
+	CloseWithin     int     `json:"close-within"`
+	WithinRate      float64 `json:"within-rate"`
+	BreachHighRate  float64 `json:"breach-high-rate"`
+	BreachLowRate   float64 `json:"breach-low-rate"`
+	CloseWithinRate float64 `json:"close-within-rate"`
+	StatedCoverage  float64 `json:"stated-coverage,omitempty"`
+	coverageSum     float64
+}
+
+// CalibrationReport is the calibration of every range per ticker, keyed by the range's JSON name, and summed across
+// the batch in Aggregate.
+type CalibrationReport struct {
+	Tickers   map[string]map[string]RangeCalibration `json:"tickers"`
+	Aggregate map[string]RangeCalibration            `json:"aggregate"`
+}
+
+// calibratedRange is one published range, named as it appears on SingleStockCandle.
+type calibratedRange struct {
+	name     string
+	duration int
+	get      func(c SingleStockCandle, d int) map[string]float64
+}
+
+func calibratedRanges() []calibratedRange {
+	var ranges []calibratedRange
+	for _, d := range []struct {
+		label    string
+		duration int
+	}{{"trade", SHORTDURATION}, {"trend", MEDIUMDURATION}, {"tail", LONGDURATION}} {
+		ranges = append(ranges,
+			calibratedRange{fmt.Sprintf("%s-range", d.label), d.duration, getRiskRange},
+			calibratedRange{fmt.Sprintf("%s-range-vadj", d.label), d.duration, getAdjRiskRange},
+			calibratedRange{fmt.Sprintf("prob-adj-%s-range", d.label), d.duration, getProbRiskRange},
+			calibratedRange{fmt.Sprintf("prob-%s-range-vadj", d.label), d.duration, getProbAdjRiskRange},
+			calibratedRange{fmt.Sprintf("quantile-%s-range", d.label), d.duration, getQuantileRange},
+		)
+	}
+	return ranges
+}
+
+func (r *RangeCalibration) add(other RangeCalibration) {
+	r.HorizonDays = other.HorizonDays
+	r.Samples += other.Samples
+	r.Within += other.Within
+	r.BreachHigh += other.BreachHigh
+	r.BreachLow += other.BreachLow
+	r.BreachBoth += other.BreachBoth
+	r.CloseWithin += other.CloseWithin
+	r.coverageSum += other.coverageSum
+}
+
+func (r *RangeCalibration) finish() {
+	if r.Samples == 0 {
+		return
+	}
+	n := float64(r.Samples)
+	r.WithinRate = float64(r.Within) / n
+	r.BreachHighRate = float64(r.BreachHigh) / n
+	r.BreachLowRate = float64(r.BreachLow) / n
+	r.CloseWithinRate = float64(r.CloseWithin) / n
+	r.StatedCoverage = r.coverageSum / n
+}
+
+// scoreRange scores the band get returns for each day in [from, to] against the highs and lows of the sessions in
+// the duration (in calendar days) after it, and against the close of the last of them. Days whose duration runs past
+// limit are skipped, so outcomes after limit never count. dateKeys must be sorted oldest first.
+func scoreRange(candles map[int64]SingleStockCandle, dateKeys []int64,
+	get func(c SingleStockCandle, d int) map[string]float64, duration int, from, to, limit int64) RangeCalibration {
+	result := RangeCalibration{HorizonDays: duration}
+	for i, date := range dateKeys {
+		if date < from || date > to {
+			continue
+		}
+		horizonEnd := time.UnixMilli(date).AddDate(0, 0, duration).UnixMilli()
+		if horizonEnd > limit {
+			break
+		}
+		band := get(candles[date], duration)
+		if band == nil || band["high"] <= band["low"] {
+			continue
+		}
+		var high, low bool
+		var last int64
+		for _, dateKey := range dateKeys[i+1:] {
+			if dateKey > horizonEnd {
+				break
+			}
+			high = high || candles[dateKey].High > band["high"]
+			low = low || candles[dateKey].Low < band["low"]
+			last = dateKey
+		}
+		if last == 0 {
+			continue
+		}
+		if closed := candles[last].Close; closed >= band["low"] && closed <= band["high"] {
+			result.CloseWithin++
+		}
+		result.Samples++
+		result.coverageSum += band["coverage"]
+		switch {
+		case high && low:
+			result.BreachBoth++
+			result.BreachHigh++
+			result.BreachLow++
+		case high:
+			result.BreachHigh++
+		case low:
+			result.BreachLow++
+		default:
+			result.Within++
+		}
+	}
+	return result
+}
+
+// sortedDateKeys returns the dates of candles oldest first.
+func sortedDateKeys(candles map[int64]SingleStockCandle) []int64 {
+	var dateKeys []int64
+	for dateKey := range candles {
+		dateKeys = append(dateKeys, dateKey)
+	}
+	sort.Slice(dateKeys, func(i, j int) bool {
+		return dateKeys[i] < dateKeys[j]
+	})
+	return dateKeys
+}
+
+// calibrateTicker scores every day's ranges against the sessions that follow it. Days too close to the end of the
+// series to have a full duration after them are skipped.
+func calibrateTicker(candles map[int64]SingleStockCandle) map[string]RangeCalibration {
+	dateKeys := sortedDateKeys(candles)
+	results := map[string]RangeCalibration{}
+	if len(dateKeys) == 0 {
+		return results
+	}
+	first, last := dateKeys[0], dateKeys[len(dateKeys)-1]
+	for _, cr := range calibratedRanges() {
+		if result := scoreRange(candles, dateKeys, cr.get, cr.duration, first, last, last); result.Samples > 0 {
+			results[cr.name] = result
+		}
+	}
+	return results
+}
+
+// CalculateRangeCalibration measures how often each published risk range contained the price over the sessions in
+// the next duration of calendar days, and at the last of their closes, for every ticker in the batch and in
+// aggregate. The ranges must already be calculated on the candles.
+func CalculateRangeCalibration(batch map[string]map[int64]SingleStockCandle) CalibrationReport {
+	report := CalibrationReport{
+		Tickers:   map[string]map[string]RangeCalibration{},
+		Aggregate: map[string]RangeCalibration{},
+	}
+	for ticker, candles := range batch {
+		results := calibrateTicker(candles)
+		for name, result := range results {
+			aggregate := report.Aggregate[name]
+			aggregate.add(result)
+			report.Aggregate[name] = aggregate
+			result.finish()
+			results[name] = result
+		}
+		report.Tickers[ticker] = results
+	}
+	for name, aggregate := range report.Aggregate {
+		aggregate.finish()
+		report.Aggregate[name] = aggregate
+	}
+	return report
+}
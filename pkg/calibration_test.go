@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// makeCalibrationData builds 40 consecutive daily candles trading between 99 and 101 that each publish a 98-102 trade
+// range, with a spike to 105 on day 35, a drop to 95 on day 8, and a close at 103 on day 39.
+func makeCalibrationData() map[int64]SingleStockCandle {
+	candles := map[int64]SingleStockCandle{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		c := SingleStockCandle{
+			Close:       100,
+			High:        101,
+			Low:         99,
+			TradeRange:  map[string]float64{"high": 102, "low": 98},
+			QTradeRange: map[string]float64{"high": 102, "low": 98, "coverage": 0.8},
+		}
+		switch i {
+		case 35:
+			c.High = 105
+		case 8:
+			c.Low = 95
+		case 39:
+			c.Close, c.High = 103, 103
+		}
+		candles[start.AddDate(0, 0, i).UnixMilli()] = c
+	}
+	return candles
+}
+
+func TestCalibrateTicker(t *testing.T) {
+	results := calibrateTicker(makeCalibrationData())
+	// Only days 0-9 have 30 days of history after them. Days 0-7 see the drop on day 8, days 5-9 see the spike, and
+	// only day 9's horizon ends on the close outside the band.
+	want := RangeCalibration{Samples: 10, Within: 0, BreachHigh: 5, BreachLow: 8, BreachBoth: 3, CloseWithin: 9}
+	got, ok := results["trade-range"]
+	if !ok {
+		t.Fatalf("missing trade-range calibration, got %v", results)
+	}
+	if got.Samples != want.Samples || got.Within != want.Within || got.BreachHigh != want.BreachHigh ||
+		got.BreachLow != want.BreachLow || got.BreachBoth != want.BreachBoth || got.CloseWithin != want.CloseWithin ||
+		got.HorizonDays != SHORTDURATION {
+		t.Errorf("trade-range = %+v, want %+v", got, want)
+	}
+	if _, ok := results["trend-range"]; ok {
+		t.Error("ranges without any values should be left out")
+	}
+}
+
+func TestCalculateRangeCalibration(t *testing.T) {
+	batch := map[string]map[int64]SingleStockCandle{
+		"AAA": makeCalibrationData(),
+		"BBB": makeCalibrationData(),
+	}
+	report := CalculateRangeCalibration(batch)
+	if len(report.Tickers) != 2 {
+		t.Fatalf("expected two tickers, got %v", report.Tickers)
+	}
+	perTicker := report.Tickers["AAA"]["trade-range"]
+	if perTicker.BreachHighRate != 0.5 || perTicker.BreachLowRate != 0.8 || perTicker.WithinRate != 0 ||
+		perTicker.CloseWithinRate != 0.9 {
+		t.Errorf("AAA trade-range rates = %+v", perTicker)
+	}
+	aggregate := report.Aggregate["trade-range"]
+	if aggregate.Samples != 20 || aggregate.BreachHigh != 10 || aggregate.BreachHighRate != 0.5 {
+		t.Errorf("aggregate trade-range = %+v", aggregate)
+	}
+	if got := report.Aggregate["quantile-trade-range"].StatedCoverage; math.Abs(got-0.8) > 1e-12 {
+		t.Errorf("quantile-trade-range stated coverage = %v, want 0.8", got)
+	}
+	if got := aggregate.StatedCoverage; got != 0 {
+		t.Errorf("trade-range states no coverage, got %v", got)
+	}
+}
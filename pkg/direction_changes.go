@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// DirectionChange is one session on which a duration's direction label differed from the session before it. Field
+// names the label that changed: TradeDirection, TrendDirection, or TailDirection.
+type DirectionChange struct {
+	Ticker string    `json:"ticker"`
+	Field  string    `json:"field"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Date   time.Time `json:"date"`
+}
+
+// DirectionChangeQuery selects direction changes. Empty fields match everything; Since and Until are inclusive.
+type DirectionChangeQuery struct {
+	Ticker string
+	Field  string
+	Since  time.Time
+	Until  time.Time
+}
+
+// directionFields pairs each duration with the name of its direction label on SingleStockCandle.
+var directionFields = []struct {
+	field    string
+	duration int
+}{{"TradeDirection", SHORTDURATION}, {"TrendDirection", MEDIUMDURATION}, {"TailDirection", LONGDURATION}}
+
+// String formats the change as, for example, "TradeDirection Neutral→Bullish on 2026-09-14".
+func (c DirectionChange) String() string {
+	return fmt.Sprintf("%s %s→%s on %s", c.Field, c.From, c.To, c.Date.Format(time.DateOnly))
+}
+
+// sortDirectionChanges orders changes by date, then ticker, then field in trade, trend, tail order.
+func sortDirectionChanges(changes []DirectionChange) {
+	fieldOrder := map[string]int{}
+	for i, df := range directionFields {
+		fieldOrder[df.field] = i
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if !changes[i].Date.Equal(changes[j].Date) {
+			return changes[i].Date.Before(changes[j].Date)
+		}
+		if changes[i].Ticker != changes[j].Ticker {
+			return changes[i].Ticker < changes[j].Ticker
+		}
+		return fieldOrder[changes[i].Field] < fieldOrder[changes[j].Field]
+	})
+}
+
+// DetectDirectionChanges scans the labeled series of every ticker oldest first and returns a change for each session
+// whose direction differs from the previous session's, for each duration. Changes are keyed by the map's ticker and
+// ordered by date. Must be called after CalculateTrendDirections.
+func DetectDirectionChanges(stockPrices map[string]map[int64]SingleStockCandle) []DirectionChange {
+	var changes []DirectionChange
+	for ticker, candles := range stockPrices {
+		dateKeys := sortedDateKeys(candles)
+		for i := 1; i < len(dateKeys); i++ {
+			prev, curr := candles[dateKeys[i-1]], candles[dateKeys[i]]
+			for _, df := range directionFields {
+				from, to := getDirection(prev, df.duration), getDirection(curr, df.duration)
+				if from == "" || to == "" || from == to {
+					continue
+				}
+				changes = append(changes, DirectionChange{
+					Ticker: ticker,
+					Field:  df.field,
+					From:   from,
+					To:     to,
+					Date:   time.UnixMilli(dateKeys[i]).UTC(),
+				})
+			}
+		}
+	}
+	sortDirectionChanges(changes)
+	return changes
+}
+
+// FilterDirectionChanges returns the changes matching query, keeping their order.
+func FilterDirectionChanges(changes []DirectionChange, query DirectionChangeQuery) []DirectionChange {
+	var matched []DirectionChange
+	for _, c := range changes {
+		if query.Ticker != "" && c.Ticker != query.Ticker {
+			continue
+		}
+		if query.Field != "" && c.Field != query.Field {
+			continue
+		}
+		if !query.Since.IsZero() && c.Date.Before(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && c.Date.After(query.Until) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+	return matched
+}
+
+// DirectionChangesSince keeps the changes that happened after the previous run. A ticker's previous run ended at the
+// Timestamp previous reports for it; tickers the previous run did not cover keep only the changes on the latest
+// session in current.
+func DirectionChangesSince(changes []DirectionChange,
+	previous, current map[string]CondensedRangesJSON) []DirectionChange {
+	var recent []DirectionChange
+	for _, c := range changes {
+		if last, ok := previous[c.Ticker]; ok {
+			if c.Date.After(last.Timestamp) {
+				recent = append(recent, c)
+			}
+			continue
+		}
+		if latest, ok := current[c.Ticker]; ok && !c.Date.Before(truncateToDay(latest.Timestamp)) {
+			recent = append(recent, c)
+		}
+	}
+	return recent
+}
+
+// MergeDirectionChanges adds changes to history, dropping any change already recorded for the same ticker, field,
+// and date, and returns the result in date order.
+func MergeDirectionChanges(history, changes []DirectionChange) []DirectionChange {
+	type key struct {
+		ticker, field string
+		date          int64
+	}
+	seen := map[key]bool{}
+	var merged []DirectionChange
+	for _, c := range append(append([]DirectionChange{}, history...), changes...) {
+		k := key{c.Ticker, c.Field, c.Date.UnixMilli()}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		merged = append(merged, c)
+	}
+	sortDirectionChanges(merged)
+	return merged
+}
+
+// LoadDirectionHistory reads a direction change history file. A missing file is an empty history.
+func LoadDirectionHistory(path string) ([]DirectionChange, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []DirectionChange
+	if err = json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("error decoding direction history %s: %v", path, err)
+	}
+	return history, nil
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+// makeDirectionData builds five consecutive daily candles with the given trade and trend directions.
+func makeDirectionData(trade, trend []string) map[int64]SingleStockCandle {
+	candles := map[int64]SingleStockCandle{}
+	start := time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)
+	for i := range trade {
+		candles[start.AddDate(0, 0, i).UnixMilli()] = SingleStockCandle{
+			TradeDirection: trade[i],
+			TrendDirection: trend[i],
+			TailDirection:  "Indeterminate",
+		}
+	}
+	return candles
+}
+
+func TestDetectDirectionChanges(t *testing.T) {
+	batch := map[string]map[int64]SingleStockCandle{
+		"AAA": makeDirectionData(
+			[]string{"Indeterminate", "Neutral", "Neutral", "Neutral", "Bullish"},
+			[]string{"Bearish", "Bearish", "Neutral", "Neutral", "Neutral"}),
+		"BBB": makeDirectionData(
+			[]string{"Bullish", "Bullish", "Bullish", "Bullish", "Bearish"},
+			[]string{"Neutral", "Neutral", "Neutral", "Neutral", "Neutral"}),
+	}
+	changes := DetectDirectionChanges(batch)
+	want := []string{
+		"AAA: TradeDirection Indeterminate→Neutral on 2026-09-11",
+		"AAA: TrendDirection Bearish→Neutral on 2026-09-12",
+		"AAA: TradeDirection Neutral→Bullish on 2026-09-14",
+		"BBB: TradeDirection Bullish→Bearish on 2026-09-14",
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes %v, want %d", len(changes), changes, len(want))
+	}
+	for i, c := range changes {
+		if got := c.Ticker + ": " + c.String(); got != want[i] {
+			t.Errorf("change %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestFilterDirectionChanges(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC) }
+	changes := []DirectionChange{
+		{Ticker: "AAA", Field: "TradeDirection", Date: day(1)},
+		{Ticker: "AAA", Field: "TrendDirection", Date: day(5)},
+		{Ticker: "BBB", Field: "TradeDirection", Date: day(5)},
+		{Ticker: "AAA", Field: "TradeDirection", Date: day(9)},
+	}
+	tests := []struct {
+		name  string
+		query DirectionChangeQuery
+		want  int
+	}{
+		{"everything", DirectionChangeQuery{}, 4},
+		{"ticker", DirectionChangeQuery{Ticker: "AAA"}, 3},
+		{"field", DirectionChangeQuery{Field: "TradeDirection"}, 3},
+		{"inclusive dates", DirectionChangeQuery{Since: day(5), Until: day(9)}, 3},
+		{"combined", DirectionChangeQuery{Ticker: "AAA", Field: "TradeDirection", Since: day(2)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterDirectionChanges(changes, tt.query); len(got) != tt.want {
+				t.Errorf("got %d changes %v, want %d", len(got), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirectionChangesSince(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC) }
+	changes := []DirectionChange{
+		{Ticker: "AAA", Field: "TradeDirection", Date: day(3)},
+		{Ticker: "AAA", Field: "TradeDirection", Date: day(8)},
+		{Ticker: "BBB", Field: "TradeDirection", Date: day(3)},
+		{Ticker: "BBB", Field: "TrendDirection", Date: day(10)},
+	}
+	previous := map[string]CondensedRangesJSON{"AAA": {Timestamp: day(5)}}
+	current := map[string]CondensedRangesJSON{"AAA": {Timestamp: day(10)}, "BBB": {Timestamp: day(10)}}
+	got := DirectionChangesSince(changes, previous, current)
+	if len(got) != 2 || !got[0].Date.Equal(day(8)) || got[1].Ticker != "BBB" || !got[1].Date.Equal(day(10)) {
+		t.Errorf("got %v, want AAA's change after the last run and BBB's change on its latest session", got)
+	}
+}
+
+func TestMergeDirectionChanges(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC) }
+	history := []DirectionChange{
+		{Ticker: "AAA", Field: "TradeDirection", From: "Neutral", To: "Bullish", Date: day(3)},
+	}
+	changes := []DirectionChange{
+		{Ticker: "BBB", Field: "TradeDirection", From: "Neutral", To: "Bearish", Date: day(1)},
+		{Ticker: "AAA", Field: "TradeDirection", From: "Neutral", To: "Bullish", Date: day(3)},
+	}
+	merged := MergeDirectionChanges(history, changes)
+	if len(merged) != 2 || merged[0].Ticker != "BBB" || merged[1].Ticker != "AAA" {
+		t.Errorf("got %v, want the BBB change then the AAA change once", merged)
+	}
+}
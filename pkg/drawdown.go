@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"sort"
+)
+
+// Drawdown summarizes the peak-to-trough declines of closes within one duration window. Declines are positive
+// fractions of the running peak, and durations are counted in sessions.
+type Drawdown struct {
+	Max          float64 `json:"max"`
+	Current      float64 `json:"current"`
+	Duration     int     `json:"duration"`
+	MaxDuration  int     `json:"max-duration"`
+	RecoveryDays int     `json:"recovery"`
+}
+
+// calculateDrawdown walks chronologically ordered closes tracking the running peak. Duration is how long the latest
+// close has been below its peak, MaxDuration the longest such stretch in the window, and RecoveryDays the sessions
+// from the deepest trough until the close regained the peak before it (-1 when it has not yet recovered).
+func calculateDrawdown(closes []float64) (dd Drawdown) {
+	if len(closes) == 0 {
+		return Drawdown{}
+	}
+	peak, peakIndex := closes[0], 0
+	troughIndex, troughPeak := -1, 0.0
+	for i, c := range closes {
+		if c >= peak {
+			peak, peakIndex = c, i
+			continue
+		}
+		drop := (peak - c) / peak
+		if drop > dd.Max {
+			dd.Max = drop
+			troughIndex, troughPeak = i, peak
+		}
+		if i-peakIndex > dd.MaxDuration {
+			dd.MaxDuration = i - peakIndex
+		}
+	}
+	last := len(closes) - 1
+	dd.Current = (peak - closes[last]) / peak
+	dd.Duration = last - peakIndex
+
+	if troughIndex >= 0 {
+		dd.RecoveryDays = -1
+		for i := troughIndex + 1; i < len(closes); i++ {
+			if closes[i] >= troughPeak {
+				dd.RecoveryDays = i - troughIndex
+				break
+			}
+		}
+	}
+	return dd
+}
+
+// CalculateDrawdowns stores the drawdown statistics of each day's duration window of closes.
+func CalculateDrawdowns(stockPrices map[string]map[int64]SingleStockCandle, duration int) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		reverseDateKeys := dateKeys
+		sort.Slice(reverseDateKeys, func(i, j int) bool {
+			return reverseDateKeys[i] > reverseDateKeys[j]
+		})
+		for index, date := range reverseDateKeys {
+			windowDates, ok := collectWindowDates(reverseDateKeys, index, duration)
+			if !ok {
+				continue
+			}
+			closes := make([]float64, len(windowDates))
+			for i, wd := range windowDates {
+				// windowDates runs newest first; fill closes oldest first.
+				closes[len(windowDates)-1-i] = stockPrices[ticker][wd].Close
+			}
+			stockCandle := stockPrices[ticker][date]
+			setDrawdown(&stockCandle, duration, calculateDrawdown(closes))
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
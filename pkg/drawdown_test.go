@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateDrawdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64
+		want   Drawdown
+	}{
+		{
+			name:   "still underwater after a deeper second drawdown",
+			closes: []float64{100, 110, 99, 105, 112, 100},
+			want:   Drawdown{Max: 12.0 / 112, Current: 12.0 / 112, Duration: 1, MaxDuration: 2, RecoveryDays: -1},
+		},
+		{
+			name:   "recovered to a new high",
+			closes: []float64{100, 90, 95, 101},
+			want:   Drawdown{Max: 0.1, Current: 0, Duration: 0, MaxDuration: 2, RecoveryDays: 2},
+		},
+		{
+			name:   "never below the running peak",
+			closes: []float64{100, 101, 102},
+			want:   Drawdown{},
+		},
+		{
+			name:   "no closes",
+			closes: nil,
+			want:   Drawdown{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateDrawdown(tt.closes)
+			if math.Abs(got.Max-tt.want.Max) > 1e-12 || math.Abs(got.Current-tt.want.Current) > 1e-12 ||
+				got.Duration != tt.want.Duration || got.MaxDuration != tt.want.MaxDuration ||
+				got.RecoveryDays != tt.want.RecoveryDays {
+				t.Errorf("calculateDrawdown() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateDrawdowns_PopulatesOnlyTargetDuration(t *testing.T) {
+	// makeTestData closes fall by 0.5 a day towards the present, so every full window is in drawdown.
+	data := makeTestData("AAPL", 90)
+	result := CalculateDrawdowns(data, SHORTDURATION)
+	hasShort := false
+	for _, candles := range result {
+		for _, c := range candles {
+			if c.DrawdownShort.Max > 0 {
+				hasShort = true
+				if c.DrawdownShort.RecoveryDays != -1 {
+					t.Errorf("steady decline should not recover: got %v", c.DrawdownShort.RecoveryDays)
+				}
+			}
+			if c.DrawdownMed != (Drawdown{}) || c.DrawdownLong != (Drawdown{}) {
+				t.Errorf("Med/Long drawdown should not be set by SHORTDURATION call")
+			}
+		}
+	}
+	if !hasShort {
+		t.Error("expected at least one candle with DrawdownShort populated")
+	}
+}
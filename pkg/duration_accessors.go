@@ -311,6 +311,29 @@ func getSlope(c SingleStockCandle, d int) float64 {
 	return 0
 }
 
+func getSlopeValid(c SingleStockCandle, d int) bool {
+	switch d {
+	case SHORTDURATION:
+		return c.SlopeShortValid
+	case MEDIUMDURATION:
+		return c.SlopeMedValid
+	case LONGDURATION:
+		return c.SlopeLongValid
+	}
+	return false
+}
+
+func setSlopeValid(c *SingleStockCandle, d int, v bool) {
+	switch d {
+	case SHORTDURATION:
+		c.SlopeShortValid = v
+	case MEDIUMDURATION:
+		c.SlopeMedValid = v
+	case LONGDURATION:
+		c.SlopeLongValid = v
+	}
+}
+
 func setSlope(c *SingleStockCandle, d int, v float64) {
 	switch d {
 	case SHORTDURATION:
@@ -321,3 +344,233 @@ func setSlope(c *SingleStockCandle, d int, v float64) {
 		c.SlopeLongDuration = v
 	}
 }
+
+func getRegression(c SingleStockCandle, d int) RegressionFit {
+	switch d {
+	case SHORTDURATION:
+		return c.RegressionShort
+	case MEDIUMDURATION:
+		return c.RegressionMed
+	case LONGDURATION:
+		return c.RegressionLong
+	}
+	return RegressionFit{}
+}
+
+func setRegression(c *SingleStockCandle, d int, v RegressionFit) {
+	switch d {
+	case SHORTDURATION:
+		c.RegressionShort = v
+	case MEDIUMDURATION:
+		c.RegressionMed = v
+	case LONGDURATION:
+		c.RegressionLong = v
+	}
+}
+
+func getBeta(c SingleStockCandle, d int) float64 {
+	switch d {
+	case SHORTDURATION:
+		return c.BetaShort
+	case MEDIUMDURATION:
+		return c.BetaMed
+	case LONGDURATION:
+		return c.BetaLong
+	}
+	return 0
+}
+
+func setBeta(c *SingleStockCandle, d int, v float64) {
+	switch d {
+	case SHORTDURATION:
+		c.BetaShort = v
+	case MEDIUMDURATION:
+		c.BetaMed = v
+	case LONGDURATION:
+		c.BetaLong = v
+	}
+}
+
+func getBenchCorr(c SingleStockCandle, d int) float64 {
+	switch d {
+	case SHORTDURATION:
+		return c.BenchCorrShort
+	case MEDIUMDURATION:
+		return c.BenchCorrMed
+	case LONGDURATION:
+		return c.BenchCorrLong
+	}
+	return 0
+}
+
+func setBenchCorr(c *SingleStockCandle, d int, v float64) {
+	switch d {
+	case SHORTDURATION:
+		c.BenchCorrShort = v
+	case MEDIUMDURATION:
+		c.BenchCorrMed = v
+	case LONGDURATION:
+		c.BenchCorrLong = v
+	}
+}
+
+func getDrawdown(c SingleStockCandle, d int) Drawdown {
+	switch d {
+	case SHORTDURATION:
+		return c.DrawdownShort
+	case MEDIUMDURATION:
+		return c.DrawdownMed
+	case LONGDURATION:
+		return c.DrawdownLong
+	}
+	return Drawdown{}
+}
+
+func setDrawdown(c *SingleStockCandle, d int, v Drawdown) {
+	switch d {
+	case SHORTDURATION:
+		c.DrawdownShort = v
+	case MEDIUMDURATION:
+		c.DrawdownMed = v
+	case LONGDURATION:
+		c.DrawdownLong = v
+	}
+}
+
+func getRiskAdjusted(c SingleStockCandle, d int) RiskRatios {
+	switch d {
+	case SHORTDURATION:
+		return c.RiskAdjustedShort
+	case MEDIUMDURATION:
+		return c.RiskAdjustedMed
+	case LONGDURATION:
+		return c.RiskAdjustedLong
+	}
+	return RiskRatios{}
+}
+
+func setRiskAdjusted(c *SingleStockCandle, d int, v RiskRatios) {
+	switch d {
+	case SHORTDURATION:
+		c.RiskAdjustedShort = v
+	case MEDIUMDURATION:
+		c.RiskAdjustedMed = v
+	case LONGDURATION:
+		c.RiskAdjustedLong = v
+	}
+}
+
+func getVaR(c SingleStockCandle, d int) []VaR {
+	switch d {
+	case SHORTDURATION:
+		return c.VaRShort
+	case MEDIUMDURATION:
+		return c.VaRMed
+	case LONGDURATION:
+		return c.VaRLong
+	}
+	return nil
+}
+
+func setVaR(c *SingleStockCandle, d int, v []VaR) {
+	switch d {
+	case SHORTDURATION:
+		c.VaRShort = v
+	case MEDIUMDURATION:
+		c.VaRMed = v
+	case LONGDURATION:
+		c.VaRLong = v
+	}
+}
+
+func getQuantileRange(c SingleStockCandle, d int) map[string]float64 {
+	switch d {
+	case SHORTDURATION:
+		return c.QTradeRange
+	case MEDIUMDURATION:
+		return c.QTrendRange
+	case LONGDURATION:
+		return c.QTailRange
+	}
+	return nil
+}
+
+func setQuantileRange(c *SingleStockCandle, d int, v map[string]float64) {
+	switch d {
+	case SHORTDURATION:
+		c.QTradeRange = v
+	case MEDIUMDURATION:
+		c.QTrendRange = v
+	case LONGDURATION:
+		c.QTailRange = v
+	}
+}
+
+func getDirection(c SingleStockCandle, d int) string {
+	switch d {
+	case SHORTDURATION:
+		return c.TradeDirection
+	case MEDIUMDURATION:
+		return c.TrendDirection
+	case LONGDURATION:
+		return c.TailDirection
+	}
+	return ""
+}
+
+func setDirection(c *SingleStockCandle, d int, v string) {
+	switch d {
+	case SHORTDURATION:
+		c.TradeDirection = v
+	case MEDIUMDURATION:
+		c.TrendDirection = v
+	case LONGDURATION:
+		c.TailDirection = v
+	}
+}
+
+func getSlopePct(c SingleStockCandle, d int) float64 {
+	switch d {
+	case SHORTDURATION:
+		return c.SlopePctShort
+	case MEDIUMDURATION:
+		return c.SlopePctMed
+	case LONGDURATION:
+		return c.SlopePctLong
+	}
+	return 0
+}
+
+func setSlopePct(c *SingleStockCandle, d int, v float64) {
+	switch d {
+	case SHORTDURATION:
+		c.SlopePctShort = v
+	case MEDIUMDURATION:
+		c.SlopePctMed = v
+	case LONGDURATION:
+		c.SlopePctLong = v
+	}
+}
+
+func getSlopeVol(c SingleStockCandle, d int) float64 {
+	switch d {
+	case SHORTDURATION:
+		return c.SlopeVolShort
+	case MEDIUMDURATION:
+		return c.SlopeVolMed
+	case LONGDURATION:
+		return c.SlopeVolLong
+	}
+	return 0
+}
+
+func setSlopeVol(c *SingleStockCandle, d int, v float64) {
+	switch d {
+	case SHORTDURATION:
+		c.SlopeVolShort = v
+	case MEDIUMDURATION:
+		c.SlopeVolMed = v
+	case LONGDURATION:
+		c.SlopeVolLong = v
+	}
+}
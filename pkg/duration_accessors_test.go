@@ -308,4 +308,217 @@ func TestGetSetSlope(t *testing.T) {
 	if w.SlopeShortDuration != 1.0 || w.SlopeMedDuration != 2.0 || w.SlopeLongDuration != 3.0 {
 		t.Errorf("setSlope: Short=%v Med=%v Long=%v", w.SlopeShortDuration, w.SlopeMedDuration, w.SlopeLongDuration)
 	}
+	setSlopeValid(&w, SHORTDURATION, true)
+	setSlopeValid(&w, LONGDURATION, true)
+	if !getSlopeValid(w, SHORTDURATION) || getSlopeValid(w, MEDIUMDURATION) || !getSlopeValid(w, LONGDURATION) {
+		t.Errorf("setSlopeValid: Short=%v Med=%v Long=%v", w.SlopeShortValid, w.SlopeMedValid, w.SlopeLongValid)
+	}
+}
+
+func TestGetSetRegression(t *testing.T) {
+	c := SingleStockCandle{
+		RegressionShort: RegressionFit{Slope: 1.0},
+		RegressionMed:   RegressionFit{Slope: 2.0},
+		RegressionLong:  RegressionFit{Slope: 3.0},
+	}
+	if got := getRegression(c, SHORTDURATION); got.Slope != 1.0 {
+		t.Errorf("Short: got %v want 1.0", got.Slope)
+	}
+	if got := getRegression(c, MEDIUMDURATION); got.Slope != 2.0 {
+		t.Errorf("Med: got %v want 2.0", got.Slope)
+	}
+	if got := getRegression(c, LONGDURATION); got.Slope != 3.0 {
+		t.Errorf("Long: got %v want 3.0", got.Slope)
+	}
+	var w SingleStockCandle
+	setRegression(&w, SHORTDURATION, RegressionFit{Slope: 1.0})
+	setRegression(&w, MEDIUMDURATION, RegressionFit{Slope: 2.0})
+	setRegression(&w, LONGDURATION, RegressionFit{Slope: 3.0})
+	if w.RegressionShort.Slope != 1.0 || w.RegressionMed.Slope != 2.0 || w.RegressionLong.Slope != 3.0 {
+		t.Errorf("setRegression: Short=%v Med=%v Long=%v", w.RegressionShort.Slope, w.RegressionMed.Slope, w.RegressionLong.Slope)
+	}
+}
+
+func TestGetSetBeta(t *testing.T) {
+	c := SingleStockCandle{BetaShort: 1.1, BetaMed: 1.2, BetaLong: 1.3}
+	if got := getBeta(c, SHORTDURATION); got != 1.1 {
+		t.Errorf("Short: got %v want 1.1", got)
+	}
+	if got := getBeta(c, MEDIUMDURATION); got != 1.2 {
+		t.Errorf("Med: got %v want 1.2", got)
+	}
+	if got := getBeta(c, LONGDURATION); got != 1.3 {
+		t.Errorf("Long: got %v want 1.3", got)
+	}
+	var w SingleStockCandle
+	setBeta(&w, SHORTDURATION, 1.1)
+	setBeta(&w, MEDIUMDURATION, 1.2)
+	setBeta(&w, LONGDURATION, 1.3)
+	if w.BetaShort != 1.1 || w.BetaMed != 1.2 || w.BetaLong != 1.3 {
+		t.Errorf("setBeta: Short=%v Med=%v Long=%v", w.BetaShort, w.BetaMed, w.BetaLong)
+	}
+}
+
+func TestGetSetBenchCorr(t *testing.T) {
+	c := SingleStockCandle{BenchCorrShort: 0.1, BenchCorrMed: 0.2, BenchCorrLong: 0.3}
+	if got := getBenchCorr(c, SHORTDURATION); got != 0.1 {
+		t.Errorf("Short: got %v want 0.1", got)
+	}
+	if got := getBenchCorr(c, MEDIUMDURATION); got != 0.2 {
+		t.Errorf("Med: got %v want 0.2", got)
+	}
+	if got := getBenchCorr(c, LONGDURATION); got != 0.3 {
+		t.Errorf("Long: got %v want 0.3", got)
+	}
+	var w SingleStockCandle
+	setBenchCorr(&w, SHORTDURATION, 0.1)
+	setBenchCorr(&w, MEDIUMDURATION, 0.2)
+	setBenchCorr(&w, LONGDURATION, 0.3)
+	if w.BenchCorrShort != 0.1 || w.BenchCorrMed != 0.2 || w.BenchCorrLong != 0.3 {
+		t.Errorf("setBenchCorr: Short=%v Med=%v Long=%v", w.BenchCorrShort, w.BenchCorrMed, w.BenchCorrLong)
+	}
+}
+
+func TestGetSetDrawdown(t *testing.T) {
+	c := SingleStockCandle{
+		DrawdownShort: Drawdown{Max: 0.1},
+		DrawdownMed:   Drawdown{Max: 0.2},
+		DrawdownLong:  Drawdown{Max: 0.3},
+	}
+	if got := getDrawdown(c, SHORTDURATION); got.Max != 0.1 {
+		t.Errorf("Short: got %v want 0.1", got.Max)
+	}
+	if got := getDrawdown(c, MEDIUMDURATION); got.Max != 0.2 {
+		t.Errorf("Med: got %v want 0.2", got.Max)
+	}
+	if got := getDrawdown(c, LONGDURATION); got.Max != 0.3 {
+		t.Errorf("Long: got %v want 0.3", got.Max)
+	}
+	var w SingleStockCandle
+	setDrawdown(&w, SHORTDURATION, Drawdown{Max: 0.1})
+	setDrawdown(&w, MEDIUMDURATION, Drawdown{Max: 0.2})
+	setDrawdown(&w, LONGDURATION, Drawdown{Max: 0.3})
+	if w.DrawdownShort.Max != 0.1 || w.DrawdownMed.Max != 0.2 || w.DrawdownLong.Max != 0.3 {
+		t.Errorf("setDrawdown: Short=%v Med=%v Long=%v", w.DrawdownShort.Max, w.DrawdownMed.Max, w.DrawdownLong.Max)
+	}
+}
+
+func TestGetSetRiskAdjusted(t *testing.T) {
+	c := SingleStockCandle{
+		RiskAdjustedShort: RiskRatios{Sharpe: 1},
+		RiskAdjustedMed:   RiskRatios{Sharpe: 2},
+		RiskAdjustedLong:  RiskRatios{Sharpe: 3},
+	}
+	if got := getRiskAdjusted(c, SHORTDURATION); got.Sharpe != 1 {
+		t.Errorf("Short: got %v want 1", got.Sharpe)
+	}
+	if got := getRiskAdjusted(c, MEDIUMDURATION); got.Sharpe != 2 {
+		t.Errorf("Med: got %v want 2", got.Sharpe)
+	}
+	if got := getRiskAdjusted(c, LONGDURATION); got.Sharpe != 3 {
+		t.Errorf("Long: got %v want 3", got.Sharpe)
+	}
+	var w SingleStockCandle
+	setRiskAdjusted(&w, SHORTDURATION, RiskRatios{Sharpe: 1})
+	setRiskAdjusted(&w, MEDIUMDURATION, RiskRatios{Sharpe: 2})
+	setRiskAdjusted(&w, LONGDURATION, RiskRatios{Sharpe: 3})
+	if w.RiskAdjustedShort.Sharpe != 1 || w.RiskAdjustedMed.Sharpe != 2 || w.RiskAdjustedLong.Sharpe != 3 {
+		t.Errorf("setRiskAdjusted: Short=%v Med=%v Long=%v",
+			w.RiskAdjustedShort.Sharpe, w.RiskAdjustedMed.Sharpe, w.RiskAdjustedLong.Sharpe)
+	}
+}
+
+func TestGetSetVaR(t *testing.T) {
+	c := SingleStockCandle{
+		VaRShort: []VaR{{Historical: 0.1}},
+		VaRMed:   []VaR{{Historical: 0.2}},
+		VaRLong:  []VaR{{Historical: 0.3}},
+	}
+	if got := getVaR(c, SHORTDURATION); got[0].Historical != 0.1 {
+		t.Errorf("Short: got %v want 0.1", got[0].Historical)
+	}
+	if got := getVaR(c, MEDIUMDURATION); got[0].Historical != 0.2 {
+		t.Errorf("Med: got %v want 0.2", got[0].Historical)
+	}
+	if got := getVaR(c, LONGDURATION); got[0].Historical != 0.3 {
+		t.Errorf("Long: got %v want 0.3", got[0].Historical)
+	}
+	var w SingleStockCandle
+	setVaR(&w, SHORTDURATION, []VaR{{Historical: 0.1}})
+	setVaR(&w, MEDIUMDURATION, []VaR{{Historical: 0.2}})
+	setVaR(&w, LONGDURATION, []VaR{{Historical: 0.3}})
+	if w.VaRShort[0].Historical != 0.1 || w.VaRMed[0].Historical != 0.2 || w.VaRLong[0].Historical != 0.3 {
+		t.Errorf("setVaR: Short=%v Med=%v Long=%v", w.VaRShort, w.VaRMed, w.VaRLong)
+	}
+}
+
+func TestGetSetQuantileRange(t *testing.T) {
+	c := SingleStockCandle{
+		QTradeRange: map[string]float64{"high": 1},
+		QTrendRange: map[string]float64{"high": 2},
+		QTailRange:  map[string]float64{"high": 3},
+	}
+	if got := getQuantileRange(c, SHORTDURATION); got["high"] != 1 {
+		t.Errorf("Short: got %v want 1", got["high"])
+	}
+	if got := getQuantileRange(c, MEDIUMDURATION); got["high"] != 2 {
+		t.Errorf("Med: got %v want 2", got["high"])
+	}
+	if got := getQuantileRange(c, LONGDURATION); got["high"] != 3 {
+		t.Errorf("Long: got %v want 3", got["high"])
+	}
+	var w SingleStockCandle
+	setQuantileRange(&w, SHORTDURATION, map[string]float64{"high": 1})
+	setQuantileRange(&w, MEDIUMDURATION, map[string]float64{"high": 2})
+	setQuantileRange(&w, LONGDURATION, map[string]float64{"high": 3})
+	if w.QTradeRange["high"] != 1 || w.QTrendRange["high"] != 2 || w.QTailRange["high"] != 3 {
+		t.Errorf("setQuantileRange: Short=%v Med=%v Long=%v", w.QTradeRange, w.QTrendRange, w.QTailRange)
+	}
+}
+
+func TestGetSetDirection(t *testing.T) {
+	c := SingleStockCandle{TradeDirection: "Bullish", TrendDirection: "Bearish", TailDirection: "Neutral"}
+	if got := getDirection(c, SHORTDURATION); got != "Bullish" {
+		t.Errorf("Short: got %q want Bullish", got)
+	}
+	if got := getDirection(c, MEDIUMDURATION); got != "Bearish" {
+		t.Errorf("Med: got %q want Bearish", got)
+	}
+	if got := getDirection(c, LONGDURATION); got != "Neutral" {
+		t.Errorf("Long: got %q want Neutral", got)
+	}
+	var w SingleStockCandle
+	setDirection(&w, SHORTDURATION, "Bullish")
+	setDirection(&w, MEDIUMDURATION, "Bearish")
+	setDirection(&w, LONGDURATION, "Neutral")
+	if w.TradeDirection != "Bullish" || w.TrendDirection != "Bearish" || w.TailDirection != "Neutral" {
+		t.Errorf("setDirection: Short=%q Med=%q Long=%q", w.TradeDirection, w.TrendDirection, w.TailDirection)
+	}
+}
+
+func TestGetSetSlopePct(t *testing.T) {
+	var w SingleStockCandle
+	setSlopePct(&w, SHORTDURATION, 0.01)
+	setSlopePct(&w, MEDIUMDURATION, 0.02)
+	setSlopePct(&w, LONGDURATION, 0.03)
+	if w.SlopePctShort != 0.01 || w.SlopePctMed != 0.02 || w.SlopePctLong != 0.03 {
+		t.Errorf("setSlopePct: Short=%v Med=%v Long=%v", w.SlopePctShort, w.SlopePctMed, w.SlopePctLong)
+	}
+	if getSlopePct(w, SHORTDURATION) != 0.01 || getSlopePct(w, MEDIUMDURATION) != 0.02 ||
+		getSlopePct(w, LONGDURATION) != 0.03 {
+		t.Errorf("getSlopePct did not read back the values set")
+	}
+}
+
+func TestGetSetSlopeVol(t *testing.T) {
+	var w SingleStockCandle
+	setSlopeVol(&w, SHORTDURATION, 1)
+	setSlopeVol(&w, MEDIUMDURATION, 2)
+	setSlopeVol(&w, LONGDURATION, 3)
+	if w.SlopeVolShort != 1 || w.SlopeVolMed != 2 || w.SlopeVolLong != 3 {
+		t.Errorf("setSlopeVol: Short=%v Med=%v Long=%v", w.SlopeVolShort, w.SlopeVolMed, w.SlopeVolLong)
+	}
+	if getSlopeVol(w, SHORTDURATION) != 1 || getSlopeVol(w, MEDIUMDURATION) != 2 || getSlopeVol(w, LONGDURATION) != 3 {
+		t.Errorf("getSlopeVol did not read back the values set")
+	}
 }
@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Holding is one open position: Quantity shares of Ticker opened on OpenDate at a per-share CostBasis.
+type Holding struct {
+	Ticker    string    `json:"ticker"`
+	Quantity  float64   `json:"quantity"`
+	CostBasis float64   `json:"cost-basis"`
+	OpenDate  time.Time `json:"open-date"`
+	Short     bool      `json:"short,omitempty"`
+}
+
+// holdingColumns are the columns a holdings file must have, in any order. side is long or short; blank means long.
+var holdingColumns = []string{"ticker", "quantity", "cost-basis", "open-date", "side"}
+
+// LoadHoldings reads a holdings CSV file with a header row naming the columns ticker, quantity, cost-basis,
+// open-date (YYYY-MM-DD), and side (long or short), e.g.
+//
+//	ticker,quantity,cost-basis,open-date,side
+//	AAPL,10,172.50,2024-03-15,long
+//	X:BTCUSD,0.25,61000,2024-08-01,long
+func LoadHoldings(path string) ([]Holding, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading holdings %s: %v", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("holdings %s is empty", path)
+	}
+	index := map[string]int{}
+	for i, name := range rows[0] {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range holdingColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("holdings %s has no %s column", path, name)
+		}
+	}
+	var holdings []Holding
+	for line, row := range rows[1:] {
+		holding, err := parseHolding(row, index)
+		if err != nil {
+			return nil, fmt.Errorf("holdings %s line %d: %v", path, line+2, err)
+		}
+		holdings = append(holdings, holding)
+	}
+	return holdings, nil
+}
+
+func parseHolding(row []string, index map[string]int) (Holding, error) {
+	field := func(name string) string { return strings.TrimSpace(row[index[name]]) }
+	holding := Holding{Ticker: NormalizeTicker(field("ticker"))}
+	if holding.Ticker == "" {
+		return Holding{}, fmt.Errorf("missing ticker")
+	}
+	var err error
+	if holding.Quantity, err = strconv.ParseFloat(field("quantity"), 64); err != nil || holding.Quantity <= 0 {
+		return Holding{}, fmt.Errorf("quantity %q must be a positive number", field("quantity"))
+	}
+	if holding.CostBasis, err = strconv.ParseFloat(field("cost-basis"), 64); err != nil || holding.CostBasis <= 0 {
+		return Holding{}, fmt.Errorf("cost-basis %q must be a positive number", field("cost-basis"))
+	}
+	if holding.OpenDate, err = time.Parse(time.DateOnly, field("open-date")); err != nil {
+		return Holding{}, fmt.Errorf("open-date %q must be formatted as YYYY-MM-DD", field("open-date"))
+	}
+	switch strings.ToLower(field("side")) {
+	case "", "long":
+	case "short":
+		holding.Short = true
+	default:
+		return Holding{}, fmt.Errorf("side %q must be long or short", field("side"))
+	}
+	return holding, nil
+}
+
+// PositionReturn is a holding valued at Price: its annualized return so far, the price it needed to reach by AsOf
+// to have earned TargetRate a year, and whether it is ahead of that target. Error is set, and the returns left
+// empty, when the position could not be evaluated.
+type PositionReturn struct {
+	Holding
+	Price          float64 `json:"price"`
+	MarketValue    float64 `json:"market-value"`
+	UnrealizedGain float64 `json:"unrealized-gain"`
+	CurrentReturn  float64 `json:"current-annual-return"`
+	TargetPrice    float64 `json:"target-price"`
+	AboveTarget    bool    `json:"above-target"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// HoldingsReport is the portfolio report: every position's return against TargetRate as of AsOf, plus the
+// portfolio's totals over the positions that could be priced. Performance is set when the holdings come from a
+// transaction ledger.
+type HoldingsReport struct {
+	AsOf           time.Time          `json:"as-of"`
+	TargetRate     float64            `json:"target-rate"`
+	Positions      []PositionReturn   `json:"positions"`
+	CostBasis      float64            `json:"cost-basis"`
+	MarketValue    float64            `json:"market-value"`
+	UnrealizedGain float64            `json:"unrealized-gain"`
+	AboveTarget    int                `json:"above-target"`
+	Performance    *PerformanceReport `json:"performance,omitempty"`
+}
+
+// EvaluateHoldings values every holding at its price in prices, keyed by normalized ticker, and computes its
+// AnnualizedReturn and AnnualTargetPrice at targetRate over the sessions of its asset class up to asOf. A long
+// position is above target when its price is at or above the target price and a short one when at or below it.
+func EvaluateHoldings(holdings []Holding, prices map[string]float64, targetRate float64, asOf time.Time) HoldingsReport {
+	report := HoldingsReport{AsOf: asOf, TargetRate: targetRate}
+	for _, holding := range holdings {
+		position := PositionReturn{Holding: holding}
+		price, ok := prices[NormalizeTicker(holding.Ticker)]
+		if !ok || price <= 0 {
+			position.Error = "no price"
+			report.Positions = append(report.Positions, position)
+			continue
+		}
+		position.Price = price
+		position.MarketValue = holding.Quantity * price
+		position.UnrealizedGain = holding.Quantity * (price - holding.CostBasis)
+		if holding.Short {
+			position.UnrealizedGain = -position.UnrealizedGain
+		}
+		report.CostBasis += holding.Quantity * holding.CostBasis
+		report.MarketValue += position.MarketValue
+		report.UnrealizedGain += position.UnrealizedGain
+
+		class := AssetClassOf(holding.Ticker)
+		current, err := AnnualizedReturn(class, price, holding.CostBasis, holding.OpenDate, asOf, holding.Short)
+		if err == nil {
+			position.TargetPrice, err = AnnualTargetPrice(class, holding.CostBasis, targetRate, holding.OpenDate, asOf,
+				holding.Short)
+		}
+		if err != nil {
+			position.Error = err.Error()
+			report.Positions = append(report.Positions, position)
+			continue
+		}
+		position.CurrentReturn = current
+		if holding.Short {
+			position.AboveTarget = price <= position.TargetPrice
+		} else {
+			position.AboveTarget = price >= position.TargetPrice
+		}
+		if position.AboveTarget {
+			report.AboveTarget++
+		}
+		report.Positions = append(report.Positions, position)
+	}
+	sort.SliceStable(report.Positions, func(i, j int) bool {
+		return report.Positions[i].Ticker < report.Positions[j].Ticker
+	})
+	return report
+}
+
+// LatestCloses returns the close of the latest candle of each ticker.
+func LatestCloses(stockPrices map[string]map[int64]SingleStockCandle) map[string]float64 {
+	closes := make(map[string]float64, len(stockPrices))
+	for ticker, candles := range stockPrices {
+		latest := int64(math.MinInt64)
+		for date, c := range candles {
+			if date > latest {
+				latest = date
+				closes[ticker] = c.Close
+			}
+		}
+	}
+	return closes
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadHoldings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holdings.csv")
+	contents := "Side, Ticker, Quantity, Cost-Basis, Open-Date\n" +
+		"long, aapl, 10, 172.50, 2024-03-15\n" +
+		", x:btcusd, 0.25, 61000, 2024-08-01\n" +
+		"short, TSLA, 5, 250, 2025-01-10\n"
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadHoldings(path)
+	if err != nil {
+		t.Fatalf("LoadHoldings() error = %v", err)
+	}
+	want := []Holding{
+		{Ticker: "AAPL", Quantity: 10, CostBasis: 172.5, OpenDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "X:BTCUSD", Quantity: 0.25, CostBasis: 61000, OpenDate: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{Ticker: "TSLA", Quantity: 5, CostBasis: 250, OpenDate: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
+			Short: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d holdings, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("holding %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadHoldings_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{"empty", ""},
+		{"missing column", "ticker,quantity,cost-basis,open-date\nAAPL,10,100,2024-01-02\n"},
+		{"bad quantity", "ticker,quantity,cost-basis,open-date,side\nAAPL,ten,100,2024-01-02,long\n"},
+		{"bad cost basis", "ticker,quantity,cost-basis,open-date,side\nAAPL,10,0,2024-01-02,long\n"},
+		{"bad date", "ticker,quantity,cost-basis,open-date,side\nAAPL,10,100,01/02/2024,long\n"},
+		{"bad side", "ticker,quantity,cost-basis,open-date,side\nAAPL,10,100,2024-01-02,both\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "holdings.csv")
+			if err := os.WriteFile(path, []byte(tt.contents), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadHoldings(path); err == nil {
+				t.Error("LoadHoldings() expected an error")
+			}
+		})
+	}
+}
+
+func TestEvaluateHoldings(t *testing.T) {
+	withReturnConf(t, ReturnConf{})
+	open := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	holdings := []Holding{
+		{Ticker: "WIN", Quantity: 10, CostBasis: 100, OpenDate: open},
+		{Ticker: "LAG", Quantity: 10, CostBasis: 100, OpenDate: open},
+		{Ticker: "SHRT", Quantity: 2, CostBasis: 100, OpenDate: open, Short: true},
+		{Ticker: "NONE", Quantity: 1, CostBasis: 100, OpenDate: open},
+		{Ticker: "NEW", Quantity: 1, CostBasis: 100, OpenDate: asOf},
+	}
+	prices := map[string]float64{"WIN": 110, "LAG": 103, "SHRT": 90, "NEW": 100}
+	report := EvaluateHoldings(holdings, prices, 0.06, asOf)
+
+	byTicker := map[string]PositionReturn{}
+	for _, p := range report.Positions {
+		byTicker[p.Ticker] = p
+	}
+	// 2024 had 252 NYSE sessions, so each position was held exactly one equity year.
+	tests := []struct {
+		ticker      string
+		wantReturn  float64
+		wantAbove   bool
+		wantGain    float64
+		wantErrored bool
+	}{
+		{"WIN", 0.10, true, 100, false},
+		{"LAG", 0.03, false, 30, false},
+		{"SHRT", 0.10, true, 20, false},
+		{"NONE", 0, false, 0, true},
+		{"NEW", 0, false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			p := byTicker[tt.ticker]
+			if (p.Error != "") != tt.wantErrored {
+				t.Fatalf("Error = %q, wantErrored %v", p.Error, tt.wantErrored)
+			}
+			if math.Abs(p.CurrentReturn-tt.wantReturn) > 1e-9 || p.AboveTarget != tt.wantAbove ||
+				math.Abs(p.UnrealizedGain-tt.wantGain) > 1e-9 {
+				t.Errorf("got return %v, above %v, gain %v", p.CurrentReturn, p.AboveTarget, p.UnrealizedGain)
+			}
+		})
+	}
+	if report.AboveTarget != 2 || math.Abs(report.MarketValue-(1100+1030+180+100)) > 1e-9 {
+		t.Errorf("AboveTarget = %d, MarketValue = %v", report.AboveTarget, report.MarketValue)
+	}
+	if report.Positions[0].Ticker != "LAG" {
+		t.Errorf("positions should be sorted by ticker, first is %s", report.Positions[0].Ticker)
+	}
+}
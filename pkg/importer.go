@@ -0,0 +1,338 @@
+package pkg
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// ImportMapping says how to read a broker's activity CSV export. Each field lists the header names a column may go
+// by, and the first one present with a value in a row is used, so one mapping copes with exports whose columns vary by
+// activity. Every Fees column present is summed, for brokers that split commissions from fees. Types maps a broker's
+// activity name to a transaction type by case-insensitive prefix, the longest matching prefix winning; rows whose
+// activity does not map, or maps to an empty type, are skipped. The Side column, when set and not empty, names the
+// activity in place of Type, for brokers that put buy and sell in a side column of fill rows. DateFormats are tried
+// in order after the defaults.
+type ImportMapping struct {
+	ID          []string          `json:"id"`
+	Date        []string          `json:"date"`
+	Ticker      []string          `json:"ticker"`
+	Type        []string          `json:"type"`
+	Side        []string          `json:"side"`
+	Quantity    []string          `json:"quantity"`
+	Price       []string          `json:"price"`
+	Fees        []string          `json:"fees"`
+	Amount      []string          `json:"amount"`
+	Ratio       []string          `json:"ratio"`
+	Types       map[string]string `json:"types"`
+	DateFormats []string          `json:"date-formats"`
+}
+
+// importDateFormats are tried on every date before a mapping's own formats.
+var importDateFormats = []string{time.DateOnly, time.RFC3339Nano, "2006-01-02 15:04:05", "01/02/2006", "1/2/2006"}
+
+// BrokerPresets are the mappings built in for common brokers' activity exports, keyed by the name passed to
+// ImportMappingFor. alpaca reads account activities as returned by the activities API and flattened to CSV; generic
+// reads a file with the same columns as the transactions ledger.
+var BrokerPresets = map[string]ImportMapping{
+	"alpaca": {
+		ID:       []string{"id"},
+		Date:     []string{"transaction_time", "date"},
+		Ticker:   []string{"symbol"},
+		Type:     []string{"activity_type"},
+		Side:     []string{"side"},
+		Quantity: []string{"qty", "cum_qty"},
+		Price:    []string{"price"},
+		Amount:   []string{"net_amount"},
+		Types: map[string]string{
+			"buy": TXBUY, "sell": TXSELL, "sell_short": "", "div": TXDIVIDEND, "fee": TXFEE, "cfee": TXFEE,
+			"split": TXSPLIT,
+		},
+	},
+	"schwab": {
+		Date:     []string{"Date"},
+		Ticker:   []string{"Symbol"},
+		Type:     []string{"Action"},
+		Quantity: []string{"Quantity"},
+		Price:    []string{"Price"},
+		Fees:     []string{"Fees & Comm"},
+		Amount:   []string{"Amount"},
+		Types: map[string]string{
+			"buy": TXBUY, "reinvest shares": TXBUY, "sell": TXSELL, "cash dividend": TXDIVIDEND,
+			"qualified dividend": TXDIVIDEND, "non-qualified div": TXDIVIDEND, "reinvest dividend": TXDIVIDEND,
+			"special dividend": TXDIVIDEND, "nra tax adj": TXDIVIDEND, "stock split": TXSPLIT, "adr mgmt fee": TXFEE,
+			"service fee": TXFEE,
+		},
+	},
+	"fidelity": {
+		Date:     []string{"Run Date"},
+		Ticker:   []string{"Symbol"},
+		Type:     []string{"Action"},
+		Quantity: []string{"Quantity"},
+		Price:    []string{"Price ($)"},
+		Fees:     []string{"Commission ($)", "Fees ($)"},
+		Amount:   []string{"Amount ($)"},
+		Types: map[string]string{
+			"you bought": TXBUY, "reinvestment": TXBUY, "you sold": TXSELL, "dividend received": TXDIVIDEND,
+			"foreign tax paid": TXDIVIDEND, "distribution": TXSPLIT, "fee charged": TXFEE,
+		},
+	},
+	"vanguard": {
+		Date:     []string{"Trade Date"},
+		Ticker:   []string{"Symbol"},
+		Type:     []string{"Transaction Type"},
+		Quantity: []string{"Shares"},
+		Price:    []string{"Share Price"},
+		Fees:     []string{"Commissions and Fees"},
+		Amount:   []string{"Net Amount"},
+		Types: map[string]string{
+			"buy": TXBUY, "reinvestment": TXBUY, "sell": TXSELL, "dividend": TXDIVIDEND, "stock split": TXSPLIT,
+			"fee": TXFEE,
+		},
+	},
+	"generic": {
+		ID:       []string{"id"},
+		Date:     []string{"date"},
+		Ticker:   []string{"ticker"},
+		Type:     []string{"type"},
+		Quantity: []string{"quantity"},
+		Price:    []string{"price"},
+		Fees:     []string{"fees"},
+		Amount:   []string{"amount"},
+		Ratio:    []string{"ratio"},
+		Types: map[string]string{
+			TXBUY: TXBUY, TXSELL: TXSELL, TXDIVIDEND: TXDIVIDEND, TXSPLIT: TXSPLIT, TXFEE: TXFEE,
+		},
+	},
+}
+
+// ImportMappingFor looks up the mapping named broker, ignoring case, in the custom mappings first and then in
+// BrokerPresets.
+func ImportMappingFor(broker string, custom map[string]ImportMapping) (ImportMapping, error) {
+	for _, mappings := range []map[string]ImportMapping{custom, BrokerPresets} {
+		for name, mapping := range mappings {
+			if strings.EqualFold(name, broker) {
+				return mapping, nil
+			}
+		}
+	}
+	return ImportMapping{}, fmt.Errorf("no import mapping for broker %q", broker)
+}
+
+// ImportTransactions reads a broker activity CSV export into transactions using the mapping. Lines before the header,
+// the first row naming the date, ticker, and type columns, are skipped, as are rows without a date or whose activity
+// the mapping does not know, which are counted in skipped. Quantities, prices, and fees are taken as magnitudes,
+// since brokers sign them by the direction of the cash; a dividend keeps its sign so tax withheld reduces income.
+func ImportTransactions(path string, mapping ImportMapping) (transactions []Transaction, skipped int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	header := -1
+	index := map[string]int{}
+	for i, row := range rows {
+		names := map[string]int{}
+		for col, name := range row {
+			names[strings.ToLower(strings.TrimSpace(name))] = col
+		}
+		if hasColumn(names, mapping.Date) && hasColumn(names, mapping.Ticker) && hasColumn(names, mapping.Type) {
+			header, index = i, names
+			break
+		}
+	}
+	if header < 0 {
+		return nil, 0, fmt.Errorf("%s has no header row with the date, ticker, and type columns", path)
+	}
+	for line, row := range rows[header+1:] {
+		tx, ok, err := mapping.parseRow(row, index)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s line %d: %v", path, header+line+2, err)
+		}
+		if !ok {
+			skipped++
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, skipped, nil
+}
+
+func hasColumn(index map[string]int, names []string) bool {
+	return slices.ContainsFunc(names, func(name string) bool {
+		_, ok := index[strings.ToLower(name)]
+		return ok
+	})
+}
+
+// parseRow converts one row, reporting false for a row that is not a transaction.
+func (m ImportMapping) parseRow(row []string, index map[string]int) (Transaction, bool, error) {
+	field := func(names []string) string {
+		for _, name := range names {
+			if col, ok := index[strings.ToLower(name)]; ok && col < len(row) {
+				if value := strings.TrimSpace(row[col]); value != "" {
+					return value
+				}
+			}
+		}
+		return ""
+	}
+	date := field(m.Date)
+	activity := field(m.Side)
+	if activity == "" {
+		activity = field(m.Type)
+	}
+	txType := m.transactionType(activity)
+	if date == "" || txType == "" {
+		return Transaction{}, false, nil
+	}
+
+	tx := Transaction{ID: field(m.ID), Ticker: NormalizeTicker(field(m.Ticker)), Type: txType}
+	var err error
+	if tx.Date, err = m.parseDate(date); err != nil {
+		return Transaction{}, false, err
+	}
+	if tx.Ticker == "" && txType != TXFEE {
+		return Transaction{}, false, fmt.Errorf("%s has no ticker", activity)
+	}
+	number := func(names []string) (float64, error) {
+		value := field(names)
+		n, err := parseImportNumber(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", value)
+		}
+		return n, nil
+	}
+	if tx.Quantity, err = number(m.Quantity); err != nil {
+		return Transaction{}, false, err
+	}
+	if tx.Price, err = number(m.Price); err != nil {
+		return Transaction{}, false, err
+	}
+	if tx.Amount, err = number(m.Amount); err != nil {
+		return Transaction{}, false, err
+	}
+	if tx.Ratio, err = number(m.Ratio); err != nil {
+		return Transaction{}, false, err
+	}
+	for _, name := range m.Fees {
+		fee, err := number([]string{name})
+		if err != nil {
+			return Transaction{}, false, err
+		}
+		tx.Fees += math.Abs(fee)
+	}
+	tx.Price, tx.Ratio = math.Abs(tx.Price), math.Abs(tx.Ratio)
+	switch txType {
+	case TXBUY, TXSELL:
+		tx.Quantity, tx.Amount = math.Abs(tx.Quantity), 0
+	case TXFEE:
+		tx.Quantity, tx.Price, tx.Fees, tx.Amount = 0, 0, 0, math.Abs(tx.Amount)
+	case TXDIVIDEND:
+		tx.Quantity, tx.Price, tx.Fees = 0, 0, 0
+	case TXSPLIT:
+		tx.Price, tx.Fees, tx.Amount = 0, 0, 0
+	}
+	return tx, true, nil
+}
+
+// transactionType maps an activity to a transaction type by its longest matching prefix in Types.
+func (m ImportMapping) transactionType(activity string) string {
+	activity = strings.ToLower(activity)
+	var match, txType string
+	for prefix, t := range m.Types {
+		prefix = strings.ToLower(prefix)
+		if strings.HasPrefix(activity, prefix) && len(prefix) > len(match) {
+			match, txType = prefix, t
+		}
+	}
+	return txType
+}
+
+// exchangeTimeZone is the time zone US brokers date trades in.
+var exchangeTimeZone, _ = time.LoadLocation("America/New_York")
+
+// parseDate reads a date in any known format and keeps only the calendar day. A timestamp with a time zone, such as
+// Alpaca's transaction_time in UTC, is dated by its day in New York, so an evening fill keeps its trade date.
+// Brokers that annotate dates, such as "04/01/2025 as of 03/31/2025", are read by their first word.
+func (m ImportMapping) parseDate(value string) (time.Time, error) {
+	candidates := []string{value}
+	if first, _, ok := strings.Cut(value, " "); ok {
+		candidates = append(candidates, first)
+	}
+	for _, candidate := range candidates {
+		for _, format := range append(importDateFormats, m.DateFormats...) {
+			t, err := time.Parse(format, candidate)
+			if err != nil {
+				continue
+			}
+			if strings.Contains(format, "Z07") || strings.Contains(format, "-07") || strings.Contains(format, "MST") {
+				t = t.In(exchangeTimeZone)
+			}
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// parseImportNumber reads a broker-formatted number such as "$1,234.50" or "(12.00)". An empty value is zero.
+func parseImportNumber(value string) (float64, error) {
+	value = strings.NewReplacer("$", "", ",", "", " ", "").Replace(value)
+	if value == "" || value == "--" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")")
+	value = strings.Trim(value, "()")
+	n, err := strconv.ParseFloat(value, 64)
+	if negative {
+		n = -n
+	}
+	return n, err
+}
+
+// transactionKey identifies a transaction across imports: by its ID when it has one, otherwise by its contents.
+func transactionKey(tx Transaction) string {
+	if tx.ID != "" {
+		return "id:" + tx.ID
+	}
+	return fmt.Sprintf("%s|%s|%s|%g|%g|%g|%g", tx.Date.Format(time.DateOnly), NormalizeTicker(tx.Ticker), tx.Type,
+		tx.Quantity, tx.Price, tx.Amount, tx.Ratio)
+}
+
+// MergeTransactions adds the imported transactions not already in existing and returns the merged ledger in date
+// order with the number added. Transactions without an ID are matched by their contents and counted, so a file
+// re-imported adds nothing while two identical fills on one day in a new file are both kept.
+func MergeTransactions(existing, imported []Transaction) ([]Transaction, int) {
+	seen := map[string]int{}
+	for _, tx := range existing {
+		seen[transactionKey(tx)]++
+	}
+	merged := append([]Transaction(nil), existing...)
+	added := 0
+	for _, tx := range imported {
+		key := transactionKey(tx)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		merged = append(merged, tx)
+		added++
+	}
+	SortTransactions(merged)
+	return merged, added
+}
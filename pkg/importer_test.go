@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeImportFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "activity.csv")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportTransactions_Presets(t *testing.T) {
+	tests := []struct {
+		broker   string
+		contents string
+		want     []Transaction
+		skipped  int
+	}{
+		{"alpaca", "id,activity_type,transaction_time,date,symbol,side,qty,price,net_amount\n" +
+			"a1,FILL,2024-03-15T13:30:00.123Z,,AAPL,buy,10,172.5,\n" +
+			"a2,FILL,2024-03-16T14:00:00Z,,TSLA,sell_short,5,250,\n" +
+			"a6,FILL,2024-03-19T00:30:00Z,,MSFT,buy,1,420,\n" +
+			"a3,DIV,,2024-05-16,AAPL,,,,2.40\n" +
+			"a4,DIVNRA,,2024-05-16,AAPL,,,,-0.36\n" +
+			"a5,CSD,,2024-05-20,,,,,1000\n",
+			[]Transaction{
+				{ID: "a1", Date: ledgerDate(2024, 3, 15), Ticker: "AAPL", Type: TXBUY, Quantity: 10, Price: 172.5},
+				// Filled after hours at 20:30 in New York on the 18th.
+				{ID: "a6", Date: ledgerDate(2024, 3, 18), Ticker: "MSFT", Type: TXBUY, Quantity: 1, Price: 420},
+				{ID: "a3", Date: ledgerDate(2024, 5, 16), Ticker: "AAPL", Type: TXDIVIDEND, Amount: 2.4},
+				{ID: "a4", Date: ledgerDate(2024, 5, 16), Ticker: "AAPL", Type: TXDIVIDEND, Amount: -0.36},
+			}, 2},
+		{"schwab", "\"Transactions for account ...123\"\n" +
+			"\"Date\",\"Action\",\"Symbol\",\"Description\",\"Quantity\",\"Price\",\"Fees & Comm\",\"Amount\"\n" +
+			"\"04/01/2025 as of 03/31/2025\",\"Sell\",\"AAPL\",\"APPLE INC\",\"8\",\"$223.19\",\"$1.00\",\"$1,784.52\"\n" +
+			"\"03/15/2024\",\"Buy\",\"AAPL\",\"APPLE INC\",\"10\",\"$172.50\",\"\",\"-$1,725.00\"\n" +
+			"\"06/10/2024\",\"Stock Split\",\"NVDA\",\"NVIDIA CORP\",\"90\",\"\",\"\",\"\"\n" +
+			"\"06/11/2024\",\"MoneyLink Transfer\",\"\",\"Tfr BANK\",\"\",\"\",\"\",\"$500.00\"\n" +
+			"\"Transactions Total\",\"\",\"\",\"\",\"\",\"\",\"\",\"$559.52\"\n",
+			[]Transaction{
+				{Date: ledgerDate(2025, 4, 1), Ticker: "AAPL", Type: TXSELL, Quantity: 8, Price: 223.19, Fees: 1},
+				{Date: ledgerDate(2024, 3, 15), Ticker: "AAPL", Type: TXBUY, Quantity: 10, Price: 172.5},
+				{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXSPLIT, Quantity: 90},
+			}, 2},
+		{"fidelity", "\n\nRun Date,Action,Symbol,Description,Type,Quantity,Price ($),Commission ($),Fees ($)," +
+			"Accrued Interest ($),Amount ($),Settlement Date\n" +
+			"04/01/2025,YOU SOLD APPLE INC (AAPL) (Cash), AAPL,APPLE INC,Cash,-8,223.19,0.95,0.05,,1784.52,04/02/2025\n" +
+			"03/15/2024,FEE CHARGED ANNUAL FEE (Cash), ,No Description,Cash,,,,,,-25.00,\n" +
+			"\"The data and information in this spreadsheet is provided to you solely for your use\"\n",
+			[]Transaction{
+				{Date: ledgerDate(2025, 4, 1), Ticker: "AAPL", Type: TXSELL, Quantity: 8, Price: 223.19, Fees: 1},
+				{Date: ledgerDate(2024, 3, 15), Type: TXFEE, Amount: 25},
+			}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.broker, func(t *testing.T) {
+			mapping, err := ImportMappingFor(tt.broker, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, skipped, err := ImportTransactions(writeImportFile(t, tt.contents), mapping)
+			if err != nil {
+				t.Fatalf("ImportTransactions() error = %v", err)
+			}
+			if skipped != tt.skipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.skipped)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("transaction %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImportTransactions_CustomMapping(t *testing.T) {
+	custom := map[string]ImportMapping{"MyBroker": {
+		Date:        []string{"Trade Date"},
+		Ticker:      []string{"Symbol"},
+		Type:        []string{"Action"},
+		Quantity:    []string{"Qty"},
+		Price:       []string{"Price"},
+		Types:       map[string]string{"bought": TXBUY, "bought back": ""},
+		DateFormats: []string{"02-Jan-2006"},
+	}}
+	mapping, err := ImportMappingFor("mybroker", custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeImportFile(t, "Trade Date,Action,Symbol,Qty,Price\n"+
+		"15-Mar-2024,Bought,msft,3,(410.00)\n"+
+		"16-Mar-2024,Bought back,msft,3,400\n")
+	got, skipped, err := ImportTransactions(path, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Transaction{Date: ledgerDate(2024, 3, 15), Ticker: "MSFT", Type: TXBUY, Quantity: 3, Price: 410}
+	if len(got) != 1 || got[0] != want || skipped != 1 {
+		t.Errorf("ImportTransactions() = %+v, %d skipped, want [%+v], 1 skipped", got, skipped, want)
+	}
+
+	if _, err = ImportMappingFor("unknown", custom); err == nil {
+		t.Error("ImportMappingFor() expected an error for an unknown broker")
+	}
+	if _, _, err = ImportTransactions(writeImportFile(t, "a,b,c\n1,2,3\n"), mapping); err == nil {
+		t.Error("ImportTransactions() expected an error without a header row")
+	}
+	if _, _, err = ImportTransactions(writeImportFile(t, "Trade Date,Action,Symbol,Qty,Price\n"+
+		"2024/03/15,Bought,MSFT,3,410\n"), mapping); err == nil {
+		t.Error("ImportTransactions() expected an error for an unrecognized date")
+	}
+}
+
+func TestMergeTransactions(t *testing.T) {
+	fill := Transaction{Date: ledgerDate(2024, 3, 15), Ticker: "AAPL", Type: TXBUY, Quantity: 10, Price: 172.5}
+	existing := []Transaction{
+		{ID: "a1", Date: ledgerDate(2024, 3, 1), Ticker: "MSFT", Type: TXBUY, Quantity: 1, Price: 400},
+		fill,
+	}
+	imported := []Transaction{
+		{ID: "a1", Date: ledgerDate(2024, 3, 1), Ticker: "MSFT", Type: TXBUY, Quantity: 1, Price: 400},
+		fill,
+		fill,
+		{Date: ledgerDate(2024, 2, 1), Ticker: "AAPL", Type: TXDIVIDEND, Amount: 2.4},
+	}
+	merged, added := MergeTransactions(existing, imported)
+	if added != 2 || len(merged) != 4 {
+		t.Fatalf("MergeTransactions() added %d of %d, want 2 of 4", added, len(merged))
+	}
+	if merged[0].Type != TXDIVIDEND {
+		t.Errorf("merged[0] = %+v, want the dividend first in date order", merged[0])
+	}
+	if _, added = MergeTransactions(merged, imported); added != 0 {
+		t.Errorf("re-importing added %d, want 0", added)
+	}
+}
@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"math"
+	"sort"
+
+	"github.com/khrystoph/portfoliotools/pkg/indicators"
+)
+
+// ParseIndicatorSpecs parses the indicator names selected in config or on the command line (e.g. "rsi-14", "macd").
+func ParseIndicatorSpecs(names []string) (specs []indicators.Spec, err error) {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		spec, err := indicators.ParseSpec(name)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// CalculateIndicators evaluates each spec over every ticker's chronologically ordered candles and stores the result on
+// each candle's Indicators map. Days still inside an indicator's warm-up period are left out of the map rather than
+// stored as NaN, which JSON cannot represent.
+func CalculateIndicators(stockPrices map[string]map[int64]SingleStockCandle, specs []indicators.Spec) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	if len(specs) == 0 {
+		return stockPrices
+	}
+	for ticker := range stockPrices {
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		sort.Slice(dateKeys, func(i, j int) bool {
+			return dateKeys[i] < dateKeys[j]
+		})
+
+		series := indicators.Series{}
+		for _, date := range dateKeys {
+			c := stockPrices[ticker][date]
+			series.Open = append(series.Open, c.Open)
+			series.High = append(series.High, c.High)
+			series.Low = append(series.Low, c.Low)
+			series.Close = append(series.Close, c.Close)
+			series.Volume = append(series.Volume, c.Volume)
+		}
+
+		for _, spec := range specs {
+			lines, err := spec.Compute(series)
+			if err != nil {
+				continue
+			}
+			for key, values := range lines {
+				for i, date := range dateKeys {
+					if math.IsNaN(values[i]) {
+						continue
+					}
+					c := stockPrices[ticker][date]
+					if c.Indicators == nil {
+						c.Indicators = map[string]float64{}
+					}
+					c.Indicators[key] = values[i]
+					stockPrices[ticker][date] = c
+				}
+			}
+		}
+	}
+	return stockPrices
+}
@@ -0,0 +1,287 @@
+// Package indicators computes standard technical indicators over a daily candle series. Every function returns a
+// slice aligned with its input; positions inside an indicator's warm-up period hold math.NaN().
+package indicators
+
+import (
+	"math"
+)
+
+// Series is a chronologically ordered (oldest first) set of candle columns. All slices must be the same length.
+type Series struct {
+	Open   []float64
+	High   []float64
+	Low    []float64
+	Close  []float64
+	Volume []float64
+}
+
+// Len returns the number of bars in the series.
+func (s Series) Len() int {
+	return len(s.Close)
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+// firstValid returns the index of the first non-NaN value, or len(values) if there is none.
+func firstValid(values []float64) int {
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			return i
+		}
+	}
+	return len(values)
+}
+
+// SMA is the simple moving average of the last period values. Leading NaNs in values are skipped, so SMA can be
+// applied to the output of another indicator.
+func SMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	start := firstValid(values)
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+	var sum float64
+	for i := start; i < len(values); i++ {
+		sum += values[i]
+		if i-start >= period {
+			sum -= values[i-period]
+		}
+		if i-start >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average with smoothing 2/(period+1), seeded with the SMA of the first period values.
+// Leading NaNs in values are skipped.
+func EMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	start := firstValid(values)
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+	alpha := 2.0 / float64(period+1)
+	seed := start + period - 1
+	out[seed] = SMA(values[start:start+period], period)[period-1]
+	for i := seed + 1; i < len(values); i++ {
+		out[i] = alpha*values[i] + (1-alpha)*out[i-1]
+	}
+	return out
+}
+
+// RSI is Wilder's relative strength index. The first value appears at index period.
+func RSI(closes []float64, period int) []float64 {
+	out := nanSlice(len(closes))
+	if period <= 0 || len(closes) <= period {
+		return out
+	}
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		change := closes[i] - closes[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	out[period] = rsiValue(avgGain, avgLoss)
+	for i := period + 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+// MACD returns the MACD line (fast EMA minus slow EMA), its signal line (EMA of the MACD line), and the histogram
+// (MACD minus signal).
+func MACD(closes []float64, fast, slow, signal int) (macd, signalLine, histogram []float64) {
+	fastEMA := EMA(closes, fast)
+	slowEMA := EMA(closes, slow)
+	macd = make([]float64, len(closes))
+	for i := range closes {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	signalLine = EMA(macd, signal)
+	histogram = make([]float64, len(closes))
+	for i := range closes {
+		histogram[i] = macd[i] - signalLine[i]
+	}
+	return macd, signalLine, histogram
+}
+
+// BollingerBands returns the SMA of closes and the bands k population standard deviations above and below it.
+func BollingerBands(closes []float64, period int, k float64) (upper, middle, lower []float64) {
+	middle = SMA(closes, period)
+	upper = nanSlice(len(closes))
+	lower = nanSlice(len(closes))
+	for i := range closes {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+		var sumSq float64
+		for j := i - period + 1; j <= i; j++ {
+			sumSq += (closes[j] - middle[i]) * (closes[j] - middle[i])
+		}
+		sd := math.Sqrt(sumSq / float64(period))
+		upper[i] = middle[i] + k*sd
+		lower[i] = middle[i] - k*sd
+	}
+	return upper, middle, lower
+}
+
+// trueRange returns the true range of each bar. The first bar has no prior close and uses high minus low.
+func trueRange(s Series) []float64 {
+	tr := make([]float64, s.Len())
+	for i := range tr {
+		tr[i] = s.High[i] - s.Low[i]
+		if i > 0 {
+			tr[i] = math.Max(tr[i], math.Max(math.Abs(s.High[i]-s.Close[i-1]), math.Abs(s.Low[i]-s.Close[i-1])))
+		}
+	}
+	return tr
+}
+
+// ATR is Wilder's average true range. The first value appears at index period and averages the true ranges of bars
+// 1 through period.
+func ATR(s Series, period int) []float64 {
+	out := nanSlice(s.Len())
+	if period <= 0 || s.Len() <= period {
+		return out
+	}
+	tr := trueRange(s)
+	var sum float64
+	for i := 1; i <= period; i++ {
+		sum += tr[i]
+	}
+	out[period] = sum / float64(period)
+	for i := period + 1; i < s.Len(); i++ {
+		out[i] = (out[i-1]*float64(period-1) + tr[i]) / float64(period)
+	}
+	return out
+}
+
+// Stochastic returns the fast %K over kPeriod bars and %D, the dPeriod SMA of %K. A window with no range reports 50.
+func Stochastic(s Series, kPeriod, dPeriod int) (k, d []float64) {
+	k = nanSlice(s.Len())
+	if kPeriod > 0 {
+		for i := kPeriod - 1; i < s.Len(); i++ {
+			highest, lowest := s.High[i], s.Low[i]
+			for j := i - kPeriod + 1; j < i; j++ {
+				highest = math.Max(highest, s.High[j])
+				lowest = math.Min(lowest, s.Low[j])
+			}
+			if highest == lowest {
+				k[i] = 50
+			} else {
+				k[i] = 100 * (s.Close[i] - lowest) / (highest - lowest)
+			}
+		}
+	}
+	return k, SMA(k, dPeriod)
+}
+
+// OBV is on-balance volume, starting from zero on the first bar.
+func OBV(s Series) []float64 {
+	out := make([]float64, s.Len())
+	for i := 1; i < s.Len(); i++ {
+		switch {
+		case s.Close[i] > s.Close[i-1]:
+			out[i] = out[i-1] + s.Volume[i]
+		case s.Close[i] < s.Close[i-1]:
+			out[i] = out[i-1] - s.Volume[i]
+		default:
+			out[i] = out[i-1]
+		}
+	}
+	return out
+}
+
+// ADX is Wilder's average directional index along with the +DI and -DI lines it is built from. The DI lines first
+// appear at index period and ADX at index 2*period-1.
+func ADX(s Series, period int) (adx, plusDI, minusDI []float64) {
+	n := s.Len()
+	adx, plusDI, minusDI = nanSlice(n), nanSlice(n), nanSlice(n)
+	if period <= 0 || n <= period {
+		return adx, plusDI, minusDI
+	}
+	tr := trueRange(s)
+	plusDM := make([]float64, n)
+	minusDM := make([]float64, n)
+	for i := 1; i < n; i++ {
+		up := s.High[i] - s.High[i-1]
+		down := s.Low[i-1] - s.Low[i]
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+	}
+
+	var smTR, smPlus, smMinus float64
+	for i := 1; i <= period; i++ {
+		smTR += tr[i]
+		smPlus += plusDM[i]
+		smMinus += minusDM[i]
+	}
+	dx := nanSlice(n)
+	for i := period; i < n; i++ {
+		if i > period {
+			smTR = smTR - smTR/float64(period) + tr[i]
+			smPlus = smPlus - smPlus/float64(period) + plusDM[i]
+			smMinus = smMinus - smMinus/float64(period) + minusDM[i]
+		}
+		if smTR == 0 {
+			plusDI[i], minusDI[i], dx[i] = 0, 0, 0
+			continue
+		}
+		plusDI[i] = 100 * smPlus / smTR
+		minusDI[i] = 100 * smMinus / smTR
+		if sum := plusDI[i] + minusDI[i]; sum != 0 {
+			dx[i] = 100 * math.Abs(plusDI[i]-minusDI[i]) / sum
+		} else {
+			dx[i] = 0
+		}
+	}
+
+	first := 2*period - 1
+	if first >= n {
+		return adx, plusDI, minusDI
+	}
+	var sum float64
+	for i := period; i <= first; i++ {
+		sum += dx[i]
+	}
+	adx[first] = sum / float64(period)
+	for i := first + 1; i < n; i++ {
+		adx[i] = (adx[i-1]*float64(period-1) + dx[i]) / float64(period)
+	}
+	return adx, plusDI, minusDI
+}
@@ -0,0 +1,122 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+const eps = 1e-9
+
+func approxEqual(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: len = %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) {
+			if !math.IsNaN(got[i]) {
+				t.Errorf("%s[%d] = %v, want NaN", name, i, got[i])
+			}
+			continue
+		}
+		if math.Abs(got[i]-want[i]) > eps {
+			t.Errorf("%s[%d] = %v, want %v", name, i, got[i], want[i])
+		}
+	}
+}
+
+// trendingSeries builds n bars that rise by 1 each day with a constant 2-point range.
+func trendingSeries(n int) Series {
+	s := Series{}
+	for i := 0; i < n; i++ {
+		c := 100.0 + float64(i)
+		s.Open = append(s.Open, c-0.5)
+		s.High = append(s.High, c+1)
+		s.Low = append(s.Low, c-1)
+		s.Close = append(s.Close, c)
+		s.Volume = append(s.Volume, 1000)
+	}
+	return s
+}
+
+func TestSMA(t *testing.T) {
+	nan := math.NaN()
+	approxEqual(t, "SMA", SMA([]float64{1, 2, 3, 4, 5}, 3), []float64{nan, nan, 2, 3, 4})
+	approxEqual(t, "SMA leading NaN", SMA([]float64{nan, 1, 2, 3}, 2), []float64{nan, nan, 1.5, 2.5})
+	approxEqual(t, "SMA short input", SMA([]float64{1, 2}, 3), []float64{nan, nan})
+}
+
+func TestEMA(t *testing.T) {
+	nan := math.NaN()
+	// Seed is SMA(1,2,3)=2; alpha=0.5, so 0.5*4+0.5*2=3 and 0.5*5+0.5*3=4.
+	approxEqual(t, "EMA", EMA([]float64{1, 2, 3, 4, 5}, 3), []float64{nan, nan, 2, 3, 4})
+}
+
+func TestRSI(t *testing.T) {
+	nan := math.NaN()
+	approxEqual(t, "RSI rising", RSI([]float64{1, 2, 3, 4, 5}, 3), []float64{nan, nan, nan, 100, 100})
+	approxEqual(t, "RSI flat", RSI([]float64{1, 1, 1, 1}, 2), []float64{nan, nan, 50, 50})
+	// Gains 2, losses 1 over the first 2 changes: RS=2, RSI=100-100/3.
+	approxEqual(t, "RSI mixed", RSI([]float64{10, 12, 11}, 2), []float64{nan, nan, 100 - 100.0/3})
+}
+
+func TestMACD(t *testing.T) {
+	closes := make([]float64, 40)
+	for i := range closes {
+		closes[i] = 50
+	}
+	macd, signal, hist := MACD(closes, 12, 26, 9)
+	if !math.IsNaN(macd[24]) || macd[25] != 0 {
+		t.Errorf("MACD should start at the slow EMA seed: macd[24]=%v macd[25]=%v", macd[24], macd[25])
+	}
+	if !math.IsNaN(signal[32]) || signal[33] != 0 || hist[39] != 0 {
+		t.Errorf("signal should start 9 bars after MACD and be flat: signal[32]=%v signal[33]=%v hist=%v",
+			signal[32], signal[33], hist[39])
+	}
+}
+
+func TestBollingerBands(t *testing.T) {
+	nan := math.NaN()
+	upper, middle, lower := BollingerBands([]float64{1, 3, 1, 3}, 2, 2)
+	approxEqual(t, "middle", middle, []float64{nan, 2, 2, 2})
+	approxEqual(t, "upper", upper, []float64{nan, 4, 4, 4})
+	approxEqual(t, "lower", lower, []float64{nan, 0, 0, 0})
+}
+
+func TestATR(t *testing.T) {
+	nan := math.NaN()
+	// Each bar's true range is max(2, |high-prevClose|=2, |low-prevClose|=0) = 2.
+	approxEqual(t, "ATR", ATR(trendingSeries(5), 3), []float64{nan, nan, nan, 2, 2})
+}
+
+func TestStochastic(t *testing.T) {
+	nan := math.NaN()
+	s := Series{
+		High:  []float64{10, 12, 14, 13},
+		Low:   []float64{8, 9, 10, 11},
+		Close: []float64{9, 12, 11, 11},
+	}
+	k, d := Stochastic(s, 2, 2)
+	// Bar 1: HH=12 LL=8 -> 100; bar 2: HH=14 LL=9 -> 40; bar 3: HH=14 LL=10 -> 25.
+	approxEqual(t, "%K", k, []float64{nan, 100, 40, 25})
+	approxEqual(t, "%D", d, []float64{nan, nan, 70, 32.5})
+}
+
+func TestOBV(t *testing.T) {
+	s := Series{Close: []float64{10, 11, 11, 9}, Volume: []float64{100, 200, 300, 400}}
+	approxEqual(t, "OBV", OBV(s), []float64{0, 200, 200, -200})
+}
+
+func TestADX(t *testing.T) {
+	adx, plus, minus := ADX(trendingSeries(30), 5)
+	if !math.IsNaN(plus[4]) || math.IsNaN(plus[5]) {
+		t.Errorf("DI lines should start at index period: plus[4]=%v plus[5]=%v", plus[4], plus[5])
+	}
+	if !math.IsNaN(adx[8]) || math.IsNaN(adx[9]) {
+		t.Errorf("ADX should start at index 2*period-1: adx[8]=%v adx[9]=%v", adx[8], adx[9])
+	}
+	// A steady uptrend has no downward movement at all.
+	if minus[29] != 0 || plus[29] <= 0 || math.Abs(adx[29]-100) > eps {
+		t.Errorf("uptrend: +DI=%v -DI=%v ADX=%v, want +DI>0, -DI=0, ADX=100", plus[29], minus[29], adx[29])
+	}
+}
@@ -0,0 +1,96 @@
+package indicators
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultParams holds the conventional parameters used when a spec omits them.
+var defaultParams = map[string][]float64{
+	"sma":    {20},
+	"ema":    {20},
+	"rsi":    {14},
+	"macd":   {12, 26, 9},
+	"bbands": {20, 2},
+	"atr":    {14},
+	"stoch":  {14, 3},
+	"obv":    {},
+	"adx":    {14},
+}
+
+// Spec names one indicator and its parameters, e.g. "rsi-14", "macd-12-26-9", or "bbands-20-2".
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// ParseSpec parses a spec string of the form name[-param...]. Missing trailing parameters take their conventional
+// defaults, so "macd" is equivalent to "macd-12-26-9".
+func ParseSpec(s string) (Spec, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "-")
+	defaults, ok := defaultParams[parts[0]]
+	if !ok {
+		return Spec{}, fmt.Errorf("unknown indicator %q", parts[0])
+	}
+	if len(parts)-1 > len(defaults) {
+		return Spec{}, fmt.Errorf("indicator %q takes at most %d parameters", parts[0], len(defaults))
+	}
+	spec := Spec{Name: parts[0], Params: append([]float64(nil), defaults...)}
+	for i, p := range parts[1:] {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v <= 0 {
+			return Spec{}, fmt.Errorf("invalid parameter %q for indicator %q", p, parts[0])
+		}
+		// Only the Bollinger band width may be fractional; every other parameter is a bar count.
+		if !(spec.Name == "bbands" && i == 1) && v != float64(int(v)) {
+			return Spec{}, fmt.Errorf("parameter %q for indicator %q must be a whole number", p, parts[0])
+		}
+		spec.Params[i] = v
+	}
+	return spec, nil
+}
+
+// String returns the normalized spec, including any defaulted parameters. It prefixes every output key of Compute.
+func (s Spec) String() string {
+	parts := []string{s.Name}
+	for _, p := range s.Params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, "-")
+}
+
+func (s Spec) period(i int) int {
+	return int(s.Params[i])
+}
+
+// Compute evaluates the indicator over the series. Single-line indicators return one entry keyed by s.String();
+// multi-line indicators add a suffix per line, e.g. "macd-12-26-9-signal".
+func (s Spec) Compute(series Series) (map[string][]float64, error) {
+	key := s.String()
+	switch s.Name {
+	case "sma":
+		return map[string][]float64{key: SMA(series.Close, s.period(0))}, nil
+	case "ema":
+		return map[string][]float64{key: EMA(series.Close, s.period(0))}, nil
+	case "rsi":
+		return map[string][]float64{key: RSI(series.Close, s.period(0))}, nil
+	case "macd":
+		macd, signal, hist := MACD(series.Close, s.period(0), s.period(1), s.period(2))
+		return map[string][]float64{key: macd, key + "-signal": signal, key + "-hist": hist}, nil
+	case "bbands":
+		upper, middle, lower := BollingerBands(series.Close, s.period(0), s.Params[1])
+		return map[string][]float64{key + "-upper": upper, key + "-middle": middle, key + "-lower": lower}, nil
+	case "atr":
+		return map[string][]float64{key: ATR(series, s.period(0))}, nil
+	case "stoch":
+		k, d := Stochastic(series, s.period(0), s.period(1))
+		return map[string][]float64{key + "-k": k, key + "-d": d}, nil
+	case "obv":
+		return map[string][]float64{key: OBV(series)}, nil
+	case "adx":
+		adx, plus, minus := ADX(series, s.period(0))
+		return map[string][]float64{key: adx, key + "-plus-di": plus, key + "-minus-di": minus}, nil
+	}
+	return nil, fmt.Errorf("unknown indicator %q", s.Name)
+}
@@ -0,0 +1,73 @@
+package indicators
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "sma-50", want: "sma-50"},
+		{input: "RSI", want: "rsi-14"},
+		{input: "macd", want: "macd-12-26-9"},
+		{input: "macd-5", want: "macd-5-26-9"},
+		{input: "bbands-20-2.5", want: "bbands-20-2.5"},
+		{input: " obv ", want: "obv"},
+		{input: "vwap", wantErr: true},
+		{input: "sma-0", wantErr: true},
+		{input: "sma-2.5", wantErr: true},
+		{input: "obv-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSpec(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseSpec(%q) = %q, want %q", tt.input, got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecCompute_Keys(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"sma-3", []string{"sma-3"}},
+		{"macd-3-5-2", []string{"macd-3-5-2", "macd-3-5-2-hist", "macd-3-5-2-signal"}},
+		{"bbands-3-2", []string{"bbands-3-2-lower", "bbands-3-2-middle", "bbands-3-2-upper"}},
+		{"stoch-3-2", []string{"stoch-3-2-d", "stoch-3-2-k"}},
+		{"adx-3", []string{"adx-3", "adx-3-minus-di", "adx-3-plus-di"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseSpec(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := spec.Compute(trendingSeries(10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []string
+			for k, v := range out {
+				keys = append(keys, k)
+				if len(v) != 10 {
+					t.Errorf("%s: len = %d, want 10", k, len(v))
+				}
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("keys = %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
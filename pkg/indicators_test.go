@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestParseIndicatorSpecs(t *testing.T) {
+	specs, err := ParseIndicatorSpecs([]string{"sma-5", "", "macd"})
+	if err != nil {
+		t.Fatalf("ParseIndicatorSpecs() error = %v", err)
+	}
+	if len(specs) != 2 || specs[0].String() != "sma-5" || specs[1].String() != "macd-12-26-9" {
+		t.Errorf("ParseIndicatorSpecs() = %v, want [sma-5 macd-12-26-9]", specs)
+	}
+	if _, err := ParseIndicatorSpecs([]string{"bogus"}); err == nil {
+		t.Error("expected error for unknown indicator")
+	}
+}
+
+func TestCalculateIndicators(t *testing.T) {
+	data := makeTestData("AAPL", 10)
+	specs, err := ParseIndicatorSpecs([]string{"sma-5", "obv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := CalculateIndicators(data, specs)
+
+	withSMA := 0
+	for _, c := range result["AAPL"] {
+		if _, ok := c.Indicators["obv"]; !ok {
+			t.Errorf("obv missing on %s", c.Timestamp)
+		}
+		if _, ok := c.Indicators["sma-5"]; ok {
+			withSMA++
+		}
+	}
+	// The 4 oldest days are inside the SMA warm-up and must be left out rather than stored as NaN.
+	if withSMA != 6 {
+		t.Errorf("candles with sma-5 = %d, want 6", withSMA)
+	}
+}
+
+func TestCalculateIndicators_NoSpecsLeavesCandlesUntouched(t *testing.T) {
+	data := makeTestData("AAPL", 10)
+	for _, c := range CalculateIndicators(data, nil)["AAPL"] {
+		if c.Indicators != nil {
+			t.Errorf("Indicators should be nil when no specs are selected: got %v", c.Indicators)
+		}
+	}
+}
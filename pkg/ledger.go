@@ -0,0 +1,386 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Transaction types recorded in the ledger.
+const (
+	TXBUY      = "buy"
+	TXSELL     = "sell"
+	TXDIVIDEND = "dividend"
+	TXSPLIT    = "split"
+	TXFEE      = "fee"
+)
+
+// Lot matching methods accepted by BuildLedger.
+const (
+	LOTFIFO       = "fifo"
+	LOTLIFO       = "lifo"
+	LOTSPECIFICID = "specific-id"
+)
+
+// Holding periods of a lot.
+const (
+	SHORTTERM = "short-term"
+	LONGTERM  = "long-term"
+)
+
+// lotQuantityEpsilon absorbs floating point residue when a sell closes a lot exactly.
+const lotQuantityEpsilon = 1e-9
+
+// Transaction is one entry in the ledger. Price is per share and Fees is the total charged on the trade. A buy opens a
+// lot identified by LotID, or by ID, or by its ticker, date, and count among that day's buys of the ticker when neither
+// is set; lot IDs must be unique. A sell closes the lot named by LotID when set, otherwise lots chosen by the ledger's
+// matching method. A dividend or fee is the cash Amount paid or charged; a dividend's Amount is negative for tax
+// withheld. A split multiplies the ticker's open lots by Ratio, or, without one, adds Quantity shares spread over the
+// lots, as brokers report it.
+type Transaction struct {
+	ID       string    `json:"id,omitempty"`
+	Date     time.Time `json:"date"`
+	Ticker   string    `json:"ticker"`
+	Type     string    `json:"type"`
+	Quantity float64   `json:"quantity,omitempty"`
+	Price    float64   `json:"price,omitempty"`
+	Fees     float64   `json:"fees,omitempty"`
+	Amount   float64   `json:"amount,omitempty"`
+	Ratio    float64   `json:"ratio,omitempty"`
+	LotID    string    `json:"lot-id,omitempty"`
+}
+
+// Lot is an open tax lot: Quantity shares remaining of OpenQuantity bought on OpenDate at a per-share CostBasis that
+// includes the buy's fees.
+type Lot struct {
+	ID           string    `json:"id"`
+	Ticker       string    `json:"ticker"`
+	OpenDate     time.Time `json:"open-date"`
+	OpenQuantity float64   `json:"open-quantity"`
+	Quantity     float64   `json:"quantity"`
+	CostBasis    float64   `json:"cost-basis"`
+}
+
+// RealizedLot is the part of a lot closed by one sell. CostBasis and Proceeds are totals, with the sell's fees taken
+// from the proceeds in proportion to the shares each lot gave up. AnnualReturn is the lot's annualized return over
+// the sessions it was held, left zero when it was bought and sold on the same day.
+type RealizedLot struct {
+	LotID        string    `json:"lot-id"`
+	Ticker       string    `json:"ticker"`
+	OpenDate     time.Time `json:"open-date"`
+	CloseDate    time.Time `json:"close-date"`
+	Quantity     float64   `json:"quantity"`
+	CostBasis    float64   `json:"cost-basis"`
+	Proceeds     float64   `json:"proceeds"`
+	Gain         float64   `json:"gain"`
+	Term         string    `json:"term"`
+	AnnualReturn float64   `json:"annual-return"`
+}
+
+// Ledger is the result of replaying transactions: the lots still open, oldest first, every lot closed so far, in the
+// order they were closed, the dividends received by ticker, and the fees charged outside of trades.
+type Ledger struct {
+	Method    string             `json:"method"`
+	Open      []Lot              `json:"open"`
+	Realized  []RealizedLot      `json:"realized"`
+	Dividends map[string]float64 `json:"dividends,omitempty"`
+	Fees      float64            `json:"fees,omitempty"`
+}
+
+// holdingTerm is long-term for a lot held more than a year and short-term otherwise.
+func holdingTerm(open, close time.Time) string {
+	if truncateToDay(close).After(truncateToDay(open).AddDate(1, 0, 0)) {
+		return LONGTERM
+	}
+	return SHORTTERM
+}
+
+// SortTransactions orders transactions by date, keeping the recorded order of transactions on the same date.
+func SortTransactions(transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return truncateToDay(transactions[i].Date).Before(truncateToDay(transactions[j].Date))
+	})
+}
+
+// BuildLedger replays transactions in date order into lots. Sells close lots of the same ticker first in, first out
+// with fifo, last in, first out with lifo, or only the lot named on the sell with specific-id. Selling more than is
+// held, naming a lot that is not open, a specific-id sell without a lot, two buys opening the same lot, or splitting
+// a ticker with no open lots is an error.
+func BuildLedger(transactions []Transaction, method string) (*Ledger, error) {
+	switch method {
+	case "":
+		method = LOTFIFO
+	case LOTFIFO, LOTLIFO, LOTSPECIFICID:
+	default:
+		return nil, fmt.Errorf("unknown lot method %q: must be %s, %s, or %s", method, LOTFIFO, LOTLIFO,
+			LOTSPECIFICID)
+	}
+	sorted := append([]Transaction(nil), transactions...)
+	SortTransactions(sorted)
+
+	ledger := &Ledger{Method: method}
+	open := map[string][]*Lot{}
+	lotIDs, buysThatDay := map[string]bool{}, map[string]int{}
+	for i, tx := range sorted {
+		tx.Ticker = NormalizeTicker(tx.Ticker)
+		if err := tx.validate(); err != nil {
+			return nil, fmt.Errorf("transaction %d (%s %s on %s): %w", i+1, tx.Type, tx.Ticker,
+				tx.Date.Format(time.DateOnly), err)
+		}
+		switch tx.Type {
+		case TXBUY:
+			// Default IDs count the ticker's buys that day, so merging in older transactions leaves them unchanged
+			day := fmt.Sprintf("%s-%s", tx.Ticker, tx.Date.Format(time.DateOnly))
+			buysThatDay[day]++
+			id := tx.LotID
+			if id == "" {
+				id = tx.ID
+			}
+			if id == "" {
+				id = fmt.Sprintf("%s-%d", day, buysThatDay[day])
+			}
+			if lotIDs[id] {
+				return nil, fmt.Errorf("transaction %d (buy %s on %s): lot %s is already in the ledger", i+1,
+					tx.Ticker, tx.Date.Format(time.DateOnly), id)
+			}
+			lotIDs[id] = true
+			open[tx.Ticker] = append(open[tx.Ticker], &Lot{ID: id, Ticker: tx.Ticker, OpenDate: tx.Date,
+				OpenQuantity: tx.Quantity, Quantity: tx.Quantity, CostBasis: (tx.Quantity*tx.Price + tx.Fees) / tx.Quantity})
+		case TXSELL:
+			realized, err := closeLots(open[tx.Ticker], tx, method)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d (sell %s on %s): %w", i+1, tx.Ticker,
+					tx.Date.Format(time.DateOnly), err)
+			}
+			ledger.Realized = append(ledger.Realized, realized...)
+			remaining := open[tx.Ticker][:0]
+			for _, lot := range open[tx.Ticker] {
+				if lot.Quantity > lotQuantityEpsilon {
+					remaining = append(remaining, lot)
+				}
+			}
+			open[tx.Ticker] = remaining
+		case TXSPLIT:
+			if err := splitLots(open[tx.Ticker], tx); err != nil {
+				return nil, fmt.Errorf("transaction %d (split %s on %s): %w", i+1, tx.Ticker,
+					tx.Date.Format(time.DateOnly), err)
+			}
+		case TXDIVIDEND:
+			if ledger.Dividends == nil {
+				ledger.Dividends = map[string]float64{}
+			}
+			ledger.Dividends[tx.Ticker] += tx.Amount
+		case TXFEE:
+			ledger.Fees += tx.Amount
+		}
+	}
+	for _, lots := range open {
+		for _, lot := range lots {
+			ledger.Open = append(ledger.Open, *lot)
+		}
+	}
+	sort.SliceStable(ledger.Open, func(i, j int) bool {
+		if !ledger.Open[i].OpenDate.Equal(ledger.Open[j].OpenDate) {
+			return ledger.Open[i].OpenDate.Before(ledger.Open[j].OpenDate)
+		}
+		return ledger.Open[i].Ticker < ledger.Open[j].Ticker
+	})
+	return ledger, nil
+}
+
+// validate checks the fields each type of transaction relies on.
+func (tx Transaction) validate() error {
+	switch tx.Type {
+	case TXBUY, TXSELL:
+		if tx.Quantity <= 0 || tx.Price < 0 || tx.Fees < 0 {
+			return errors.New("quantity must be positive and price and fees not negative")
+		}
+	case TXDIVIDEND:
+		if tx.Amount == 0 {
+			return errors.New("dividend has no amount")
+		}
+	case TXFEE:
+		if tx.Amount <= 0 {
+			return errors.New("fee amount must be positive")
+		}
+	case TXSPLIT:
+		if tx.Ratio < 0 || (tx.Ratio == 0 && tx.Quantity == 0) {
+			return errors.New("split needs a positive ratio or the shares it added")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", tx.Type)
+	}
+	return nil
+}
+
+// splitLots scales every open lot of the split's ticker by its ratio, derived from the shares held when the split
+// only names the shares it added (negative for a reverse split). Each lot keeps its open date and total cost.
+func splitLots(lots []*Lot, split Transaction) error {
+	var held float64
+	for _, lot := range lots {
+		held += lot.Quantity
+	}
+	if held <= lotQuantityEpsilon {
+		return errors.New("no open lots to split")
+	}
+	ratio := split.Ratio
+	if ratio == 0 {
+		ratio = (held + split.Quantity) / held
+	}
+	if ratio <= 0 {
+		return fmt.Errorf("split removes %g shares but only %g are held", -split.Quantity, held)
+	}
+	for _, lot := range lots {
+		lot.Quantity *= ratio
+		lot.OpenQuantity *= ratio
+		lot.CostBasis /= ratio
+	}
+	return nil
+}
+
+// closeLots takes the sell's shares from lots, which are oldest first, and returns the realized part of each lot.
+func closeLots(lots []*Lot, sell Transaction, method string) ([]RealizedLot, error) {
+	var order []*Lot
+	switch {
+	case sell.LotID != "":
+		for _, lot := range lots {
+			if lot.ID == sell.LotID {
+				order = append(order, lot)
+			}
+		}
+		if len(order) == 0 {
+			return nil, fmt.Errorf("lot %s is not open", sell.LotID)
+		}
+	case method == LOTSPECIFICID:
+		return nil, errors.New("specific-id sells must name a lot-id")
+	case method == LOTLIFO:
+		for i := len(lots) - 1; i >= 0; i-- {
+			order = append(order, lots[i])
+		}
+	default:
+		order = lots
+	}
+	var held float64
+	for _, lot := range order {
+		held += lot.Quantity
+	}
+	if sell.Quantity > held+lotQuantityEpsilon {
+		return nil, fmt.Errorf("selling %g shares but only %g are held", sell.Quantity, held)
+	}
+
+	var realized []RealizedLot
+	remaining := sell.Quantity
+	for _, lot := range order {
+		if remaining <= lotQuantityEpsilon {
+			break
+		}
+		quantity := math.Min(lot.Quantity, remaining)
+		lot.Quantity -= quantity
+		remaining -= quantity
+		r := RealizedLot{
+			LotID:     lot.ID,
+			Ticker:    lot.Ticker,
+			OpenDate:  lot.OpenDate,
+			CloseDate: sell.Date,
+			Quantity:  quantity,
+			CostBasis: quantity * lot.CostBasis,
+			Proceeds:  quantity*sell.Price - sell.Fees*quantity/sell.Quantity,
+			Term:      holdingTerm(lot.OpenDate, sell.Date),
+		}
+		r.Gain = r.Proceeds - r.CostBasis
+		if annual, err := AnnualizedReturn(AssetClassOf(lot.Ticker), r.Proceeds/quantity, lot.CostBasis,
+			lot.OpenDate, sell.Date, false); err == nil {
+			r.AnnualReturn = annual
+		}
+		realized = append(realized, r)
+	}
+	return realized, nil
+}
+
+// Holdings returns each open lot as a Holding, so lots bought at different times are valued and annualized apart.
+func (l *Ledger) Holdings() []Holding {
+	holdings := make([]Holding, 0, len(l.Open))
+	for _, lot := range l.Open {
+		holdings = append(holdings, Holding{Ticker: lot.Ticker, Quantity: lot.Quantity, CostBasis: lot.CostBasis,
+			OpenDate: lot.OpenDate})
+	}
+	return holdings
+}
+
+// UnrealizedLot is an open lot valued at Price as of a date.
+type UnrealizedLot struct {
+	Lot
+	Price        float64 `json:"price"`
+	MarketValue  float64 `json:"market-value"`
+	Gain         float64 `json:"gain"`
+	Term         string  `json:"term"`
+	AnnualReturn float64 `json:"annual-return"`
+}
+
+// LotReport is the ledger valued as of AsOf: every open lot's unrealized gain, every closed lot's realized gain, and
+// both totaled by holding period, along with the dividends received and fees charged outside of trades.
+type LotReport struct {
+	Method     string             `json:"method"`
+	AsOf       time.Time          `json:"as-of"`
+	Open       []UnrealizedLot    `json:"open"`
+	Realized   []RealizedLot      `json:"realized"`
+	Unrealized map[string]float64 `json:"unrealized-by-term"`
+	Gains      map[string]float64 `json:"realized-by-term"`
+	Dividends  map[string]float64 `json:"dividends,omitempty"`
+	Fees       float64            `json:"fees,omitempty"`
+}
+
+// Report values the open lots at prices, keyed by normalized ticker, as of asOf. Lots without a price are valued at
+// their cost basis.
+func (l *Ledger) Report(prices map[string]float64, asOf time.Time) LotReport {
+	report := LotReport{Method: l.Method, AsOf: asOf, Realized: l.Realized, Dividends: l.Dividends, Fees: l.Fees,
+		Unrealized: map[string]float64{SHORTTERM: 0, LONGTERM: 0}, Gains: map[string]float64{SHORTTERM: 0, LONGTERM: 0}}
+	for _, lot := range l.Open {
+		price, ok := prices[lot.Ticker]
+		if !ok || price <= 0 {
+			price = lot.CostBasis
+		}
+		u := UnrealizedLot{Lot: lot, Price: price, MarketValue: lot.Quantity * price,
+			Gain: lot.Quantity * (price - lot.CostBasis), Term: holdingTerm(lot.OpenDate, asOf)}
+		if annual, err := AnnualizedReturn(AssetClassOf(lot.Ticker), price, lot.CostBasis, lot.OpenDate, asOf,
+			false); err == nil {
+			u.AnnualReturn = annual
+		}
+		report.Unrealized[u.Term] += u.Gain
+		report.Open = append(report.Open, u)
+	}
+	for _, r := range l.Realized {
+		report.Gains[r.Term] += r.Gain
+	}
+	return report
+}
+
+// LoadTransactions reads a ledger of transactions from a json file. A missing file is an empty ledger.
+func LoadTransactions(path string) ([]Transaction, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var transactions []Transaction
+	if err = json.Unmarshal(data, &transactions); err != nil {
+		return nil, fmt.Errorf("error decoding transactions %s: %v", path, err)
+	}
+	return transactions, nil
+}
+
+// SaveTransactions writes the ledger of transactions to a json file in date order.
+func SaveTransactions(path string, transactions []Transaction) error {
+	SortTransactions(transactions)
+	data, err := json.MarshalIndent(transactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
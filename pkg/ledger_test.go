@@ -0,0 +1,232 @@
+package pkg
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func ledgerDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildLedger(t *testing.T) {
+	transactions := []Transaction{
+		// Recorded out of order; the ledger replays them by date.
+		{Date: ledgerDate(2025, 3, 3), Ticker: "aaa", Type: TXSELL, Quantity: 15, Price: 30, Fees: 3},
+		{ID: "first", Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 10, Fees: 1},
+		{ID: "second", Date: ledgerDate(2024, 6, 3), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 20},
+		{Date: ledgerDate(2024, 6, 3), Ticker: "BBB", Type: TXBUY, Quantity: 1, Price: 50},
+	}
+	tests := []struct {
+		method       string
+		wantRealized []RealizedLot
+		wantOpen     []string
+	}{
+		{LOTFIFO, []RealizedLot{
+			{LotID: "first", Quantity: 10, CostBasis: 101, Proceeds: 298, Gain: 197, Term: LONGTERM},
+			{LotID: "second", Quantity: 5, CostBasis: 100, Proceeds: 149, Gain: 49, Term: SHORTTERM},
+		}, []string{"second", "BBB-2024-06-03-1"}},
+		{LOTLIFO, []RealizedLot{
+			{LotID: "second", Quantity: 10, CostBasis: 200, Proceeds: 298, Gain: 98, Term: SHORTTERM},
+			{LotID: "first", Quantity: 5, CostBasis: 50.5, Proceeds: 149, Gain: 98.5, Term: LONGTERM},
+		}, []string{"first", "BBB-2024-06-03-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ledger, err := BuildLedger(transactions, tt.method)
+			if err != nil {
+				t.Fatalf("BuildLedger() error = %v", err)
+			}
+			if len(ledger.Realized) != len(tt.wantRealized) {
+				t.Fatalf("got %d realized lots, want %d", len(ledger.Realized), len(tt.wantRealized))
+			}
+			for i, want := range tt.wantRealized {
+				got := ledger.Realized[i]
+				if got.LotID != want.LotID || got.Quantity != want.Quantity || got.Term != want.Term ||
+					math.Abs(got.CostBasis-want.CostBasis) > 1e-9 || math.Abs(got.Proceeds-want.Proceeds) > 1e-9 ||
+					math.Abs(got.Gain-want.Gain) > 1e-9 {
+					t.Errorf("realized %d = %+v, want %+v", i, got, want)
+				}
+				if got.AnnualReturn <= 0 {
+					t.Errorf("realized %d AnnualReturn = %v, want a gain", i, got.AnnualReturn)
+				}
+			}
+			if len(ledger.Open) != len(tt.wantOpen) {
+				t.Fatalf("got %d open lots, want %d", len(ledger.Open), len(tt.wantOpen))
+			}
+			for i, id := range tt.wantOpen {
+				if ledger.Open[i].ID != id {
+					t.Errorf("open lot %d = %s, want %s", i, ledger.Open[i].ID, id)
+				}
+			}
+			if q := ledger.Open[0].Quantity; q != 5 {
+				t.Errorf("remaining quantity = %v, want 5", q)
+			}
+		})
+	}
+}
+
+func TestBuildLedger_SpecificID(t *testing.T) {
+	transactions := []Transaction{
+		{LotID: "cheap", Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 10},
+		{LotID: "dear", Date: ledgerDate(2024, 2, 1), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 20},
+		{LotID: "dear", Date: ledgerDate(2024, 3, 1), Ticker: "AAA", Type: TXSELL, Quantity: 4, Price: 15},
+	}
+	ledger, err := BuildLedger(transactions, LOTSPECIFICID)
+	if err != nil {
+		t.Fatalf("BuildLedger() error = %v", err)
+	}
+	if r := ledger.Realized[0]; r.LotID != "dear" || math.Abs(r.Gain+20) > 1e-9 {
+		t.Errorf("realized = %+v, want a 20 loss on dear", r)
+	}
+	if len(ledger.Holdings()) != 2 || ledger.Holdings()[1].Quantity != 6 {
+		t.Errorf("Holdings() = %+v", ledger.Holdings())
+	}
+}
+
+func TestBuildLedger_StableLotIDs(t *testing.T) {
+	transactions := []Transaction{
+		{Date: ledgerDate(2024, 6, 3), Ticker: "AAA", Type: TXBUY, Quantity: 1, Price: 10},
+		{Date: ledgerDate(2024, 6, 3), Ticker: "AAA", Type: TXBUY, Quantity: 2, Price: 11},
+		{Date: ledgerDate(2024, 7, 1), Ticker: "AAA", Type: TXSELL, Quantity: 2, Price: 12, LotID: "AAA-2024-06-03-2"},
+	}
+	// Merging in an older buy must not rename the lot the sell names.
+	older := Transaction{Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 5, Price: 8}
+	merged, _ := MergeTransactions(transactions, []Transaction{older})
+	for _, ledger := range [][]Transaction{transactions, merged} {
+		got, err := BuildLedger(ledger, LOTSPECIFICID)
+		if err != nil {
+			t.Fatalf("BuildLedger() error = %v", err)
+		}
+		if r := got.Realized[0]; r.LotID != "AAA-2024-06-03-2" || r.CostBasis != 22 {
+			t.Errorf("realized = %+v, want both shares of the second buy on 2024-06-03", r)
+		}
+	}
+}
+
+func TestBuildLedger_Errors(t *testing.T) {
+	buy := Transaction{LotID: "a", Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 10}
+	sell := func(quantity float64, lot string) Transaction {
+		return Transaction{Date: ledgerDate(2024, 2, 1), Ticker: "AAA", Type: TXSELL, Quantity: quantity, Price: 12,
+			LotID: lot}
+	}
+	tests := []struct {
+		name         string
+		method       string
+		transactions []Transaction
+	}{
+		{"unknown method", "hifo", []Transaction{buy}},
+		{"oversold", LOTFIFO, []Transaction{buy, sell(11, "")}},
+		{"sold before bought", LOTFIFO, []Transaction{sell(1, ""), {Date: ledgerDate(2024, 3, 1), Ticker: "AAA",
+			Type: TXBUY, Quantity: 1, Price: 1}}},
+		{"unknown lot", LOTFIFO, []Transaction{buy, sell(1, "b")}},
+		{"specific-id without a lot", LOTSPECIFICID, []Transaction{buy, sell(1, "")}},
+		{"duplicate lot", LOTFIFO, []Transaction{buy, buy}},
+		{"unknown type", LOTFIFO, []Transaction{{Date: buy.Date, Ticker: "AAA", Type: "gift", Quantity: 1}}},
+		{"zero quantity", LOTFIFO, []Transaction{{Date: buy.Date, Ticker: "AAA", Type: TXBUY, Price: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BuildLedger(tt.transactions, tt.method); err == nil {
+				t.Error("BuildLedger() expected an error")
+			}
+		})
+	}
+}
+
+func TestLedgerReport(t *testing.T) {
+	transactions := []Transaction{
+		{Date: ledgerDate(2023, 6, 1), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 10},
+		{Date: ledgerDate(2024, 6, 3), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 20},
+		{Date: ledgerDate(2024, 6, 3), Ticker: "ZZZ", Type: TXBUY, Quantity: 1, Price: 40},
+	}
+	ledger, err := BuildLedger(transactions, LOTFIFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := ledger.Report(map[string]float64{"AAA": 25}, ledgerDate(2024, 12, 2))
+	if report.Unrealized[LONGTERM] != 150 || report.Unrealized[SHORTTERM] != 50 {
+		t.Errorf("unrealized by term = %v, want 150 long-term and 50 short-term", report.Unrealized)
+	}
+	// ZZZ has no price, so it is valued at cost.
+	if z := report.Open[2]; z.Ticker != "ZZZ" || z.Gain != 0 || z.Price != 40 {
+		t.Errorf("unpriced lot = %+v", z)
+	}
+}
+
+func TestHoldingTerm(t *testing.T) {
+	open := ledgerDate(2024, 3, 15)
+	if got := holdingTerm(open, ledgerDate(2025, 3, 15)); got != SHORTTERM {
+		t.Errorf("exactly one year = %s, want %s", got, SHORTTERM)
+	}
+	if got := holdingTerm(open, ledgerDate(2025, 3, 16)); got != LONGTERM {
+		t.Errorf("one year and a day = %s, want %s", got, LONGTERM)
+	}
+}
+
+func TestSaveLoadTransactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.json")
+	if got, err := LoadTransactions(path); err != nil || got != nil {
+		t.Fatalf("LoadTransactions() of a missing file = %v, %v", got, err)
+	}
+	transactions := []Transaction{
+		{ID: "2", Date: ledgerDate(2024, 2, 1), Ticker: "AAA", Type: TXSELL, Quantity: 1, Price: 12},
+		{ID: "1", Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 1, Price: 10, Fees: 0.5},
+	}
+	if err := SaveTransactions(path, transactions); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadTransactions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != "1" || got[0].Fees != 0.5 || !got[0].Date.Equal(ledgerDate(2024, 1, 2)) {
+		t.Errorf("LoadTransactions() = %+v", got)
+	}
+}
+
+func TestBuildLedger_CashAndSplits(t *testing.T) {
+	transactions := []Transaction{
+		{LotID: "a", Date: ledgerDate(2024, 1, 2), Ticker: "NVDA", Type: TXBUY, Quantity: 10, Price: 500},
+		{LotID: "b", Date: ledgerDate(2024, 3, 1), Ticker: "NVDA", Type: TXBUY, Quantity: 10, Price: 800},
+		{Date: ledgerDate(2024, 3, 5), Ticker: "NVDA", Type: TXDIVIDEND, Amount: 0.8},
+		{Date: ledgerDate(2024, 3, 5), Ticker: "NVDA", Type: TXDIVIDEND, Amount: -0.12},
+		{Date: ledgerDate(2024, 4, 1), Type: TXFEE, Amount: 25},
+		// A 10-for-1 split reported as the 180 shares it added.
+		{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXSPLIT, Quantity: 180},
+		{Date: ledgerDate(2024, 7, 1), Ticker: "NVDA", Type: TXSELL, Quantity: 150, Price: 120},
+	}
+	ledger, err := BuildLedger(transactions, LOTFIFO)
+	if err != nil {
+		t.Fatalf("BuildLedger() error = %v", err)
+	}
+	if math.Abs(ledger.Dividends["NVDA"]-0.68) > 1e-9 || ledger.Fees != 25 {
+		t.Errorf("dividends = %v, fees = %v, want 0.68 and 25", ledger.Dividends, ledger.Fees)
+	}
+	if len(ledger.Realized) != 2 || math.Abs(ledger.Realized[0].Gain-7000) > 1e-9 ||
+		math.Abs(ledger.Realized[1].Gain-2000) > 1e-9 {
+		t.Errorf("realized = %+v, want gains of 7000 on a and 2000 on b", ledger.Realized)
+	}
+	if lot := ledger.Open[0]; lot.ID != "b" || lot.Quantity != 50 || lot.OpenQuantity != 100 || lot.CostBasis != 80 {
+		t.Errorf("open lot = %+v, want 50 of 100 split shares of b at 80", lot)
+	}
+
+	ratio := append([]Transaction(nil), transactions[:2]...)
+	ratio = append(ratio, Transaction{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXSPLIT, Ratio: 0.5})
+	if ledger, err = BuildLedger(ratio, LOTFIFO); err != nil || ledger.Open[1].CostBasis != 1600 {
+		t.Errorf("reverse split = %+v, %v, want lot b at 1600", ledger, err)
+	}
+	for _, bad := range []Transaction{
+		{Date: ledgerDate(2024, 6, 10), Ticker: "AAPL", Type: TXSPLIT, Ratio: 2},
+		{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXSPLIT, Quantity: -20},
+		{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXSPLIT},
+		{Date: ledgerDate(2024, 6, 10), Type: TXFEE, Amount: -1},
+		{Date: ledgerDate(2024, 6, 10), Ticker: "NVDA", Type: TXDIVIDEND},
+	} {
+		if _, err = BuildLedger(append(transactions[:2:2], bad), LOTFIFO); err == nil {
+			t.Errorf("BuildLedger() with %+v expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// SimulationConf controls the Monte Carlo simulators. Paths defaults to 10,000. Seed makes runs reproducible. Drift is
+// the expected annual return, in decimal form, that SimulateGBM grows prices at; bootstrap paths carry whatever drift
+// the historical returns had.
+type SimulationConf struct {
+	Paths int     `json:"paths"`
+	Seed  uint64  `json:"seed"`
+	Drift float64 `json:"drift"`
+}
+
+// HitProbability is the outcome of simulating Paths price paths Sessions sessions ahead. TouchProbability is the share
+// of paths whose close reached Target at any session (checked at closes, so intraday touches are missed), and
+// FinishProbability the share that ended at or beyond it. Terminal holds the mean and the p5, p25, p50, p75, and p95
+// percentiles of the final prices.
+type HitProbability struct {
+	Method            string             `json:"method"`
+	Spot              float64            `json:"spot"`
+	Target            float64            `json:"target"`
+	Sessions          int                `json:"sessions"`
+	Paths             int                `json:"paths"`
+	TouchProbability  float64            `json:"touch-probability"`
+	FinishProbability float64            `json:"finish-probability"`
+	Terminal          map[string]float64 `json:"terminal"`
+}
+
+func (c SimulationConf) paths() int {
+	if c.Paths <= 0 {
+		return 10000
+	}
+	return c.Paths
+}
+
+// simulatePaths runs the simulation shared by both methods. step draws one session's log return. A target above spot
+// is reached from below and a target below spot from above.
+func simulatePaths(method string, spot, target float64, sessions int, conf SimulationConf,
+	step func(rng *rand.Rand) float64) HitProbability {
+	rng := rand.New(rand.NewPCG(conf.Seed, conf.Seed^0x9E3779B97F4A7C15))
+	result := HitProbability{Method: method, Spot: spot, Target: target, Sessions: sessions, Paths: conf.paths()}
+	above := target >= spot
+	reached := func(price float64) bool {
+		if above {
+			return price >= target
+		}
+		return price <= target
+	}
+	terminal := make([]float64, result.Paths)
+	var touched, finished int
+	for p := range terminal {
+		logPrice := math.Log(spot)
+		hit := reached(spot)
+		for s := 0; s < sessions; s++ {
+			logPrice += step(rng)
+			if !hit && reached(math.Exp(logPrice)) {
+				hit = true
+			}
+		}
+		terminal[p] = math.Exp(logPrice)
+		if hit {
+			touched++
+		}
+		if reached(terminal[p]) {
+			finished++
+		}
+	}
+	result.TouchProbability = float64(touched) / float64(result.Paths)
+	result.FinishProbability = float64(finished) / float64(result.Paths)
+	result.Terminal = terminalDistribution(terminal)
+	return result
+}
+
+// terminalDistribution summarizes simulated final prices by their mean and nearest-rank percentiles.
+func terminalDistribution(prices []float64) map[string]float64 {
+	sort.Float64s(prices)
+	var sum float64
+	for _, p := range prices {
+		sum += p
+	}
+	dist := map[string]float64{"mean": sum / float64(len(prices))}
+	for _, pct := range []struct {
+		key string
+		p   float64
+	}{{"p5", 0.05}, {"p25", 0.25}, {"p50", 0.5}, {"p75", 0.75}, {"p95", 0.95}} {
+		rank := int(math.Ceil(pct.p*float64(len(prices)))) - 1
+		dist[pct.key] = prices[max(rank, 0)]
+	}
+	return dist
+}
+
+func validateSimulation(spot, target float64, sessions int) error {
+	if spot <= 0 || target <= 0 {
+		return errors.New("spot and target prices must be positive")
+	}
+	if sessions < 1 {
+		return errors.New("simulation needs at least one session")
+	}
+	return nil
+}
+
+// SimulateGBM estimates the probability of reaching target within sessions under geometric Brownian motion with the
+// annualized volatility annualVol and conf.Drift, stepping one session (1/daysInYear of a year) at a time.
+func SimulateGBM(spot, target, annualVol float64, sessions int, daysInYear float64,
+	conf SimulationConf) (HitProbability, error) {
+	if err := validateSimulation(spot, target, sessions); err != nil {
+		return HitProbability{}, err
+	}
+	if annualVol < 0 || math.IsNaN(annualVol) {
+		return HitProbability{}, errors.New("volatility must be a non-negative number")
+	}
+	drift := (math.Log1p(conf.Drift) - annualVol*annualVol/2) / daysInYear
+	shock := annualVol / math.Sqrt(daysInYear)
+	return simulatePaths("gbm", spot, target, sessions, conf, func(rng *rand.Rand) float64 {
+		return drift + shock*rng.NormFloat64()
+	}), nil
+}
+
+// SimulateBootstrap estimates the probability of reaching target within sessions by resampling, with replacement,
+// the historical daily log returns.
+func SimulateBootstrap(spot, target float64, returns []float64, sessions int,
+	conf SimulationConf) (HitProbability, error) {
+	if err := validateSimulation(spot, target, sessions); err != nil {
+		return HitProbability{}, err
+	}
+	if len(returns) == 0 {
+		return HitProbability{}, errors.New("bootstrap needs at least one historical return")
+	}
+	return simulatePaths("bootstrap", spot, target, sessions, conf, func(rng *rand.Rand) float64 {
+		return returns[rng.IntN(len(returns))]
+	}), nil
+}
+
+// SimulateTargetHit runs both simulators from the latest close in candles over the ticker's sessions until the by
+// date. The GBM volatility is the realized volatility of all the candles and the bootstrap draws from their daily log
+// returns.
+func SimulateTargetHit(candles map[int64]SingleStockCandle, ticker string, target float64, by time.Time,
+	conf SimulationConf) (results []HitProbability, err error) {
+	var dateKeys []int64
+	for dateKey := range candles {
+		dateKeys = append(dateKeys, dateKey)
+	}
+	if len(dateKeys) < 3 {
+		return nil, errors.New("not enough price history to simulate")
+	}
+	sort.Slice(dateKeys, func(i, j int) bool { return dateKeys[i] < dateKeys[j] })
+	closes := make([]float64, len(dateKeys))
+	for i, dateKey := range dateKeys {
+		closes[i] = candles[dateKey].Close
+	}
+	spot := closes[len(closes)-1]
+	daysInYear := annualization(ticker)
+	sessions := TradingDays(AssetClassOf(ticker), time.UnixMilli(dateKeys[len(dateKeys)-1]), by)
+
+	gbm, err := SimulateGBM(spot, target, RealizedVolatility(closes, ticker), sessions, daysInYear, conf)
+	if err != nil {
+		return nil, err
+	}
+	bootstrap, err := SimulateBootstrap(spot, target, calculateDailyReturn(closes), sessions, conf)
+	if err != nil {
+		return nil, err
+	}
+	return []HitProbability{gbm, bootstrap}, nil
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulateGBM(t *testing.T) {
+	tests := []struct {
+		name       string
+		spot       float64
+		target     float64
+		vol        float64
+		sessions   int
+		conf       SimulationConf
+		wantTouch  float64
+		wantFinish float64
+		tolerance  float64
+	}{
+		{
+			name: "no volatility or drift never moves", spot: 100, target: 101, vol: 0, sessions: 30,
+			conf: SimulationConf{Paths: 100}, wantTouch: 0, wantFinish: 0,
+		},
+		{
+			name: "target at spot is touched immediately", spot: 100, target: 100, vol: 0, sessions: 30,
+			conf: SimulationConf{Paths: 100}, wantTouch: 1, wantFinish: 1,
+		},
+		{
+			name: "no volatility grows at the drift", spot: 100, target: 109, vol: 0, sessions: 252,
+			conf: SimulationConf{Paths: 100, Drift: 0.1}, wantTouch: 1, wantFinish: 1,
+		},
+		{
+			// P(S_T >= K) = N((ln(S/K) - sigma^2 T/2) / (sigma sqrt(T))) for a driftless price over one year.
+			name: "finish probability matches the lognormal closed form", spot: 100, target: 110, vol: 0.3,
+			sessions: 252, conf: SimulationConf{Paths: 20000, Seed: 7}, wantFinish: 0.31999935621715214,
+			wantTouch: -1, tolerance: 0.015,
+		},
+		{
+			name: "target below spot is reached from above", spot: 100, target: 90, vol: 0, sessions: 252,
+			conf: SimulationConf{Paths: 100, Drift: -0.2}, wantTouch: 1, wantFinish: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SimulateGBM(tt.spot, tt.target, tt.vol, tt.sessions, TRADINGDAYSPERYEAR, tt.conf)
+			if err != nil {
+				t.Fatalf("SimulateGBM() error = %v", err)
+			}
+			if math.Abs(got.FinishProbability-tt.wantFinish) > tt.tolerance {
+				t.Errorf("FinishProbability = %v, want %v", got.FinishProbability, tt.wantFinish)
+			}
+			if tt.wantTouch >= 0 && math.Abs(got.TouchProbability-tt.wantTouch) > tt.tolerance {
+				t.Errorf("TouchProbability = %v, want %v", got.TouchProbability, tt.wantTouch)
+			}
+			if got.TouchProbability < got.FinishProbability {
+				t.Errorf("every path finishing beyond the target touched it: touch %v finish %v",
+					got.TouchProbability, got.FinishProbability)
+			}
+			if got.Terminal["p5"] > got.Terminal["p50"] || got.Terminal["p50"] > got.Terminal["p95"] {
+				t.Errorf("terminal percentiles out of order: %v", got.Terminal)
+			}
+		})
+	}
+}
+
+func TestSimulateGBM_SeedReproducible(t *testing.T) {
+	conf := SimulationConf{Paths: 500, Seed: 42}
+	a, _ := SimulateGBM(100, 105, 0.4, 30, TRADINGDAYSPERYEAR, conf)
+	b, _ := SimulateGBM(100, 105, 0.4, 30, TRADINGDAYSPERYEAR, conf)
+	if a.TouchProbability != b.TouchProbability || a.Terminal["mean"] != b.Terminal["mean"] {
+		t.Errorf("same seed gave different results: %+v vs %+v", a, b)
+	}
+}
+
+func TestSimulateBootstrap(t *testing.T) {
+	// A single 1% return resamples to the same path every time: 10 sessions end at 100*e^0.1.
+	got, err := SimulateBootstrap(100, 105, []float64{0.01}, 10, SimulationConf{Paths: 50})
+	if err != nil {
+		t.Fatalf("SimulateBootstrap() error = %v", err)
+	}
+	if got.TouchProbability != 1 || got.FinishProbability != 1 {
+		t.Errorf("TouchProbability = %v, FinishProbability = %v, want 1 and 1",
+			got.TouchProbability, got.FinishProbability)
+	}
+	if want := 100 * math.Exp(0.1); math.Abs(got.Terminal["p50"]-want) > 1e-9 {
+		t.Errorf("median terminal price = %v, want %v", got.Terminal["p50"], want)
+	}
+
+	// A path that overshoots and falls back still counts as touching.
+	got, _ = SimulateBootstrap(100, 100.5, []float64{0.01, -0.01}, 2, SimulationConf{Paths: 2000, Seed: 3})
+	if got.TouchProbability <= got.FinishProbability {
+		t.Errorf("touch %v should exceed finish %v when paths can reverse", got.TouchProbability,
+			got.FinishProbability)
+	}
+}
+
+func TestSimulate_InvalidInputs(t *testing.T) {
+	if _, err := SimulateGBM(0, 100, 0.2, 10, TRADINGDAYSPERYEAR, SimulationConf{}); err == nil {
+		t.Error("expected error for zero spot")
+	}
+	if _, err := SimulateGBM(100, 110, -0.2, 10, TRADINGDAYSPERYEAR, SimulationConf{}); err == nil {
+		t.Error("expected error for negative volatility")
+	}
+	if _, err := SimulateGBM(100, 110, 0.2, 0, TRADINGDAYSPERYEAR, SimulationConf{}); err == nil {
+		t.Error("expected error for zero sessions")
+	}
+	if _, err := SimulateBootstrap(100, 110, nil, 10, SimulationConf{}); err == nil {
+		t.Error("expected error for no historical returns")
+	}
+}
+
+func TestSimulateTargetHit(t *testing.T) {
+	data := makeTestData("AAPL", 90)
+	by := time.Now().AddDate(0, 0, 14)
+	results, err := SimulateTargetHit(data["AAPL"], "AAPL", 110, by, SimulationConf{Paths: 200})
+	if err != nil {
+		t.Fatalf("SimulateTargetHit() error = %v", err)
+	}
+	if len(results) != 2 || results[0].Method != "gbm" || results[1].Method != "bootstrap" {
+		t.Fatalf("expected gbm and bootstrap results, got %+v", results)
+	}
+	// 14 calendar days is 14*252/365.24 sessions, starting from the latest close of 100.
+	for _, r := range results {
+		if r.Sessions != 10 || r.Spot != 100 || r.Paths != 200 {
+			t.Errorf("%s: Sessions = %d, Spot = %v, Paths = %d", r.Method, r.Sessions, r.Spot, r.Paths)
+		}
+	}
+
+	if _, err := SimulateTargetHit(data["AAPL"], "AAPL", 110, time.Now(), SimulationConf{}); err == nil {
+		t.Error("expected error when the by date is not after the latest close")
+	}
+}
@@ -0,0 +1,248 @@
+package pkg
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+)
+
+// CashFlow is money moving between the investor and a portfolio on Date: negative when invested and positive when
+// taken out.
+type CashFlow struct {
+	Date   time.Time `json:"date"`
+	Amount float64   `json:"amount"`
+}
+
+// XIRR solves for the annual rate at which the flows' net present value is zero, discounting each by the calendar
+// years since the first flow. The flows need at least one investment and one withdrawal on different dates. The
+// rate is found by bisection on its logarithm, which cannot diverge on irregular flows and copes with the extreme
+// rates a few days' return annualizes to.
+func XIRR(flows []CashFlow) (float64, error) {
+	if len(flows) < 2 {
+		return 0, errors.New("xirr needs at least two cash flows")
+	}
+	first := flows[0].Date
+	var in, out bool
+	for _, flow := range flows {
+		if flow.Date.Before(first) {
+			first = flow.Date
+		}
+		in = in || flow.Amount < 0
+		out = out || flow.Amount > 0
+	}
+	if !in || !out {
+		return 0, errors.New("xirr needs both an investment and a withdrawal")
+	}
+	// npv discounts at the continuous rate, the log of one plus the annual rate
+	npv := func(rate float64) float64 {
+		var total float64
+		for _, flow := range flows {
+			total += flow.Amount * math.Exp(-rate*YearFraction("", first, flow.Date))
+		}
+		return total
+	}
+
+	// Bracket the root, widening both bounds until the value changes sign
+	lo, hi := -1.0, 1.0
+	for (npv(lo) > 0) == (npv(hi) > 0) {
+		if hi > 700 {
+			return 0, errors.New("xirr found no rate that zeroes the cash flows")
+		}
+		lo, hi = lo*2, hi*2
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if (npv(lo) > 0) != (npv(mid) > 0) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return math.Expm1((lo + hi) / 2), nil
+}
+
+// PeriodReturn is the performance of the portfolio, or of one Ticker, over a period. Invested is what went in, at
+// cost including fees, and Withdrawn what came out as sale proceeds and dividends, less fees charged outside of
+// trades. TimeWeighted links the daily returns, so it measures the holdings regardless of when money was added, and
+// MoneyWeighted is the XIRR of the flows, which weighs each day by the money at stake.
+type PeriodReturn struct {
+	Ticker                 string  `json:"ticker,omitempty"`
+	StartValue             float64 `json:"start-value"`
+	Invested               float64 `json:"invested"`
+	Withdrawn              float64 `json:"withdrawn"`
+	EndValue               float64 `json:"end-value"`
+	TimeWeighted           float64 `json:"time-weighted-return"`
+	AnnualizedTimeWeighted float64 `json:"annualized-time-weighted-return"`
+	MoneyWeighted          float64 `json:"money-weighted-return"`
+	Error                  string  `json:"error,omitempty"`
+}
+
+// PerformanceReport is the time- and money-weighted return of the portfolio and each position from From to To.
+type PerformanceReport struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Portfolio PeriodReturn   `json:"portfolio"`
+	Positions []PeriodReturn `json:"positions"`
+}
+
+// EvaluatePerformance replays the transactions against the daily closes in prices, keyed by normalized ticker, and
+// measures the portfolio and every ticker traded from the close of from to the close of to. A zero from starts the
+// day before the first transaction. Holdings are valued at their latest close, carried over days a ticker did not
+// trade, or at the last trade price before the first close. Each day's return treats the day's purchases as
+// invested at its open and its proceeds as taken out at its close. Annualizing counts calendar days.
+func EvaluatePerformance(transactions []Transaction, prices map[string]map[int64]SingleStockCandle, from,
+	to time.Time) (PerformanceReport, error) {
+	if len(transactions) == 0 {
+		return PerformanceReport{}, errors.New("no transactions")
+	}
+	sorted := append([]Transaction(nil), transactions...)
+	SortTransactions(sorted)
+	for i := range sorted {
+		sorted[i].Ticker = NormalizeTicker(sorted[i].Ticker)
+	}
+	if from.IsZero() {
+		from = truncateToDay(sorted[0].Date).AddDate(0, 0, -1)
+	}
+	if !to.After(from) {
+		return PerformanceReport{}, errors.New("the period must end after it starts")
+	}
+	closes := map[string]map[int64]float64{}
+	for ticker, candles := range prices {
+		byDay := make(map[int64]float64, len(candles))
+		for _, c := range candles {
+			byDay[truncateToDay(c.Timestamp).UnixMilli()] = c.Close
+		}
+		closes[NormalizeTicker(ticker)] = byDay
+	}
+
+	report := PerformanceReport{From: from, To: to, Portfolio: periodReturn(sorted, closes, from, to)}
+	var tickers []string
+	for _, tx := range sorted {
+		if tx.Ticker != "" && !slices.Contains(tickers, tx.Ticker) {
+			tickers = append(tickers, tx.Ticker)
+		}
+	}
+	slices.Sort(tickers)
+	for _, ticker := range tickers {
+		var own []Transaction
+		for _, tx := range sorted {
+			if tx.Ticker == ticker {
+				own = append(own, tx)
+			}
+		}
+		position := periodReturn(own, map[string]map[int64]float64{ticker: closes[ticker]}, from, to)
+		position.Ticker = ticker
+		report.Positions = append(report.Positions, position)
+	}
+	return report, nil
+}
+
+// periodReturn measures the sorted transactions over the period, valuing them at closes keyed by ticker and UTC day.
+func periodReturn(transactions []Transaction, closes map[string]map[int64]float64, from, to time.Time) PeriodReturn {
+	start, end := truncateToDay(from).UnixMilli(), truncateToDay(to).UnixMilli()
+	var days []int64
+	for _, tx := range transactions {
+		days = append(days, truncateToDay(tx.Date).UnixMilli())
+	}
+	for _, byDay := range closes {
+		for day := range byDay {
+			days = append(days, day)
+		}
+	}
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	shares, price := map[string]float64{}, map[string]float64{}
+	value := func() float64 {
+		var total float64
+		for ticker, held := range shares {
+			total += held * price[ticker]
+		}
+		return total
+	}
+	result := PeriodReturn{}
+	var flows []CashFlow
+	growth, linked := 1.0, false
+	next := 0
+	for _, day := range days {
+		if day > end {
+			break
+		}
+		var invested, withdrawn float64
+		for ; next < len(transactions) && truncateToDay(transactions[next].Date).UnixMilli() == day; next++ {
+			tx := transactions[next]
+			switch tx.Type {
+			case TXBUY:
+				shares[tx.Ticker] += tx.Quantity
+				price[tx.Ticker] = tx.Price
+				invested += tx.Quantity*tx.Price + tx.Fees
+			case TXSELL:
+				shares[tx.Ticker] -= tx.Quantity
+				price[tx.Ticker] = tx.Price
+				withdrawn += tx.Quantity*tx.Price - tx.Fees
+			case TXDIVIDEND:
+				withdrawn += tx.Amount
+			case TXFEE:
+				withdrawn -= tx.Amount
+			case TXSPLIT:
+				held := shares[tx.Ticker]
+				ratio := tx.Ratio
+				if ratio == 0 && held > 0 {
+					ratio = (held + tx.Quantity) / held
+				}
+				if ratio > 0 {
+					shares[tx.Ticker] = held * ratio
+					price[tx.Ticker] /= ratio
+				}
+			}
+		}
+		for ticker, byDay := range closes {
+			if c, ok := byDay[day]; ok && c > 0 {
+				price[ticker] = c
+			}
+		}
+		current := value()
+		if day <= start {
+			result.StartValue = current
+			continue
+		}
+		previous := result.EndValue
+		if !linked {
+			previous = result.StartValue
+			if previous > 0 {
+				flows = append(flows, CashFlow{Date: truncateToDay(from), Amount: -previous})
+			}
+		}
+		if base := previous + invested; base > 0 {
+			growth *= (current + withdrawn) / base
+		}
+		linked = true
+		result.Invested += invested
+		result.Withdrawn += withdrawn
+		result.EndValue = current
+		if net := withdrawn - invested; net != 0 {
+			flows = append(flows, CashFlow{Date: time.UnixMilli(day).UTC(), Amount: net})
+		}
+	}
+	if !linked {
+		result.EndValue = result.StartValue
+		result.Error = "no activity or prices in the period"
+		return result
+	}
+	if result.EndValue > 0 {
+		flows = append(flows, CashFlow{Date: truncateToDay(to), Amount: result.EndValue})
+	}
+
+	result.TimeWeighted = growth - 1
+	if years := YearFraction("", from, to); years > 0 && growth > 0 {
+		result.AnnualizedTimeWeighted = math.Pow(growth, 1/years) - 1
+	}
+	rate, err := XIRR(flows)
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.MoneyWeighted = rate
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestXIRR(t *testing.T) {
+	flows := []CashFlow{{Date: ledgerDate(2024, 1, 1), Amount: -1000}, {Date: ledgerDate(2025, 1, 1), Amount: 1100}}
+	got, err := XIRR(flows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024 is a leap year, so the year between the flows is 366 calendar days.
+	if want := math.Pow(1.1, YEAR/366) - 1; math.Abs(got-want) > 1e-6 {
+		t.Errorf("XIRR() = %v, want %v", got, want)
+	}
+
+	flows = []CashFlow{
+		{Date: ledgerDate(2024, 1, 1), Amount: -1000},
+		{Date: ledgerDate(2024, 7, 1), Amount: -500},
+		{Date: ledgerDate(2025, 3, 1), Amount: 200},
+		{Date: ledgerDate(2026, 1, 1), Amount: 900},
+	}
+	if got, err = XIRR(flows); err != nil || got >= 0 {
+		t.Fatalf("XIRR() = %v, %v, want a loss", got, err)
+	}
+	var npv float64
+	for _, flow := range flows {
+		npv += flow.Amount / math.Pow(1+got, YearFraction("", flows[0].Date, flow.Date))
+	}
+	if math.Abs(npv) > 1e-6 {
+		t.Errorf("net present value at %v = %v, want 0", got, npv)
+	}
+
+	for _, bad := range [][]CashFlow{
+		nil,
+		{{Date: ledgerDate(2024, 1, 1), Amount: -1000}, {Date: ledgerDate(2025, 1, 1), Amount: -100}},
+	} {
+		if _, err = XIRR(bad); err == nil {
+			t.Errorf("XIRR(%v) expected an error", bad)
+		}
+	}
+}
+
+func TestEvaluatePerformance(t *testing.T) {
+	transactions := []Transaction{
+		{Date: ledgerDate(2024, 1, 2), Ticker: "AAA", Type: TXBUY, Quantity: 10, Price: 10},
+		{Date: ledgerDate(2024, 1, 4), Ticker: "aaa", Type: TXBUY, Quantity: 10, Price: 11},
+		{Date: ledgerDate(2024, 1, 5), Ticker: "AAA", Type: TXSELL, Quantity: 20, Price: 9},
+		{Date: ledgerDate(2024, 1, 3), Ticker: "BBB", Type: TXBUY, Quantity: 1, Price: 50},
+		{Date: ledgerDate(2024, 1, 4), Type: TXFEE, Amount: 5},
+	}
+	candles := func(closes map[int]float64) map[int64]SingleStockCandle {
+		series := map[int64]SingleStockCandle{}
+		for day, c := range closes {
+			date := ledgerDate(2024, 1, day)
+			series[date.UnixMilli()] = SingleStockCandle{Timestamp: date, Close: c}
+		}
+		return series
+	}
+	prices := map[string]map[int64]SingleStockCandle{
+		"AAA": candles(map[int]float64{2: 10, 3: 11, 4: 12, 5: 9}),
+		// BBB has no close until the 4th, so it is held at its purchase price on the 3rd.
+		"BBB": candles(map[int]float64{4: 55, 5: 60}),
+	}
+	report, err := EvaluatePerformance(transactions, prices, time.Time{}, ledgerDate(2024, 1, 5))
+	if err != nil {
+		t.Fatalf("EvaluatePerformance() error = %v", err)
+	}
+	if !report.From.Equal(ledgerDate(2024, 1, 1)) {
+		t.Errorf("From = %v, want the day before the first transaction", report.From)
+	}
+	// The 4th's fee is a withdrawal of -5 and the 5th's sale takes 180 out.
+	portfolio := report.Portfolio
+	if want := (160.0/150)*(290.0/270)*(240.0/295) - 1; math.Abs(portfolio.TimeWeighted-want) > 1e-9 {
+		t.Errorf("portfolio TimeWeighted = %v, want %v", portfolio.TimeWeighted, want)
+	}
+	if portfolio.Invested != 260 || portfolio.Withdrawn != 175 || portfolio.EndValue != 60 || portfolio.Error != "" {
+		t.Errorf("portfolio = %+v, want 260 invested, 175 withdrawn, and 60 held", portfolio)
+	}
+	if portfolio.MoneyWeighted >= 0 || portfolio.AnnualizedTimeWeighted >= portfolio.TimeWeighted {
+		t.Errorf("portfolio = %+v, want an annualized loss", portfolio)
+	}
+
+	if len(report.Positions) != 2 {
+		t.Fatalf("got %d positions, want 2", len(report.Positions))
+	}
+	aaa, bbb := report.Positions[0], report.Positions[1]
+	if aaa.Ticker != "AAA" || math.Abs(aaa.TimeWeighted+0.1) > 1e-9 || aaa.EndValue != 0 {
+		t.Errorf("AAA = %+v, want a 10%% time-weighted loss", aaa)
+	}
+	if bbb.Ticker != "BBB" || math.Abs(bbb.TimeWeighted-0.2) > 1e-9 || bbb.MoneyWeighted <= 0 {
+		t.Errorf("BBB = %+v, want a 20%% time-weighted gain", bbb)
+	}
+
+	// Starting later begins from the value at the start date's close.
+	report, err = EvaluatePerformance(transactions, prices, ledgerDate(2024, 1, 3), ledgerDate(2024, 1, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Portfolio.StartValue != 160 || report.Portfolio.Invested != 110 {
+		t.Errorf("portfolio = %+v, want a start value of 160 and 110 invested", report.Portfolio)
+	}
+	if want := (290.0/270)*(240.0/295) - 1; math.Abs(report.Portfolio.TimeWeighted-want) > 1e-9 {
+		t.Errorf("portfolio TimeWeighted = %v, want %v", report.Portfolio.TimeWeighted, want)
+	}
+
+	if _, err = EvaluatePerformance(nil, prices, time.Time{}, ledgerDate(2024, 1, 5)); err == nil {
+		t.Error("EvaluatePerformance() expected an error without transactions")
+	}
+	if _, err = EvaluatePerformance(transactions, prices, ledgerDate(2024, 1, 5), ledgerDate(2024, 1, 5)); err == nil {
+		t.Error("EvaluatePerformance() expected an error for an empty period")
+	}
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pipeline runs analysis steps over a set of candles and returns the annotated candles. Steps only look back from
+// each day, so a pipeline run on history truncated at a day gives that day the values it had at the time.
+type Pipeline func(stockPrices map[string]map[int64]SingleStockCandle) map[string]map[int64]SingleStockCandle
+
+// RangePipeline computes the realized volatility, risk ranges, and trend directions for every duration, using the
+// range and trend settings in conf. It is the subset of the command pipelines the range strategies read.
+func RangePipeline(conf StockDataConf) Pipeline {
+	return func(stockPrices map[string]map[int64]SingleStockCandle) map[string]map[int64]SingleStockCandle {
+		durations := []int{SHORTDURATION, MEDIUMDURATION, LONGDURATION}
+		for _, d := range durations {
+			stockPrices = StoreRealizedVols(stockPrices, d)
+			stockPrices = GetAvgVolume(stockPrices, d)
+			stockPrices = CalculateAvgVolumeRatios(stockPrices, d)
+			stockPrices = CalculateRiskRanges(stockPrices, d)
+			stockPrices = CalculateVolumeAdjustedRiskRanges(stockPrices, d)
+			stockPrices = GetProbAdjRiskRanges(stockPrices, d, conf.RangeAdjustment)
+			stockPrices = CalculateQuantileRiskRanges(stockPrices, d, conf.RangeCoverage)
+			if conf.Trend.UseRegression {
+				stockPrices = GetLinearRegressionSlope(stockPrices, d, conf.Trend.LogPrices, false)
+			}
+		}
+		stockPrices = GetSimpleSlopes(stockPrices, false)
+		return CalculateTrendDirections(stockPrices, conf.Trend, false)
+	}
+}
+
+// TruncateAsOf returns a copy of stockPrices holding only the candles dated on or before asOf's UTC day, the history
+// a run at the close of asOf would have fetched.
+func TruncateAsOf(stockPrices map[string]map[int64]SingleStockCandle,
+	asOf time.Time) map[string]map[int64]SingleStockCandle {
+	cutoff := truncateToDay(asOf).AddDate(0, 0, 1).UnixMilli()
+	truncated := make(map[string]map[int64]SingleStockCandle, len(stockPrices))
+	for ticker, candles := range stockPrices {
+		truncated[ticker] = map[int64]SingleStockCandle{}
+		for date, c := range candles {
+			if date < cutoff {
+				truncated[ticker][date] = c
+			}
+		}
+	}
+	return truncated
+}
+
+// AnalyzeAsOf runs pipeline over the candles known at the close of asOf, so the latest analyzed day of each ticker
+// carries what a report run on asOf would have shown. Tickers without a candle on or before asOf are an error.
+func AnalyzeAsOf(stockPrices map[string]map[int64]SingleStockCandle, asOf time.Time,
+	pipeline Pipeline) (map[string]map[int64]SingleStockCandle, error) {
+	truncated := TruncateAsOf(stockPrices, asOf)
+	for ticker, candles := range truncated {
+		if len(candles) == 0 {
+			return nil, fmt.Errorf("no candles for %s on or before %s", ticker, asOf.Format(time.DateOnly))
+		}
+	}
+	return pipeline(truncated), nil
+}
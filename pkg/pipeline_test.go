@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTruncateAsOf(t *testing.T) {
+	day := func(d, hour int) int64 { return time.Date(2026, 9, d, hour, 0, 0, 0, time.UTC).UnixMilli() }
+	data := map[string]map[int64]SingleStockCandle{
+		"AAA": {day(1, 4): {Close: 1}, day(2, 4): {Close: 2}, day(3, 4): {Close: 3}},
+	}
+	got := TruncateAsOf(data, time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC))
+	if len(got["AAA"]) != 2 {
+		t.Fatalf("got %d candles, want the two on or before the as-of day", len(got["AAA"]))
+	}
+	if _, ok := got["AAA"][day(2, 4)]; !ok {
+		t.Error("a candle stamped later on the as-of day should be kept")
+	}
+	if len(data["AAA"]) != 3 {
+		t.Error("the input candles should not be modified")
+	}
+}
+
+func TestAnalyzeAsOf(t *testing.T) {
+	data := makeTestData("AAA", 300)
+	dateKeys := sortedDateKeys(data["AAA"])
+	asOfKey := dateKeys[len(dateKeys)-60]
+	asOf := time.UnixMilli(asOfKey)
+
+	copyData := func() map[string]map[int64]SingleStockCandle {
+		c := map[string]map[int64]SingleStockCandle{"AAA": {}}
+		for date, candle := range data["AAA"] {
+			c["AAA"][date] = candle
+		}
+		return c
+	}
+	pipeline := RangePipeline(StockDataConf{})
+	got, err := AnalyzeAsOf(copyData(), asOf, pipeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := sortedDateKeys(got["AAA"])
+	if last := latest[len(latest)-1]; last != asOfKey {
+		t.Fatalf("latest analyzed day = %v, want the as-of day %v", time.UnixMilli(last), asOf)
+	}
+	full := pipeline(copyData())["AAA"][asOfKey]
+	day := got["AAA"][asOfKey]
+	if day.RealizedVolatilityShort != full.RealizedVolatilityShort ||
+		day.PTradeRangeAdj["high"] != full.PTradeRangeAdj["high"] ||
+		day.TrendDirection != full.TrendDirection || day.SlopeMedDuration != full.SlopeMedDuration {
+		t.Errorf("as-of analysis %+v differs from the full-history analysis of the same day %+v", day, full)
+	}
+
+	if _, err = AnalyzeAsOf(copyData(), asOf.AddDate(-2, 0, 0), pipeline); err == nil {
+		t.Error("an as-of date before any candle should be an error")
+	}
+}
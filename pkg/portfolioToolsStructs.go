@@ -12,15 +12,61 @@ type Vapr struct {
 }
 
 type StockDataConf struct {
-	PolygonAPIToken string   `json:"polygon-api-key"`
-	AlpacaAPIKey    string   `json:"alpaca-api-key"`
-	AlpacaSecretKey string   `json:"alpaca-secret-key"`
-	RangeAdjustment float64  `json:"probable-range-adj"`
-	EmailAddress    string   `json:"email-address"`
-	EmailPassword   string   `json:"email-password"`
-	Hostname        string   `json:"hostname"`
-	Port            int      `json:"port"`
-	MailTo          []string `json:"mail-to"`
+	PolygonAPIToken string                   `json:"polygon-api-key"`
+	AlpacaAPIKey    string                   `json:"alpaca-api-key"`
+	AlpacaSecretKey string                   `json:"alpaca-secret-key"`
+	RangeAdjustment float64                  `json:"probable-range-adj"`
+	RangeModel      string                   `json:"range-model"`
+	RangeCoverage   float64                  `json:"range-coverage"`
+	TickerParams    string                   `json:"ticker-params"`
+	EmailAddress    string                   `json:"email-address"`
+	EmailPassword   string                   `json:"email-password"`
+	Hostname        string                   `json:"hostname"`
+	Port            int                      `json:"port"`
+	MailTo          []string                 `json:"mail-to"`
+	Trend           TrendConf                `json:"trend"`
+	Indicators      []string                 `json:"indicators"`
+	Benchmark       string                   `json:"benchmark"`
+	RiskFreeRate    float64                  `json:"risk-free-rate"`
+	VaR             VaRConf                  `json:"var"`
+	Returns         ReturnConf               `json:"returns"`
+	Baskets         map[string]BasketConf    `json:"baskets"`
+	Sizing          SizingConf               `json:"sizing"`
+	Importers       map[string]ImportMapping `json:"importers"`
+}
+
+// TrendConf controls how CalculateTrendDirections derives the per-day signal it labels. With UseRegression unset the
+// raw price delta from GetSimpleSlopes is used; otherwise a day counts as up or down only when its regression slope is
+// significant at MinTStat (default 2.0). Trade, Trend, and Tail set the labeling rule for each duration.
+type TrendConf struct {
+	UseRegression bool          `json:"use-regression"`
+	LogPrices     bool          `json:"log-prices"`
+	MinTStat      float64       `json:"min-t-stat"`
+	Trade         DirectionRule `json:"trade"`
+	Trend         DirectionRule `json:"trend"`
+	Tail          DirectionRule `json:"tail"`
+}
+
+// DirectionRule is how one duration turns its daily signals into a direction. Confirmation is the number of
+// consecutive up or down sessions needed to call Bullish or Bearish (default 3). DeadBand is the smallest move, as a
+// fraction of the close, that counts as up or down; smaller moves count as flat. With Hysteresis set, a Bullish or
+// Bearish label holds until that many consecutive sessions move the opposite way, instead of dropping to Neutral as
+// soon as one session breaks the run.
+type DirectionRule struct {
+	Confirmation int     `json:"confirmation"`
+	DeadBand     float64 `json:"dead-band"`
+	Hysteresis   int     `json:"hysteresis"`
+}
+
+// RegressionFit is an ordinary least squares fit of closes against the session index. Slope is in price (or log price)
+// per session. StdErr and TStat describe the slope; N is the number of points in the fit.
+type RegressionFit struct {
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+	RSquared  float64 `json:"r-squared"`
+	StdErr    float64 `json:"std-err"`
+	TStat     float64 `json:"t-stat"`
+	N         int     `json:"n"`
 }
 
 // OHLC is a struct that contains the Open, High, Low, and Close values from a range of times for a specific ticker
@@ -69,12 +115,23 @@ type SingleStockCandle struct {
 	SlopeShortDuration       float64            `json:"trade-slope"`
 	SlopeMedDuration         float64            `json:"trend-slope"`
 	SlopeLongDuration        float64            `json:"tail-slope"`
+	SlopePctShort            float64            `json:"trade-slope-pct"`
+	SlopePctMed              float64            `json:"trend-slope-pct"`
+	SlopePctLong             float64            `json:"tail-slope-pct"`
+	SlopeVolShort            float64            `json:"trade-slope-vol"`
+	SlopeVolMed              float64            `json:"trend-slope-vol"`
+	SlopeVolLong             float64            `json:"tail-slope-vol"`
+	TrendStrength            float64            `json:"trend-strength"`
 	SlopeShortValid          bool               `json:"-"`
 	SlopeMedValid            bool               `json:"-"`
 	SlopeLongValid           bool               `json:"-"`
+	RegressionShort          RegressionFit      `json:"trade-regression"`
+	RegressionMed            RegressionFit      `json:"trend-regression"`
+	RegressionLong           RegressionFit      `json:"tail-regression"`
 	TradeDirection           string             `json:"trade-direction"`
 	TrendDirection           string             `json:"trend-direction"`
 	TailDirection            string             `json:"tail-direction"`
+	Regime                   string             `json:"regime"`
 	RVolHighShort            float64            `json:"short-rvol-high"`
 	RVolLowShort             float64            `json:"short-rvol-low"`
 	RVolHighMed              float64            `json:"med-rvol-high"`
@@ -93,6 +150,21 @@ type SingleStockCandle struct {
 	RealizedVolAccelShort    float64            `json:"short-rvol-accel"`
 	RealizedVolAccelMed      float64            `json:"med-rvol-accel"`
 	RealizedVolAccelLong     float64            `json:"long-rvol-accel"`
+	BetaShort                float64            `json:"short-beta"`
+	BetaMed                  float64            `json:"med-beta"`
+	BetaLong                 float64            `json:"long-beta"`
+	BenchCorrShort           float64            `json:"short-benchmark-corr"`
+	BenchCorrMed             float64            `json:"med-benchmark-corr"`
+	BenchCorrLong            float64            `json:"long-benchmark-corr"`
+	DrawdownShort            Drawdown           `json:"trade-drawdown"`
+	DrawdownMed              Drawdown           `json:"trend-drawdown"`
+	DrawdownLong             Drawdown           `json:"tail-drawdown"`
+	RiskAdjustedShort        RiskRatios         `json:"trade-risk-adjusted"`
+	RiskAdjustedMed          RiskRatios         `json:"trend-risk-adjusted"`
+	RiskAdjustedLong         RiskRatios         `json:"tail-risk-adjusted"`
+	VaRShort                 []VaR              `json:"trade-var"`
+	VaRMed                   []VaR              `json:"trend-var"`
+	VaRLong                  []VaR              `json:"tail-var"`
 	TradeRange               map[string]float64 `json:"trade-range"`
 	TrendRange               map[string]float64 `json:"trend-range"`
 	TailRange                map[string]float64 `json:"tail-range"`
@@ -105,6 +177,10 @@ type SingleStockCandle struct {
 	PTradeRangeAdj           map[string]float64 `json:"prob-trade-range-vadj"`
 	PTrendRangeAdj           map[string]float64 `json:"prob-trend-range-vadj"`
 	PTailRangeAdj            map[string]float64 `json:"prob-tail-range-vadj"`
+	QTradeRange              map[string]float64 `json:"quantile-trade-range"`
+	QTrendRange              map[string]float64 `json:"quantile-trend-range"`
+	QTailRange               map[string]float64 `json:"quantile-tail-range"`
+	Indicators               map[string]float64 `json:"indicators,omitempty"`
 }
 
 type condensedStockCandle struct {
@@ -117,6 +193,10 @@ type condensedStockCandle struct {
 	AvgVolumeShort      float64            `json:"short-avg-volume"`
 	AvgVolumeRatioShort float64            `json:"short-avg-volume-ratio"`
 	TradeSlope          float64            `json:"trade-slope"`
+	TradeRegression     RegressionFit      `json:"trade-regression"`
+	TradeDrawdown       Drawdown           `json:"trade-drawdown"`
+	TradeRiskAdjusted   RiskRatios         `json:"trade-risk-adjusted"`
+	TradeVaR            []VaR              `json:"trade-var"`
 	RVolShort           float64            `json:"rvol-short"`
 	RVolShortVel        float64            `json:"rvol-short-vel"`
 	RVolShortAccel      float64            `json:"rvol-short-accel"`
@@ -125,9 +205,14 @@ type condensedStockCandle struct {
 	RVolLowShort        float64            `json:"short-day-rvol-low"`
 	TradeRangeAdj       map[string]float64 `json:"trade-range-vadj"`
 	PtradeRangeAdj      map[string]float64 `json:"prob-trade-range-vadj"`
+	QTradeRange         map[string]float64 `json:"quantile-trade-range"`
 	AvgVolumeMed        float64            `json:"med-avg-volume"`
 	AvgVolumeRatioMed   float64            `json:"med-avg-volume-ratio"`
 	TrendSlope          float64            `json:"trend-slope"`
+	TrendRegression     RegressionFit      `json:"trend-regression"`
+	TrendDrawdown       Drawdown           `json:"trend-drawdown"`
+	TrendRiskAdjusted   RiskRatios         `json:"trend-risk-adjusted"`
+	TrendVaR            []VaR              `json:"trend-var"`
 	RVolMed             float64            `json:"rvol-med"`
 	RVolMedVel          float64            `json:"rvol-med-vel"`
 	RVolMedAccel        float64            `json:"rvol-med-accel"`
@@ -136,12 +221,18 @@ type condensedStockCandle struct {
 	RVolLowMed          float64            `json:"med-day-rvol-low"`
 	TrendRangeAdj       map[string]float64 `json:"trend-range-vadj"`
 	PTrendRangeAdj      map[string]float64 `json:"prob-trend-range-vadj"`
+	QTrendRange         map[string]float64 `json:"quantile-trend-range"`
 	AvgVolumeLong       float64            `json:"long-avg-volume"`
 	AvgVolumeRatioLong  float64            `json:"long-avg-volume-ratio"`
 	TailSlope           float64            `json:"tail-slope"`
+	TailRegression      RegressionFit      `json:"tail-regression"`
+	TailDrawdown        Drawdown           `json:"tail-drawdown"`
+	TailRiskAdjusted    RiskRatios         `json:"tail-risk-adjusted"`
+	TailVaR             []VaR              `json:"tail-var"`
 	TradeDirection      string             `json:"trade-direction"`
 	TrendDirection      string             `json:"trend-direction"`
 	TailDirection       string             `json:"tail-direction"`
+	Regime              string             `json:"regime"`
 	RVolLong            float64            `json:"rvol-long"`
 	RVolLongVel         float64            `json:"rvol-long-vel"`
 	RVolLongAccel       float64            `json:"rvol-long-accel"`
@@ -150,20 +241,60 @@ type condensedStockCandle struct {
 	RVolLowLong         float64            `json:"long-day-rvol-low"`
 	TailRangeAdj        map[string]float64 `json:"tail-range-vadj"`
 	PTailRangeAdj       map[string]float64 `json:"prob-tail-range-vadj"`
+	QTailRange          map[string]float64 `json:"quantile-tail-range"`
+	Indicators          map[string]float64 `json:"indicators,omitempty"`
 }
 
 type CondensedRangesJSON struct {
-	Ticker         string    `json:"ticker"`
-	Close          float64   `json:"close"`
-	AvgVolRatio    float64   `json:"avg_vol_ratio"`
-	RVolPercent    float64   `json:"rvol_percent"`
-	RiskRangeHigh  float64   `json:"rr_high"`
-	RiskRangeLow   float64   `json:"rr_low"`
-	TradeSlope     float64   `json:"trade-slope"`
-	TrendSlope     float64   `json:"trend-slope"`
-	TailSlope      float64   `json:"tail-slope"`
-	TradeDirection string    `json:"trade-direction"`
-	TrendDirection string    `json:"trend-direction"`
-	TailDirection  string    `json:"tail-direction"`
-	Timestamp      time.Time `json:"timestamp"`
+	Ticker         string             `json:"ticker"`
+	Close          float64            `json:"close"`
+	AvgVolRatio    float64            `json:"avg_vol_ratio"`
+	RVolPercent    float64            `json:"rvol_percent"`
+	RiskRangeHigh  float64            `json:"rr_high"`
+	RiskRangeLow   float64            `json:"rr_low"`
+	RangeCoverage  float64            `json:"rr_coverage,omitempty"`
+	TradeSlope     float64            `json:"trade-slope"`
+	TrendSlope     float64            `json:"trend-slope"`
+	TailSlope      float64            `json:"tail-slope"`
+	TradeSlopePct  float64            `json:"trade-slope-pct"`
+	TrendSlopePct  float64            `json:"trend-slope-pct"`
+	TailSlopePct   float64            `json:"tail-slope-pct"`
+	TradeSlopeVol  float64            `json:"trade-slope-vol"`
+	TrendSlopeVol  float64            `json:"trend-slope-vol"`
+	TailSlopeVol   float64            `json:"tail-slope-vol"`
+	TrendStrength  float64            `json:"trend-strength"`
+	TradeDirection string             `json:"trade-direction"`
+	TrendDirection string             `json:"trend-direction"`
+	TailDirection  string             `json:"tail-direction"`
+	Regime         string             `json:"regime"`
+	Timestamp      time.Time          `json:"timestamp"`
+	Indicators     map[string]float64 `json:"indicators,omitempty"`
+	Benchmark      string             `json:"benchmark,omitempty"`
+	TradeBeta      float64            `json:"trade-beta,omitempty"`
+	TrendBeta      float64            `json:"trend-beta,omitempty"`
+	TailBeta       float64            `json:"tail-beta,omitempty"`
+	TradeCorr      float64            `json:"trade-benchmark-corr,omitempty"`
+	TrendCorr      float64            `json:"trend-benchmark-corr,omitempty"`
+	TailCorr       float64            `json:"tail-benchmark-corr,omitempty"`
+	TradeDrawdown  Drawdown           `json:"trade-drawdown"`
+	TrendDrawdown  Drawdown           `json:"trend-drawdown"`
+	TailDrawdown   Drawdown           `json:"tail-drawdown"`
+	TradeRiskAdj   RiskRatios         `json:"trade-risk-adjusted"`
+	TrendRiskAdj   RiskRatios         `json:"trend-risk-adjusted"`
+	TailRiskAdj    RiskRatios         `json:"tail-risk-adjusted"`
+	TradeVaR       []VaR              `json:"trade-var,omitempty"`
+	TrendVaR       []VaR              `json:"trend-var,omitempty"`
+	TailVaR        []VaR              `json:"tail-var,omitempty"`
+	Position       *PositionSize      `json:"position-size,omitempty"`
+}
+
+// BatchReport is the document batchStocks writes: the condensed ranges per ticker plus any watchlist-wide sections.
+// AsOf is the past date the report was reproduced for, when it was run with -asof or -end.
+type BatchReport struct {
+	AsOf        string                         `json:"as-of,omitempty"`
+	Tickers     map[string]CondensedRangesJSON `json:"tickers"`
+	Correlation *CorrelationMatrix             `json:"correlation,omitempty"`
+	Portfolio   *PortfolioVaR                  `json:"portfolio-var,omitempty"`
+	Calibration *CalibrationReport             `json:"calibration,omitempty"`
+	Changes     []DirectionChange              `json:"changes-since-last-run,omitempty"`
 }
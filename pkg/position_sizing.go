@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+	"github.com/khrystoph/portfoliotools/pkg/indicators"
+)
+
+// Stop placement methods accepted by SizingConf.Method.
+const (
+	STOPMETHODRANGE      = "range"
+	STOPMETHODVOLATILITY = "volatility"
+	STOPMETHODATR        = "atr"
+)
+
+// SizingConf sets the account and risk budget position sizes are derived from. RiskPercent is the fraction of Equity
+// a trade may lose if stopped out, e.g. 0.01 for 1%. Method places the stop: range (the default) at the far side of
+// the probability-adjusted trade range, volatility VolMultiple trade-duration standard deviations from the entry, or
+// atr ATRMultiple average true ranges of ATRPeriod sessions from the entry.
+type SizingConf struct {
+	Equity      float64 `json:"equity"`
+	RiskPercent float64 `json:"risk-percent"`
+	Method      string  `json:"method"`
+	VolMultiple float64 `json:"vol-multiple"`
+	ATRPeriod   int     `json:"atr-period"`
+	ATRMultiple float64 `json:"atr-multiple"`
+}
+
+// Validate reports a conf without equity or with a risk budget outside (0, 1], or with an unknown method.
+func (c SizingConf) Validate() error {
+	if c.Equity <= 0 {
+		return fmt.Errorf("equity must be greater than zero, got %g", c.Equity)
+	}
+	if c.RiskPercent <= 0 || c.RiskPercent > 1 {
+		return fmt.Errorf("risk-percent must be in (0, 1], got %g", c.RiskPercent)
+	}
+	switch c.Method {
+	case "", STOPMETHODRANGE, STOPMETHODVOLATILITY, STOPMETHODATR:
+	default:
+		return fmt.Errorf("unknown stop method %q", c.Method)
+	}
+	if c.VolMultiple < 0 || c.ATRMultiple < 0 || c.ATRPeriod < 0 {
+		return errors.New("vol-multiple, atr-multiple, and atr-period must not be negative")
+	}
+	return nil
+}
+
+// withDefaults fills in the range method, one standard deviation, and a 2x 14-session ATR.
+func (c SizingConf) withDefaults() SizingConf {
+	if c.Method == "" {
+		c.Method = STOPMETHODRANGE
+	}
+	if c.VolMultiple == 0 {
+		c.VolMultiple = 1
+	}
+	if c.ATRPeriod == 0 {
+		c.ATRPeriod = 14
+	}
+	if c.ATRMultiple == 0 {
+		c.ATRMultiple = 2
+	}
+	return c
+}
+
+// PositionSize is a suggested position: how many shares risk the budget if the stop is hit, and what they cost.
+// Target is the opposite edge of the trade range, when the candle has one.
+type PositionSize struct {
+	Method        string  `json:"method"`
+	Short         bool    `json:"short,omitempty"`
+	Entry         float64 `json:"entry"`
+	Stop          float64 `json:"stop"`
+	Target        float64 `json:"target,omitempty"`
+	RiskPerShare  float64 `json:"risk-per-share"`
+	Shares        float64 `json:"shares"`
+	PositionValue float64 `json:"position-value"`
+	RiskAmount    float64 `json:"risk-amount"`
+}
+
+// SizePosition sizes a trade in ticker entered at entry, the latest close when zero, from the latest of the ticker's
+// candles. The range method reads PTradeRangeAdj, or TradeRangeAdj without one, and the volatility method
+// RealizedVolatilityShort, so the candles must have been through StoreRealizedVols and GetProbAdjRiskRanges for
+// SHORTDURATION; the atr method computes the ATR from the candles themselves. Shares are the risk budget divided by
+// the distance to the stop, capped so the position costs no more than the equity, and rounded down to whole shares
+// except for crypto.
+func SizePosition(candles map[int64]SingleStockCandle, ticker string, entry float64, isShort bool,
+	conf SizingConf) (PositionSize, error) {
+	if err := conf.Validate(); err != nil {
+		return PositionSize{}, err
+	}
+	conf = conf.withDefaults()
+	if len(candles) == 0 {
+		return PositionSize{}, fmt.Errorf("no candles for %s", ticker)
+	}
+	dates := sortedDateKeys(candles)
+	latest := candles[dates[len(dates)-1]]
+	if entry == 0 {
+		entry = latest.Close
+	}
+	if entry <= 0 {
+		return PositionSize{}, fmt.Errorf("entry for %s must be greater than zero", ticker)
+	}
+
+	size := PositionSize{Method: conf.Method, Short: isShort, Entry: entry}
+	direction := 1.0
+	if isShort {
+		direction = -1
+	}
+	switch conf.Method {
+	case STOPMETHODRANGE:
+		band := latest.PTradeRangeAdj
+		if len(band) == 0 {
+			band = latest.TradeRangeAdj
+		}
+		if len(band) == 0 {
+			return PositionSize{}, fmt.Errorf("no trade range for %s", ticker)
+		}
+		size.Stop, size.Target = band["low"], band["high"]
+		if isShort {
+			size.Stop, size.Target = band["high"], band["low"]
+		}
+	case STOPMETHODVOLATILITY:
+		if latest.RealizedVolatilityShort <= 0 {
+			return PositionSize{}, fmt.Errorf("no trade volatility for %s", ticker)
+		}
+		horizon := math.Sqrt(float64(durationSessions(SHORTDURATION, ticker, latest.Timestamp)) / annualization(ticker))
+		size.Stop = entry * (1 - direction*conf.VolMultiple*latest.RealizedVolatilityShort*horizon)
+	case STOPMETHODATR:
+		atr := latestATR(candles, dates, conf.ATRPeriod)
+		if math.IsNaN(atr) || atr <= 0 {
+			return PositionSize{}, fmt.Errorf("not enough history for a %d session ATR of %s", conf.ATRPeriod, ticker)
+		}
+		size.Stop = entry - direction*conf.ATRMultiple*atr
+	}
+	size.Stop = math.Max(size.Stop, 0)
+
+	size.RiskPerShare = direction * (entry - size.Stop)
+	if size.RiskPerShare <= 0 {
+		return PositionSize{}, fmt.Errorf("entry %.2f for %s is already past the stop at %.2f", entry, ticker, size.Stop)
+	}
+	shares := math.Min(conf.Equity*conf.RiskPercent/size.RiskPerShare, conf.Equity/entry)
+	if AssetClassOf(ticker) == store.AssetClassCrypto {
+		shares = math.Floor(shares*1e8) / 1e8
+	} else {
+		shares = math.Floor(shares)
+	}
+	size.Shares = shares
+	size.PositionValue = shares * entry
+	size.RiskAmount = shares * size.RiskPerShare
+	return size, nil
+}
+
+// latestATR is the average true range of the last of the oldest-first dates, or NaN while still inside the warm-up
+// period.
+func latestATR(candles map[int64]SingleStockCandle, dates []int64, period int) float64 {
+	series := indicators.Series{}
+	for _, date := range dates {
+		c := candles[date]
+		series.Open = append(series.Open, c.Open)
+		series.High = append(series.High, c.High)
+		series.Low = append(series.Low, c.Low)
+		series.Close = append(series.Close, c.Close)
+		series.Volume = append(series.Volume, c.Volume)
+	}
+	atr := indicators.ATR(series, period)
+	return atr[len(atr)-1]
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSizePosition(t *testing.T) {
+	candles := map[int64]SingleStockCandle{
+		1: {Close: 90},
+		2: {Close: 100, RealizedVolatilityShort: 0.2,
+			PTradeRangeAdj: map[string]float64{"low": 95, "high": 110}},
+	}
+	conf := SizingConf{Equity: 100000, RiskPercent: 0.01}
+	horizon := 0.2 * math.Sqrt(21.0/252)
+	tests := []struct {
+		name       string
+		ticker     string
+		entry      float64
+		isShort    bool
+		conf       SizingConf
+		wantStop   float64
+		wantShares float64
+	}{
+		{"range long at the close", "AAA", 0, false, conf, 95, 200},
+		{"range short", "AAA", 100, true, conf, 110, 100},
+		{"volatility long", "AAA", 100, false, SizingConf{Equity: 100000, RiskPercent: 0.01,
+			Method: STOPMETHODVOLATILITY}, 100 * (1 - horizon), math.Floor(1000 / (100 * horizon))},
+		{"capped at equity", "AAA", 0, false, SizingConf{Equity: 1000, RiskPercent: 1}, 95, 10},
+		{"crypto keeps fractional units", "X:BTCUSD", 0, false, SizingConf{Equity: 1000, RiskPercent: 0.013}, 95, 2.6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SizePosition(candles, tt.ticker, tt.entry, tt.isShort, tt.conf)
+			if err != nil {
+				t.Fatalf("SizePosition() error = %v", err)
+			}
+			if math.Abs(got.Stop-tt.wantStop) > 1e-9 {
+				t.Errorf("Stop = %v, want %v", got.Stop, tt.wantStop)
+			}
+			if got.Shares != tt.wantShares {
+				t.Errorf("Shares = %v, want %v", got.Shares, tt.wantShares)
+			}
+			if math.Abs(got.RiskAmount-got.Shares*got.RiskPerShare) > 1e-9 ||
+				math.Abs(got.PositionValue-got.Shares*got.Entry) > 1e-9 {
+				t.Errorf("RiskAmount %v or PositionValue %v inconsistent with %v shares", got.RiskAmount,
+					got.PositionValue, got.Shares)
+			}
+		})
+	}
+}
+
+func TestSizePosition_ATR(t *testing.T) {
+	candles := map[int64]SingleStockCandle{}
+	for i := int64(0); i < 20; i++ {
+		candles[i] = SingleStockCandle{Open: 100, High: 101, Low: 99, Close: 100}
+	}
+	conf := SizingConf{Equity: 100000, RiskPercent: 0.01, Method: STOPMETHODATR, ATRPeriod: 14}
+	got, err := SizePosition(candles, "AAA", 0, false, conf)
+	if err != nil {
+		t.Fatalf("SizePosition() error = %v", err)
+	}
+	// Every bar ranges 2, so the stop is two ATRs, 4, below the close.
+	if math.Abs(got.Stop-96) > 1e-9 || got.Shares != 250 {
+		t.Errorf("Stop, Shares = %v, %v; want 96, 250", got.Stop, got.Shares)
+	}
+	conf.ATRPeriod = 30
+	if _, err := SizePosition(candles, "AAA", 0, false, conf); err == nil {
+		t.Error("expected an error without enough history for the ATR")
+	}
+}
+
+func TestSizePosition_Errors(t *testing.T) {
+	candles := map[int64]SingleStockCandle{1: {Close: 100, PTradeRangeAdj: map[string]float64{"low": 95, "high": 110}}}
+	tests := []struct {
+		name  string
+		entry float64
+		conf  SizingConf
+	}{
+		{"no equity", 0, SizingConf{RiskPercent: 0.01}},
+		{"risk above one", 0, SizingConf{Equity: 1000, RiskPercent: 2}},
+		{"unknown method", 0, SizingConf{Equity: 1000, RiskPercent: 0.01, Method: "kelly"}},
+		{"entry below the stop", 90, SizingConf{Equity: 1000, RiskPercent: 0.01}},
+		{"no volatility", 0, SizingConf{Equity: 1000, RiskPercent: 0.01, Method: STOPMETHODVOLATILITY}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SizePosition(candles, "AAA", tt.entry, false, tt.conf); err == nil {
+				t.Error("SizePosition() expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	gonum "gonum.org/v1/gonum/stat"
+)
+
+// QUANTILERANGEMODEL selects CalculateQuantileRiskRanges bands over the probable-range-adj bands in batch output.
+const QUANTILERANGEMODEL = "quantile"
+
+// minForwardReturns is the fewest historical forward returns a quantile range is estimated from.
+const minForwardReturns = 20
+
+// durationSessions counts the sessions the ticker trades on its exchange calendar in the duration calendar days after
+// from.
+func durationSessions(duration int, ticker string, from time.Time) int {
+	return TradingDays(AssetClassOf(ticker), from, from.AddDate(0, 0, duration))
+}
+
+// forwardReturns returns the log return from each close to the close horizon sessions later, for every pair that
+// fits in closes.
+func forwardReturns(closes []float64, horizon int) []float64 {
+	var returns []float64
+	for i := 0; i+horizon < len(closes); i++ {
+		returns = append(returns, math.Log(closes[i+horizon]/closes[i]))
+	}
+	return returns
+}
+
+// calculateQuantileRange centers the empirical (1-coverage)/2 and (1+coverage)/2 quantiles of returns on price. The
+// "coverage" key states the share of historical outcomes the band would have contained.
+func calculateQuantileRange(price float64, returns []float64, coverage float64) map[string]float64 {
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	tail := (1 - coverage) / 2
+	return map[string]float64{
+		"high":     price * math.Exp(gonum.Quantile(1-tail, gonum.Empirical, sorted, nil)),
+		"low":      price * math.Exp(gonum.Quantile(tail, gonum.Empirical, sorted, nil)),
+		"coverage": coverage,
+	}
+}
+
+// CalculateQuantileRiskRanges is the alternative to GetProbAdjRiskRanges that derives each day's band from the
+// ticker's own history instead of a fixed adjustment: the distribution of forward returns over the duration horizon,
+// using only closes up to that day. coverage is the probability the band is meant to contain, defaulting to 0.8
+// (the 10th to 90th percentile). Days with fewer than minForwardReturns forward returns behind them are left unset.
+func CalculateQuantileRiskRanges(stockPrices map[string]map[int64]SingleStockCandle, duration int,
+	coverage float64) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	if coverage <= 0 || coverage >= 1 {
+		coverage = 0.8
+	}
+	for ticker := range stockPrices {
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		sort.Slice(dateKeys, func(i, j int) bool {
+			return dateKeys[i] < dateKeys[j]
+		})
+		closes := make([]float64, len(dateKeys))
+		for i, date := range dateKeys {
+			closes[i] = stockPrices[ticker][date].Close
+		}
+		for i, date := range dateKeys {
+			returns := forwardReturns(closes[:i+1], durationSessions(duration, ticker, time.UnixMilli(date)))
+			if len(returns) < minForwardReturns {
+				continue
+			}
+			stockCandle := stockPrices[ticker][date]
+			setQuantileRange(&stockCandle, duration, calculateQuantileRange(stockCandle.Close, returns, coverage))
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
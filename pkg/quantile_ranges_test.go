@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestForwardReturns(t *testing.T) {
+	got := forwardReturns([]float64{100, 110, 121, 100}, 2)
+	want := []float64{math.Log(1.21), math.Log(100.0 / 110)}
+	if len(got) != len(want) {
+		t.Fatalf("forwardReturns() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-12 {
+			t.Errorf("forwardReturns()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got := forwardReturns([]float64{100, 101}, 2); got != nil {
+		t.Errorf("horizon longer than history should give no returns, got %v", got)
+	}
+}
+
+func TestCalculateQuantileRange(t *testing.T) {
+	// Ten returns from -0.05 to 0.04; an 80% band runs from the 10th to the 90th percentile.
+	var returns []float64
+	for i := 9; i >= 0; i-- {
+		returns = append(returns, float64(i-5)/100)
+	}
+	got := calculateQuantileRange(200, returns, 0.8)
+	if want := 200 * math.Exp(-0.05); math.Abs(got["low"]-want) > 1e-9 {
+		t.Errorf("low = %v, want %v", got["low"], want)
+	}
+	if want := 200 * math.Exp(0.03); math.Abs(got["high"]-want) > 1e-9 {
+		t.Errorf("high = %v, want %v", got["high"], want)
+	}
+	if got["coverage"] != 0.8 {
+		t.Errorf("coverage = %v, want 0.8", got["coverage"])
+	}
+}
+
+func TestCalculateQuantileRiskRanges(t *testing.T) {
+	data := makeTestData("AAPL", 120)
+	result := CalculateQuantileRiskRanges(data, SHORTDURATION, 0)
+	// A 30 day horizon is 21 sessions, so only days with 21+20 closes behind them get a band.
+	populated := 0
+	for _, c := range result["AAPL"] {
+		if c.QTrendRange != nil || c.QTailRange != nil {
+			t.Errorf("Med/Long quantile ranges should not be set by SHORTDURATION call")
+		}
+		r := c.QTradeRange
+		if r == nil {
+			continue
+		}
+		populated++
+		if r["coverage"] != 0.8 {
+			t.Errorf("coverage = %v, want default 0.8", r["coverage"])
+		}
+		// makeTestData only ever declines, so the whole band sits below the close.
+		if !(r["low"] <= r["high"] && r["high"] < c.Close) {
+			t.Errorf("band %v should be ordered and below close %v", r, c.Close)
+		}
+	}
+	if want := 120 - 21 - minForwardReturns + 1; populated != want {
+		t.Errorf("populated %d days, want %d", populated, want)
+	}
+}
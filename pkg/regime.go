@@ -0,0 +1,84 @@
+package pkg
+
+const (
+	// heavyVolumeRatio is the AvgVolumeRatioShort at or above which a move counts as confirmed by volume.
+	heavyVolumeRatio = 1.25
+	// highVolPercent is the RVolPercentShort at or above which volatility counts as near the top of its range.
+	highVolPercent = 0.8
+)
+
+// classifyRegime combines the three directions with volume confirmation and volatility position into one regime.
+// Rows are checked top to bottom and the first match wins; "*" matches anything, and "heavy volume" means
+// AvgVolumeRatioShort >= 1.25 and "high volatility" RVolPercentShort >= 0.8.
+//
+//	Trend          Tail           Trade     Confirmation     Regime
+//	Indeterminate  *              *         *                Indeterminate
+//	*              *              Indet.    *                Indeterminate
+//	Bullish        Bullish        Bullish   high volatility  Bullish, volatility rising
+//	Bullish        Bullish        Bullish   *                Bullish
+//	Bullish        Bullish        Bearish   *                Bullish with Bearish trade pullback
+//	Bullish        Bullish        Neutral   *                Bullish consolidation
+//	Bearish        Bearish        Bearish   heavy volume     Breakdown on volume
+//	Bearish        Bearish        Bearish   *                Bearish
+//	Bearish        Bearish        Bullish   *                Bearish with Bullish trade bounce
+//	Bearish        Bearish        Neutral   *                Bearish consolidation
+//	not Bearish    *              Bullish   heavy volume     Breakout on volume
+//	not Bullish    *              Bearish   heavy volume     Breakdown on volume
+//	Bullish        not Bullish    Bullish   *                Early Bullish reversal
+//	Bearish        not Bearish    Bearish   *                Early Bearish reversal
+//	*              *              *         *                Range-bound
+func classifyRegime(trade, trend, tail string, volumeRatio, volPercent float64) string {
+	heavyVolume := volumeRatio >= heavyVolumeRatio
+	highVol := volPercent >= highVolPercent
+	switch {
+	case trend == "Indeterminate" || trade == "Indeterminate" || trend == "" || trade == "":
+		return "Indeterminate"
+	case trend == "Bullish" && tail == "Bullish":
+		switch trade {
+		case "Bullish":
+			if highVol {
+				return "Bullish, volatility rising"
+			}
+			return "Bullish"
+		case "Bearish":
+			return "Bullish with Bearish trade pullback"
+		default:
+			return "Bullish consolidation"
+		}
+	case trend == "Bearish" && tail == "Bearish":
+		switch trade {
+		case "Bearish":
+			if heavyVolume {
+				return "Breakdown on volume"
+			}
+			return "Bearish"
+		case "Bullish":
+			return "Bearish with Bullish trade bounce"
+		default:
+			return "Bearish consolidation"
+		}
+	case trend != "Bearish" && trade == "Bullish" && heavyVolume:
+		return "Breakout on volume"
+	case trend != "Bullish" && trade == "Bearish" && heavyVolume:
+		return "Breakdown on volume"
+	case trend == "Bullish" && trade == "Bullish":
+		return "Early Bullish reversal"
+	case trend == "Bearish" && trade == "Bearish":
+		return "Early Bearish reversal"
+	}
+	return "Range-bound"
+}
+
+// ClassifyRegimes assigns each day the composite regime classifyRegime derives from its trade, trend, and tail
+// directions, with volume and volatility read from the trade duration. Must be called after CalculateTrendDirections,
+// CalculateAvgVolumeRatios, and GetRelHighLowVol for SHORTDURATION.
+func ClassifyRegimes(stockPrices map[string]map[int64]SingleStockCandle) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		for date, stockCandle := range stockPrices[ticker] {
+			stockCandle.Regime = classifyRegime(stockCandle.TradeDirection, stockCandle.TrendDirection,
+				stockCandle.TailDirection, stockCandle.AvgVolumeRatioShort, stockCandle.RVolPercentShort)
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
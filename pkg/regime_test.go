@@ -0,0 +1,53 @@
+package pkg
+
+import "testing"
+
+func TestClassifyRegime(t *testing.T) {
+	tests := []struct {
+		name                string
+		trade, trend, tail  string
+		volumeRatio, volPct float64
+		want                string
+	}{
+		{"no trend yet", "Bullish", "Indeterminate", "Indeterminate", 1, 0.5, "Indeterminate"},
+		{"no trade yet", "Indeterminate", "Bullish", "Bullish", 1, 0.5, "Indeterminate"},
+		{"aligned bullish", "Bullish", "Bullish", "Bullish", 1, 0.5, "Bullish"},
+		{"aligned bullish high vol", "Bullish", "Bullish", "Bullish", 1, 0.9, "Bullish, volatility rising"},
+		{"bullish pullback", "Bearish", "Bullish", "Bullish", 2, 0.9, "Bullish with Bearish trade pullback"},
+		{"bullish consolidation", "Neutral", "Bullish", "Bullish", 1, 0.5, "Bullish consolidation"},
+		{"aligned bearish", "Bearish", "Bearish", "Bearish", 1, 0.5, "Bearish"},
+		{"bearish on volume", "Bearish", "Bearish", "Bearish", 1.5, 0.5, "Breakdown on volume"},
+		{"bearish bounce", "Bullish", "Bearish", "Bearish", 1, 0.5, "Bearish with Bullish trade bounce"},
+		{"bearish consolidation", "Neutral", "Bearish", "Bearish", 1, 0.5, "Bearish consolidation"},
+		{"breakout", "Bullish", "Neutral", "Bearish", 1.3, 0.5, "Breakout on volume"},
+		{"breakdown", "Bearish", "Neutral", "Bullish", 1.3, 0.5, "Breakdown on volume"},
+		{"early bullish", "Bullish", "Bullish", "Neutral", 1, 0.5, "Early Bullish reversal"},
+		{"early bearish", "Bearish", "Bearish", "Indeterminate", 1, 0.5, "Early Bearish reversal"},
+		{"range-bound", "Bullish", "Neutral", "Neutral", 1, 0.5, "Range-bound"},
+		{"counter-trend trade", "Bullish", "Bearish", "Neutral", 1.3, 0.5, "Range-bound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyRegime(tt.trade, tt.trend, tt.tail, tt.volumeRatio, tt.volPct); got != tt.want {
+				t.Errorf("classifyRegime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyRegimes(t *testing.T) {
+	stockPrices := map[string]map[int64]SingleStockCandle{
+		"AAA": {
+			1: {TradeDirection: "Bearish", TrendDirection: "Bullish", TailDirection: "Bullish"},
+			2: {TradeDirection: "Bearish", TrendDirection: "Bearish", TailDirection: "Bearish",
+				AvgVolumeRatioShort: 2},
+		},
+	}
+	got := ClassifyRegimes(stockPrices)
+	if r := got["AAA"][1].Regime; r != "Bullish with Bearish trade pullback" {
+		t.Errorf("day 1 regime = %q", r)
+	}
+	if r := got["AAA"][2].Regime; r != "Breakdown on volume" {
+		t.Errorf("day 2 regime = %q", r)
+	}
+}
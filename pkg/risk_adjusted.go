@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"math"
+	"sort"
+
+	gonum "gonum.org/v1/gonum/stat"
+)
+
+// RiskRatios holds annualized return-quality ratios for one duration window. Ratios that are undefined for
+// the window (no variation, no downside, or no drawdown) are reported as 0.
+type RiskRatios struct {
+	Sharpe  float64 `json:"sharpe"`
+	Sortino float64 `json:"sortino"`
+	Calmar  float64 `json:"calmar"`
+}
+
+// calculateRiskAdjustedReturns computes the ratios from chronologically ordered closes. riskFreeRate is an annual
+// rate in decimal form, the same convention as GetTargetAnnualReturn, and is converted to a per-session log rate so it
+// can be subtracted from the daily log returns produced by calculateDailyReturn.
+func calculateRiskAdjustedReturns(closes []float64, riskFreeRate, daysInYear float64) (ratios RiskRatios) {
+	returns := calculateDailyReturn(closes)
+	if len(returns) < 2 {
+		return RiskRatios{}
+	}
+	dailyRiskFree := math.Log(1+riskFreeRate) / daysInYear
+	excess := make([]float64, len(returns))
+	var downsideSq float64
+	for i, r := range returns {
+		excess[i] = r - dailyRiskFree
+		if excess[i] < 0 {
+			downsideSq += excess[i] * excess[i]
+		}
+	}
+	meanExcess := gonum.Mean(excess, nil)
+	if sd := gonum.StdDev(excess, nil); sd > 0 {
+		ratios.Sharpe = meanExcess / sd * math.Sqrt(daysInYear)
+	}
+	if downsideDev := math.Sqrt(downsideSq / float64(len(excess))); downsideDev > 0 {
+		ratios.Sortino = meanExcess / downsideDev * math.Sqrt(daysInYear)
+	}
+	if maxDD := calculateDrawdown(closes).Max; maxDD > 0 {
+		annualReturn := math.Exp(gonum.Mean(returns, nil)*daysInYear) - 1
+		ratios.Calmar = annualReturn / maxDD
+	}
+	return ratios
+}
+
+// CalculateRiskAdjustedReturns stores the Sharpe, Sortino, and Calmar ratios of each day's duration window of closes.
+func CalculateRiskAdjustedReturns(stockPrices map[string]map[int64]SingleStockCandle, duration int,
+	riskFreeRate float64) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		daysInYear := annualization(ticker)
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		reverseDateKeys := dateKeys
+		sort.Slice(reverseDateKeys, func(i, j int) bool {
+			return reverseDateKeys[i] > reverseDateKeys[j]
+		})
+		for index, date := range reverseDateKeys {
+			windowDates, ok := collectWindowDates(reverseDateKeys, index, duration)
+			if !ok {
+				continue
+			}
+			closes := make([]float64, len(windowDates))
+			for i, wd := range windowDates {
+				closes[len(windowDates)-1-i] = stockPrices[ticker][wd].Close
+			}
+			stockCandle := stockPrices[ticker][date]
+			setRiskAdjusted(&stockCandle, duration, calculateRiskAdjustedReturns(closes, riskFreeRate, daysInYear))
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
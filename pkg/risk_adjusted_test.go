@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateRiskAdjustedReturns(t *testing.T) {
+	tests := []struct {
+		name         string
+		closes       []float64
+		riskFreeRate float64
+		want         RiskRatios
+	}{
+		{
+			name:         "choppy advance with one drawdown",
+			closes:       []float64{100, 102, 101, 104, 103, 106},
+			riskFreeRate: 0.05,
+			want:         RiskRatios{Sharpe: 9.140504718852693, Sortino: 28.90594359822782, Calmar: 1821.1616102182077},
+		},
+		{
+			name:         "steady advance has no downside or drawdown",
+			closes:       []float64{100, 101, 102.01, 103.0301},
+			riskFreeRate: 0,
+			want:         RiskRatios{},
+		},
+		{
+			name:   "too few closes",
+			closes: []float64{100, 101},
+			want:   RiskRatios{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateRiskAdjustedReturns(tt.closes, tt.riskFreeRate, 252)
+			if math.Abs(got.Sharpe-tt.want.Sharpe) > 1e-6 || math.Abs(got.Sortino-tt.want.Sortino) > 1e-6 ||
+				math.Abs(got.Calmar-tt.want.Calmar) > 1e-6 {
+				t.Errorf("calculateRiskAdjustedReturns() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateRiskAdjustedReturns_PopulatesOnlyTargetDuration(t *testing.T) {
+	// makeTestData closes fall towards the present, so every full window has a negative Sharpe ratio.
+	data := makeTestData("AAPL", 90)
+	result := CalculateRiskAdjustedReturns(data, SHORTDURATION, 0.04)
+	hasShort := false
+	for _, candles := range result {
+		for _, c := range candles {
+			if c.RiskAdjustedShort.Sharpe < 0 {
+				hasShort = true
+			}
+			if c.RiskAdjustedMed != (RiskRatios{}) || c.RiskAdjustedLong != (RiskRatios{}) {
+				t.Errorf("Med/Long risk ratios should not be set by SHORTDURATION call")
+			}
+		}
+	}
+	if !hasShort {
+		t.Error("expected at least one candle with RiskAdjustedShort populated")
+	}
+}
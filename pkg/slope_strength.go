@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"math"
+	"time"
+
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// NormalizeSlopes scales each day's raw slope for the duration two ways: as a percentage move, the delta divided by
+// the close it was measured from, and as a volatility-normalized move, that percentage divided by the realized
+// volatility scaled to the duration's sessions. The second reads like a z-score, so moves compare across tickers
+// with very different prices and volatilities.
+//
+// Must be called after GetSimpleSlopes and StoreRealizedVols for the same duration.
+func NormalizeSlopes(stockPrices map[string]map[int64]SingleStockCandle, duration int) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		for date, stockCandle := range stockPrices[ticker] {
+			if !getSlopeValid(stockCandle, duration) {
+				continue
+			}
+			// The slope measures the move over the duration up to the day
+			start := time.UnixMilli(date).AddDate(0, 0, -duration)
+			horizon := math.Sqrt(float64(durationSessions(duration, ticker, start)) / annualization(ticker))
+			slope := getSlope(stockCandle, duration)
+			base := stockCandle.Close - slope
+			if base <= 0 {
+				continue
+			}
+			pct := slope / base
+			setSlopePct(&stockCandle, duration, pct)
+			if rv := getRVol(stockCandle, duration); rv > 0 && horizon > 0 {
+				setSlopeVol(&stockCandle, duration, pct/(rv*horizon))
+			}
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
+
+// trendStrength maps the mean of the valid volatility-normalized slopes onto 0-100 as 100 * (2Φ(|z|) - 1), where Φ
+// is the standard normal CDF. A flat or contradictory set of durations scores near 0; a one standard deviation move
+// scores about 68 and a two standard deviation move about 95.
+func trendStrength(z []float64) float64 {
+	if len(z) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range z {
+		sum += v
+	}
+	return 100 * (2*distuv.UnitNormal.CDF(math.Abs(sum/float64(len(z)))) - 1)
+}
+
+// CalculateTrendStrength scores each day's trend from 0 to 100 from the volatility-normalized slopes of every
+// duration with a valid slope. Durations pulling in opposite directions cancel out. Must be called after
+// NormalizeSlopes for every duration.
+func CalculateTrendStrength(stockPrices map[string]map[int64]SingleStockCandle) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		for date, stockCandle := range stockPrices[ticker] {
+			var z []float64
+			for _, d := range []int{SHORTDURATION, MEDIUMDURATION, LONGDURATION} {
+				if getSlopeValid(stockCandle, d) && getRVol(stockCandle, d) > 0 {
+					z = append(z, getSlopeVol(stockCandle, d))
+				}
+			}
+			stockCandle.TrendStrength = trendStrength(z)
+			stockPrices[ticker][date] = stockCandle
+		}
+	}
+	return stockPrices
+}
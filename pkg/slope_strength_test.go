@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSlopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticker  string
+		candle  SingleStockCandle
+		wantPct float64
+		wantVol float64
+	}{
+		{
+			name:   "cheap stock",
+			ticker: "AAA",
+			candle: SingleStockCandle{Close: 25, SlopeShortDuration: 5, SlopeShortValid: true,
+				RealizedVolatilityShort: 0.5},
+			wantPct: 0.25,
+			wantVol: 0.25 / (0.5 * math.Sqrt(21.0/252)),
+		},
+		{
+			name:   "expensive stock with the same dollar move",
+			ticker: "BBB",
+			candle: SingleStockCandle{Close: 2005, SlopeShortDuration: 5, SlopeShortValid: true,
+				RealizedVolatilityShort: 0.2},
+			wantPct: 0.0025,
+			wantVol: 0.0025 / (0.2 * math.Sqrt(21.0/252)),
+		},
+		{
+			name:   "crypto scales volatility by calendar days",
+			ticker: "X:BTCUSD",
+			candle: SingleStockCandle{Close: 90, SlopeShortDuration: -10, SlopeShortValid: true,
+				RealizedVolatilityShort: 0.6},
+			wantPct: -0.1,
+			wantVol: -0.1 / (0.6 * math.Sqrt(30/365.24)),
+		},
+		{
+			name:    "without volatility only the percentage is set",
+			ticker:  "CCC",
+			candle:  SingleStockCandle{Close: 110, SlopeShortDuration: 10, SlopeShortValid: true},
+			wantPct: 0.1,
+		},
+		{
+			name:   "invalid slope is left alone",
+			ticker: "DDD",
+			candle: SingleStockCandle{Close: 110, SlopeShortDuration: 10, RealizedVolatilityShort: 0.2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The 30 days to May 2 2025 hold 21 exchange sessions, Good Friday being a holiday
+			day := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC).UnixMilli()
+			data := map[string]map[int64]SingleStockCandle{tt.ticker: {day: tt.candle}}
+			got := NormalizeSlopes(data, SHORTDURATION)[tt.ticker][day]
+			if math.Abs(got.SlopePctShort-tt.wantPct) > 1e-12 {
+				t.Errorf("SlopePctShort = %v, want %v", got.SlopePctShort, tt.wantPct)
+			}
+			if math.Abs(got.SlopeVolShort-tt.wantVol) > 1e-9 {
+				t.Errorf("SlopeVolShort = %v, want %v", got.SlopeVolShort, tt.wantVol)
+			}
+			if got.SlopePctMed != 0 || got.SlopeVolMed != 0 {
+				t.Errorf("medium duration should not be set by a SHORTDURATION call: %v %v", got.SlopePctMed,
+					got.SlopeVolMed)
+			}
+		})
+	}
+}
+
+func TestTrendStrength(t *testing.T) {
+	tests := []struct {
+		name string
+		z    []float64
+		want float64
+	}{
+		{"no durations", nil, 0},
+		{"flat", []float64{0, 0, 0}, 0},
+		{"one sigma", []float64{1, 1, 1}, 68.27},
+		{"two sigma down", []float64{-2, -2, -2}, 95.45},
+		{"opposing durations cancel", []float64{2, -2}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trendStrength(tt.z); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("trendStrength(%v) = %v, want %v", tt.z, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateTrendStrength(t *testing.T) {
+	data := map[string]map[int64]SingleStockCandle{"AAA": {
+		1: {SlopeShortValid: true, RealizedVolatilityShort: 0.2, SlopeVolShort: 1,
+			SlopeMedValid: true, RealizedVolatilityMed: 0.2, SlopeVolMed: 1,
+			// The long slope has no history behind it, so its value must be ignored.
+			SlopeVolLong: -5},
+	}}
+	got := CalculateTrendStrength(data)["AAA"][1].TrendStrength
+	if math.Abs(got-68.27) > 0.01 {
+		t.Errorf("TrendStrength = %v, want 68.27", got)
+	}
+}
@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+// SyntheticLeg is one side of a synthetic series: a ticker scaled by a positive weight.
+type SyntheticLeg struct {
+	Ticker string
+	Weight float64
+}
+
+// SyntheticSpec describes a series derived from two tickers, either their ratio (Op '/') or a weighted spread (Op '-'
+// or '+'), such as XLK/SPY or GLD-2*SLV. Name is the normalized expression the series is keyed by.
+type SyntheticSpec struct {
+	Name  string
+	Op    byte
+	Left  SyntheticLeg
+	Right SyntheticLeg
+}
+
+// ParseSynthetic parses a pair expression of the form [w*]A op [w*]B, where op is / for a ratio or - or + for a
+// spread and each weight defaults to 1. It returns false for a plain ticker, including a crypto pair written with a
+// slash such as X:BTC/USD. Any other -, +, or / is read as an operator, so tickers containing one must use their
+// dotted form, e.g. BRK.B.
+func ParseSynthetic(expr string) (SyntheticSpec, bool, error) {
+	expr = strings.ReplaceAll(NormalizeTicker(expr), " ", "")
+	at := strings.IndexAny(expr, "/-+")
+	if at < 0 || isCryptoPair(expr) {
+		return SyntheticSpec{}, false, nil
+	}
+	if strings.ContainsAny(expr[at+1:], "/-+") {
+		return SyntheticSpec{}, true, fmt.Errorf("synthetic series %s must combine exactly two tickers", expr)
+	}
+	left, err := parseSyntheticLeg(expr[:at])
+	if err != nil {
+		return SyntheticSpec{}, true, fmt.Errorf("synthetic series %s: %w", expr, err)
+	}
+	right, err := parseSyntheticLeg(expr[at+1:])
+	if err != nil {
+		return SyntheticSpec{}, true, fmt.Errorf("synthetic series %s: %w", expr, err)
+	}
+	spec := SyntheticSpec{Op: expr[at], Left: left, Right: right}
+	spec.Name = formatSyntheticLeg(left) + string(spec.Op) + formatSyntheticLeg(right)
+	return spec, true, nil
+}
+
+// cryptoQuoteCurrencies are the currencies a crypto pair written with a slash may be quoted in.
+var cryptoQuoteCurrencies = []string{"USD", "USDT", "USDC", "BTC", "ETH", "EUR", "GBP"}
+
+// isCryptoPair reports whether expr is a single crypto ticker in its slashed form, e.g. X:BTC/USD, rather than the
+// ratio of two tickers such as X:BTCUSD/SPY or X:ETHUSD/X:BTCUSD, whose right side is not a bare quote currency.
+func isCryptoPair(expr string) bool {
+	base, quote, ok := strings.Cut(expr, "/")
+	return ok && strings.HasPrefix(base, "X:") && !strings.ContainsAny(base, "-+*") &&
+		slices.Contains(cryptoQuoteCurrencies, quote)
+}
+
+func parseSyntheticLeg(leg string) (SyntheticLeg, error) {
+	weight := 1.0
+	if w, ticker, ok := strings.Cut(leg, "*"); ok {
+		var err error
+		if weight, err = strconv.ParseFloat(w, 64); err != nil || weight <= 0 {
+			return SyntheticLeg{}, fmt.Errorf("weight %q must be a positive number", w)
+		}
+		leg = ticker
+	}
+	if leg == "" {
+		return SyntheticLeg{}, errors.New("missing ticker")
+	}
+	return SyntheticLeg{Ticker: leg, Weight: weight}, nil
+}
+
+func formatSyntheticLeg(leg SyntheticLeg) string {
+	if leg.Weight == 1 {
+		return leg.Ticker
+	}
+	return strconv.FormatFloat(leg.Weight, 'f', -1, 64) + "*" + leg.Ticker
+}
+
+// syntheticAssetClass is the asset class shared by both legs of a synthetic series, or equity when they differ, since
+// the series only has a session when both legs trade.
+func syntheticAssetClass(spec SyntheticSpec) store.AssetClass {
+	left, right := AssetClassOf(spec.Left.Ticker), AssetClassOf(spec.Right.Ticker)
+	if left == right {
+		return left
+	}
+	return store.AssetClassEquity
+}
+
+// combine applies the spec to one price from each leg.
+func (s SyntheticSpec) combine(left, right float64) float64 {
+	l, r := s.Left.Weight*left, s.Right.Weight*right
+	switch s.Op {
+	case '/':
+		return l / r
+	case '+':
+		return l + r
+	}
+	return l - r
+}
+
+// BuildSynthetic derives the spec's daily candles from the two legs' candles, keyed by the spec's name. Days are
+// aligned on the UTC date and only days both legs traded are kept. Open, close, and the volume-weighted price combine
+// the legs directly; the high and low are the widest the series could have reached, pairing one leg's high with the
+// other's low where the operator calls for it. Volume and transactions are the smaller leg's, the side that limits
+// trading the pair. The volatility and range math works on log returns, so a spread that closes at or below zero is
+// an error; reweight the legs to keep it positive. A ratio whose divisor has a price at or below zero, a bad or missing
+// bar, is an error too.
+func BuildSynthetic(spec SyntheticSpec, left, right map[int64]SingleStockCandle) (map[string]map[int64]SingleStockCandle,
+	error) {
+	rightByDay := make(map[int64]SingleStockCandle, len(right))
+	for _, c := range right {
+		rightByDay[truncateToDay(c.Timestamp).UnixMilli()] = c
+	}
+	series := map[int64]SingleStockCandle{}
+	for date, l := range left {
+		r, ok := rightByDay[truncateToDay(l.Timestamp).UnixMilli()]
+		if !ok {
+			continue
+		}
+		if spec.Op == '/' && min(r.Open, r.High, r.Low, r.Close) <= 0 {
+			return nil, fmt.Errorf("synthetic series %s divides by %s at or below zero on %s; its prices must stay "+
+				"above zero", spec.Name, spec.Right.Ticker, l.Timestamp.Format(time.DateOnly))
+		}
+		candle := SingleStockCandle{
+			Ticker:         spec.Name,
+			Timestamp:      l.Timestamp,
+			Open:           spec.combine(l.Open, r.Open),
+			Close:          spec.combine(l.Close, r.Close),
+			WeightedVolume: spec.combine(l.WeightedVolume, r.WeightedVolume),
+			Volume:         math.Min(l.Volume, r.Volume),
+			Transactions:   min(l.Transactions, r.Transactions),
+		}
+		if spec.Op == '/' && r.WeightedVolume <= 0 {
+			// A leg without a volume-weighted price leaves the ratio's unknown rather than infinite
+			candle.WeightedVolume = 0
+		}
+		if spec.Op == '+' {
+			candle.High = spec.combine(l.High, r.High)
+			candle.Low = spec.combine(l.Low, r.Low)
+		} else {
+			candle.High = spec.combine(l.High, r.Low)
+			candle.Low = spec.combine(l.Low, r.High)
+		}
+		candle.High = max(candle.High, candle.Open, candle.Close)
+		candle.Low = min(candle.Low, candle.Open, candle.Close)
+		if candle.Close <= 0 {
+			return nil, fmt.Errorf("synthetic series %s closed at %g on %s; it must stay above zero", spec.Name,
+				candle.Close, candle.Timestamp.Format(time.DateOnly))
+		}
+		series[date] = candle
+	}
+	return map[string]map[int64]SingleStockCandle{spec.Name: series}, nil
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+func TestParseSynthetic(t *testing.T) {
+	tests := []struct {
+		expr      string
+		wantOK    bool
+		wantErr   bool
+		wantName  string
+		wantOp    byte
+		wantRight SyntheticLeg
+	}{
+		{expr: "SPY"},
+		{expr: "x:btcusd"},
+		{expr: "x:btc/usd"},
+		{expr: "xlk/spy", wantOK: true, wantName: "XLK/SPY", wantOp: '/', wantRight: SyntheticLeg{"SPY", 1}},
+		{expr: "GLD - 2*SLV", wantOK: true, wantName: "GLD-2*SLV", wantOp: '-', wantRight: SyntheticLeg{"SLV", 2}},
+		{expr: "0.5*QQQ+IWM", wantOK: true, wantName: "0.5*QQQ+IWM", wantOp: '+', wantRight: SyntheticLeg{"IWM", 1}},
+		{expr: "X:ETHUSD/X:BTCUSD", wantOK: true, wantName: "X:ETHUSD/X:BTCUSD", wantOp: '/',
+			wantRight: SyntheticLeg{"X:BTCUSD", 1}},
+		{expr: "A/B/C", wantOK: true, wantErr: true},
+		{expr: "GLD-x*SLV", wantOK: true, wantErr: true},
+		{expr: "GLD--2*SLV", wantOK: true, wantErr: true},
+		{expr: "/SPY", wantOK: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, ok, err := ParseSynthetic(tt.expr)
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSynthetic() ok = %v, err = %v; want ok %v, error %v", ok, err, tt.wantOK, tt.wantErr)
+			}
+			if !ok || err != nil {
+				return
+			}
+			if spec.Name != tt.wantName || spec.Op != tt.wantOp || spec.Right != tt.wantRight {
+				t.Errorf("ParseSynthetic() = %+v", spec)
+			}
+		})
+	}
+}
+
+func TestBuildSynthetic(t *testing.T) {
+	day := func(d int, hour int) time.Time { return time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC) }
+	left := map[int64]SingleStockCandle{
+		day(3, 5).UnixMilli(): {Timestamp: day(3, 5), Open: 100, High: 110, Low: 90, Close: 105, WeightedVolume: 104,
+			Volume: 1000, Transactions: 10},
+		day(4, 5).UnixMilli(): {Timestamp: day(4, 5), Open: 105, High: 106, Low: 104, Close: 105},
+	}
+	// The right leg's bars are stamped at midnight, and it has no bar on the 4th.
+	right := map[int64]SingleStockCandle{
+		day(3, 0).UnixMilli(): {Timestamp: day(3, 0), Open: 50, High: 55, Low: 45, Close: 50, WeightedVolume: 52,
+			Volume: 500, Transactions: 20},
+	}
+	tests := []struct {
+		expr string
+		want SingleStockCandle
+	}{
+		{"A/B", SingleStockCandle{Open: 2, High: 110.0 / 45, Low: 90.0 / 55, Close: 2.1, WeightedVolume: 2}},
+		{"A-2*B", SingleStockCandle{Open: 0, High: 20, Low: -20, Close: 5, WeightedVolume: 0}},
+		{"A+B", SingleStockCandle{Open: 150, High: 165, Low: 135, Close: 155, WeightedVolume: 156}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, _, err := ParseSynthetic(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			built, err := BuildSynthetic(spec, left, right)
+			if err != nil {
+				t.Fatalf("BuildSynthetic() error = %v", err)
+			}
+			series := built[spec.Name]
+			if len(series) != 1 {
+				t.Fatalf("got %d aligned days, want 1", len(series))
+			}
+			got := series[day(3, 5).UnixMilli()]
+			for name, pair := range map[string][2]float64{
+				"Open": {got.Open, tt.want.Open}, "High": {got.High, tt.want.High}, "Low": {got.Low, tt.want.Low},
+				"Close": {got.Close, tt.want.Close}, "WeightedVolume": {got.WeightedVolume, tt.want.WeightedVolume},
+			} {
+				if math.Abs(pair[0]-pair[1]) > 1e-12 {
+					t.Errorf("%s = %v, want %v", name, pair[0], pair[1])
+				}
+			}
+			if got.Volume != 500 || got.Transactions != 10 || got.Ticker != spec.Name {
+				t.Errorf("Volume, Transactions, Ticker = %v, %v, %q", got.Volume, got.Transactions, got.Ticker)
+			}
+		})
+	}
+}
+
+func TestBuildSynthetic_NonPositive(t *testing.T) {
+	ts := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	left := map[int64]SingleStockCandle{ts.UnixMilli(): {Timestamp: ts, Close: 30}}
+	right := map[int64]SingleStockCandle{ts.UnixMilli(): {Timestamp: ts, Close: 20}}
+	spec, _, _ := ParseSynthetic("A-2*B")
+	if _, err := BuildSynthetic(spec, left, right); err == nil {
+		t.Error("expected an error for a spread closing below zero")
+	}
+
+	// A divisor without a low would make the ratio's high infinite
+	right[ts.UnixMilli()] = SingleStockCandle{Timestamp: ts, Open: 20, High: 20, Close: 20}
+	spec, _, _ = ParseSynthetic("A/B")
+	if _, err := BuildSynthetic(spec, left, right); err == nil {
+		t.Error("expected an error for a ratio dividing by zero")
+	}
+}
+
+func TestAssetClassOf_Synthetic(t *testing.T) {
+	tests := []struct {
+		ticker string
+		want   store.AssetClass
+	}{
+		{"X:ETHUSD/X:BTCUSD", store.AssetClassCrypto},
+		{"X:BTCUSD/SPY", store.AssetClassEquity},
+		{"X:BTC/USD", store.AssetClassCrypto},
+		{"C:EURUSD-C:GBPUSD", store.AssetClassForex},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			if got := AssetClassOf(tt.ticker); got != tt.want {
+				t.Errorf("AssetClassOf(%q) = %q, want %q", tt.ticker, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+// FOREXSESSIONSPERYEAR is the number of sessions in a forex year: every weekday, as the market trades around the clock
+// from Sunday evening to Friday evening.
+const FOREXSESSIONSPERYEAR = 260
+
+// SessionsPerYear is the number of sessions per year used to annualize per-session statistics for the asset class.
+// Crypto trades every calendar day, forex every weekday, and everything else, including an unknown class, on the
+// exchange calendar's roughly 252 sessions.
+func SessionsPerYear(class store.AssetClass) float64 {
+	switch class {
+	case store.AssetClassCrypto:
+		return YEAR
+	case store.AssetClassForex:
+		return FOREXSESSIONSPERYEAR
+	}
+	return TRADINGDAYSPERYEAR
+}
+
+// IsTradingDay reports whether the asset class has a session on day's UTC date: every day for crypto, weekdays for
+// forex, and NYSE sessions, weekdays other than exchange holidays, for everything else.
+func IsTradingDay(class store.AssetClass, day time.Time) bool {
+	if class == store.AssetClassCrypto {
+		return true
+	}
+	day = truncateToDay(day)
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return class == store.AssetClassForex || !isNYSEHoliday(day)
+}
+
+// TradingDays counts the asset class's sessions after from up to and including to. It is negative when to is before
+// from.
+func TradingDays(class store.AssetClass, from, to time.Time) int {
+	from, to = truncateToDay(from), truncateToDay(to)
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	if class == store.AssetClassCrypto {
+		return sign * int(to.Sub(from).Hours()/DAY)
+	}
+	var sessions int
+	for day := from.AddDate(0, 0, 1); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if IsTradingDay(class, day) {
+			sessions++
+		}
+	}
+	return sign * sessions
+}
+
+// YearFraction is the length of the holding period from from to to in years of the asset class's sessions. An empty
+// class counts calendar days over 365.24-day years.
+func YearFraction(class store.AssetClass, from, to time.Time) float64 {
+	if class == "" {
+		return truncateToDay(to).Sub(truncateToDay(from)).Hours() / DAY / YEAR
+	}
+	return float64(TradingDays(class, from, to)) / SessionsPerYear(class)
+}
+
+// isNYSEHoliday reports whether the date is a full-day NYSE holiday, applying the exchange's rule that a holiday on a
+// Saturday closes the Friday before and one on a Sunday the Monday after. New Year's Day on a Saturday is not made up.
+// One-off closures are not included.
+func isNYSEHoliday(day time.Time) bool {
+	year := day.Year()
+	holidays := []time.Time{
+		// New Year's Day on a Saturday would be observed on the last day of the year before, which is not made up
+		observed(date(year, time.January, 1)),
+		nthWeekday(year, time.January, time.Monday, 3),
+		nthWeekday(year, time.February, time.Monday, 3),
+		easter(year).AddDate(0, 0, -2),
+		lastWeekday(year, time.May, time.Monday),
+		observed(date(year, time.July, 4)),
+		nthWeekday(year, time.September, time.Monday, 1),
+		nthWeekday(year, time.November, time.Thursday, 4),
+		observed(date(year, time.December, 25)),
+	}
+	if year >= 2022 {
+		holidays = append(holidays, observed(date(year, time.June, 19)))
+	}
+	for _, h := range holidays {
+		if h.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// observed moves a holiday on a Saturday to the Friday before and one on a Sunday to the Monday after.
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// nthWeekday returns the nth weekday of the month, counting from 1.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := date(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of the month.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easter returns Easter Sunday of the Gregorian year using the anonymous Gregorian algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
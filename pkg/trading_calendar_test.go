@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/khrystoph/portfoliotools/internal/store"
+)
+
+func TestIsNYSEHoliday(t *testing.T) {
+	tests := []struct {
+		day  string
+		want bool
+	}{
+		{"2024-01-01", true},  // New Year's Day
+		{"2024-01-15", true},  // Martin Luther King Jr. Day
+		{"2024-02-19", true},  // Washington's Birthday
+		{"2024-03-29", true},  // Good Friday
+		{"2024-05-27", true},  // Memorial Day
+		{"2024-06-19", true},  // Juneteenth
+		{"2024-07-04", true},  // Independence Day
+		{"2024-09-02", true},  // Labor Day
+		{"2024-11-28", true},  // Thanksgiving
+		{"2024-12-25", true},  // Christmas
+		{"2021-06-18", false}, // Juneteenth was first observed in 2022
+		{"2022-12-26", true},  // Christmas on a Sunday moves to Monday
+		{"2021-12-31", false}, // New Year's Day on a Saturday is not made up
+		{"2026-07-03", true},  // Independence Day on a Saturday moves to Friday
+		{"2025-04-18", true},  // Good Friday
+		{"2024-03-28", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.day, func(t *testing.T) {
+			day, _ := time.Parse(time.DateOnly, tt.day)
+			if got := isNYSEHoliday(day); got != tt.want {
+				t.Errorf("isNYSEHoliday(%s) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingDays(t *testing.T) {
+	// 2024 had 252 NYSE sessions, 262 weekdays, and 366 days.
+	from := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		class store.AssetClass
+		want  int
+	}{
+		{store.AssetClassEquity, 252},
+		{store.AssetClassETF, 252},
+		{store.AssetClassForex, 262},
+		{store.AssetClassCrypto, 366},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.class), func(t *testing.T) {
+			if got := TradingDays(tt.class, from, to); got != tt.want {
+				t.Errorf("TradingDays() = %d, want %d", got, tt.want)
+			}
+			if got := TradingDays(tt.class, to, from); got != -tt.want {
+				t.Errorf("reversed TradingDays() = %d, want %d", got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestYearFraction(t *testing.T) {
+	from := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		class store.AssetClass
+		want  float64
+	}{
+		{"", 366 / YEAR},
+		{store.AssetClassEquity, 1},
+		{store.AssetClassForex, 262.0 / 260},
+		{store.AssetClassCrypto, 366 / YEAR},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.class), func(t *testing.T) {
+			if got := YearFraction(tt.class, from, to); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("YearFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	gonum "gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// esGridPoints is the number of tail quantiles averaged to integrate expected shortfall for the distribution-based
+// methods.
+const esGridPoints = 200
+
+// VaRConf selects the confidence levels and holding period for value-at-risk, and the position sizes aggregated into
+// the portfolio figure. Confidence defaults to 95% and 99%. With HorizonDays unset each duration is its own horizon,
+// converted from calendar days to sessions. Holdings maps tickers to position values in account currency.
+type VaRConf struct {
+	Confidence  []float64          `json:"confidence"`
+	HorizonDays float64            `json:"horizon-days"`
+	Holdings    map[string]float64 `json:"holdings"`
+}
+
+// VaR is the loss, as a positive fraction of position value, that is only exceeded with probability 1-Confidence over
+// Horizon sessions, estimated from the empirical return distribution, a normal distribution, and a Cornish-Fisher
+// expansion that corrects the normal quantile for skew and kurtosis. The ES fields are the expected shortfall (CVaR),
+// the mean loss once VaR is breached.
+type VaR struct {
+	Confidence      float64 `json:"confidence"`
+	Horizon         float64 `json:"horizon"`
+	Historical      float64 `json:"historical"`
+	Parametric      float64 `json:"parametric"`
+	CornishFisher   float64 `json:"cornish-fisher"`
+	HistoricalES    float64 `json:"historical-es"`
+	ParametricES    float64 `json:"parametric-es"`
+	CornishFisherES float64 `json:"cornish-fisher-es"`
+}
+
+// PortfolioVaR is value-at-risk for a set of holdings, from the daily returns of the position-weighted basket over
+// each duration window ending at the latest date in the batch. Losses are fractions of Value; Missing lists holdings
+// without price data in the batch.
+type PortfolioVaR struct {
+	Value    float64            `json:"value"`
+	Holdings map[string]float64 `json:"holdings"`
+	Missing  []string           `json:"missing,omitempty"`
+	Trade    []VaR              `json:"trade"`
+	Trend    []VaR              `json:"trend"`
+	Tail     []VaR              `json:"tail"`
+}
+
+// Validate reports confidence levels outside (0, 1) and negative horizons or position values.
+func (c VaRConf) Validate() error {
+	for _, confidence := range c.Confidence {
+		if confidence <= 0 || confidence >= 1 {
+			return fmt.Errorf("var confidence %v must be between 0 and 1", confidence)
+		}
+	}
+	if c.HorizonDays < 0 {
+		return fmt.Errorf("var horizon-days %v must not be negative", c.HorizonDays)
+	}
+	for ticker, value := range c.Holdings {
+		if value < 0 {
+			return fmt.Errorf("var holding %s has negative value %v", ticker, value)
+		}
+	}
+	return nil
+}
+
+func (c VaRConf) confidences() []float64 {
+	if len(c.Confidence) == 0 {
+		return []float64{0.95, 0.99}
+	}
+	return c.Confidence
+}
+
+// horizon returns the holding period in sessions for a duration measured in calendar days.
+func (c VaRConf) horizon(duration int, daysInYear float64) float64 {
+	if c.HorizonDays > 0 {
+		return c.HorizonDays
+	}
+	return float64(duration) * daysInYear / 365.24
+}
+
+// cornishFisher adjusts the standard normal quantile z for the sample skew and excess kurtosis of the returns.
+func cornishFisher(z, skew, exKurtosis float64) float64 {
+	return z + (z*z-1)*skew/6 + (z*z*z-3*z)*exKurtosis/24 - (2*z*z*z-5*z)*skew*skew/36
+}
+
+// quantileLoss converts a standardized daily log return quantile into the fractional loss over horizon sessions,
+// scaling the drift linearly and the deviation by the square root of time.
+func quantileLoss(mean, stdDev, horizon, z float64) float64 {
+	return 1 - math.Exp(mean*horizon+z*stdDev*math.Sqrt(horizon))
+}
+
+// tailLoss averages quantileLoss over the worst 1-confidence of a distribution given by its standardized quantile
+// function, which is the expected shortfall of that distribution.
+func tailLoss(mean, stdDev, horizon, confidence float64, quantile func(p float64) float64) float64 {
+	alpha := 1 - confidence
+	var total float64
+	for i := 0; i < esGridPoints; i++ {
+		p := alpha * (float64(i) + 0.5) / esGridPoints
+		total += quantileLoss(mean, stdDev, horizon, quantile(p))
+	}
+	return total / esGridPoints
+}
+
+// calculateVaR estimates VaR and expected shortfall from daily log returns. The historical and Cornish-Fisher figures
+// use the sample moments of returns; the parametric figures use dailyVol, falling back to the sample standard
+// deviation when it is not positive. ok is false with fewer than four returns or no variation.
+func calculateVaR(returns []float64, dailyVol, confidence, horizon float64) (v VaR, ok bool) {
+	if len(returns) < 4 {
+		return VaR{}, false
+	}
+	mean, stdDev := gonum.MeanStdDev(returns, nil)
+	if stdDev == 0 || math.IsNaN(stdDev) {
+		return VaR{}, false
+	}
+	if dailyVol <= 0 {
+		dailyVol = stdDev
+	}
+	skew := gonum.Skew(returns, nil)
+	exKurtosis := gonum.ExKurtosis(returns, nil)
+	alpha := 1 - confidence
+	z := distuv.UnitNormal.Quantile(alpha)
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	q := gonum.Quantile(alpha, gonum.Empirical, sorted, nil)
+
+	v = VaR{Confidence: confidence, Horizon: horizon}
+	v.Historical = quantileLoss(mean, stdDev, horizon, (q-mean)/stdDev)
+	var tail int
+	for _, r := range sorted {
+		if r > q {
+			break
+		}
+		v.HistoricalES += quantileLoss(mean, stdDev, horizon, (r-mean)/stdDev)
+		tail++
+	}
+	v.HistoricalES /= float64(tail)
+	v.Parametric = quantileLoss(mean, dailyVol, horizon, z)
+	v.ParametricES = tailLoss(mean, dailyVol, horizon, confidence, distuv.UnitNormal.Quantile)
+	v.CornishFisher = quantileLoss(mean, stdDev, horizon, cornishFisher(z, skew, exKurtosis))
+	v.CornishFisherES = tailLoss(mean, stdDev, horizon, confidence, func(p float64) float64 {
+		return cornishFisher(distuv.UnitNormal.Quantile(p), skew, exKurtosis)
+	})
+	return v, true
+}
+
+// calculateVaRs runs calculateVaR at each configured confidence level, skipping the window entirely when it is too
+// short to estimate.
+func calculateVaRs(returns []float64, dailyVol float64, conf VaRConf, horizon float64) []VaR {
+	var results []VaR
+	for _, confidence := range conf.confidences() {
+		v, ok := calculateVaR(returns, dailyVol, confidence, horizon)
+		if !ok {
+			return nil
+		}
+		results = append(results, v)
+	}
+	return results
+}
+
+// CalculateVaR stores the value-at-risk of each day's duration window of closes. The parametric method uses the
+// realized volatility StoreRealizedVols stored for the same duration, so it must run first.
+func CalculateVaR(stockPrices map[string]map[int64]SingleStockCandle, duration int,
+	conf VaRConf) (stockPricesMap map[string]map[int64]SingleStockCandle) {
+	for ticker := range stockPrices {
+		daysInYear := annualization(ticker)
+		horizon := conf.horizon(duration, daysInYear)
+		var dateKeys []int64
+		for dateKey := range stockPrices[ticker] {
+			dateKeys = append(dateKeys, dateKey)
+		}
+		reverseDateKeys := dateKeys
+		sort.Slice(reverseDateKeys, func(i, j int) bool {
+			return reverseDateKeys[i] > reverseDateKeys[j]
+		})
+		for index, date := range reverseDateKeys {
+			windowDates, ok := collectWindowDates(reverseDateKeys, index, duration)
+			if !ok {
+				continue
+			}
+			closes := make([]float64, len(windowDates))
+			for i, wd := range windowDates {
+				closes[len(windowDates)-1-i] = stockPrices[ticker][wd].Close
+			}
+			stockCandle := stockPrices[ticker][date]
+			dailyVol := getRVol(stockCandle, duration) / math.Sqrt(daysInYear)
+			if results := calculateVaRs(calculateDailyReturn(closes), dailyVol, conf, horizon); results != nil {
+				setVaR(&stockCandle, duration, results)
+				stockPrices[ticker][date] = stockCandle
+			}
+		}
+	}
+	return stockPrices
+}
+
+// portfolioReturns returns the daily log returns of a basket holding each ticker at weights, rebalanced daily, over
+// the sessions in [from, to] that every ticker traded.
+func portfolioReturns(closes map[string]map[string]float64, weights map[string]float64, from, to string) []float64 {
+	var dates []string
+	for date := range closes[firstKey(weights)] {
+		if date < from || date > to {
+			continue
+		}
+		common := true
+		for ticker := range weights {
+			if _, ok := closes[ticker][date]; !ok {
+				common = false
+				break
+			}
+		}
+		if common {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	var returns []float64
+	for i := 1; i < len(dates); i++ {
+		var simple float64
+		for ticker, w := range weights {
+			simple += w * (closes[ticker][dates[i]]/closes[ticker][dates[i-1]] - 1)
+		}
+		returns = append(returns, math.Log1p(simple))
+	}
+	return returns
+}
+
+func firstKey(m map[string]float64) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
+
+// CalculatePortfolioVaR aggregates conf.Holdings, keyed like batch, into a position-weighted basket and estimates its
+// value-at-risk for each duration. Diversification is captured by using the basket's own return series, so the
+// parametric figures use its sample volatility.
+func CalculatePortfolioVaR(batch map[string]map[int64]SingleStockCandle, conf VaRConf) PortfolioVaR {
+	portfolio := PortfolioVaR{Holdings: conf.Holdings}
+	closes := map[string]map[string]float64{}
+	weights := map[string]float64{}
+	latest := ""
+	for ticker, value := range conf.Holdings {
+		candles, ok := batch[ticker]
+		if !ok || len(candles) == 0 {
+			portfolio.Missing = append(portfolio.Missing, ticker)
+			continue
+		}
+		if value == 0 {
+			continue
+		}
+		closes[ticker] = closesByDate(candles)
+		weights[ticker] = value
+		portfolio.Value += value
+		for date := range closes[ticker] {
+			if date > latest {
+				latest = date
+			}
+		}
+	}
+	sort.Strings(portfolio.Missing)
+	if portfolio.Value == 0 {
+		return portfolio
+	}
+	for ticker := range weights {
+		weights[ticker] /= portfolio.Value
+	}
+	end, _ := time.Parse(time.DateOnly, latest)
+	for _, duration := range []int{SHORTDURATION, MEDIUMDURATION, LONGDURATION} {
+		from := end.AddDate(0, 0, -duration).Format(time.DateOnly)
+		returns := portfolioReturns(closes, weights, from, latest)
+		// The basket only moves on sessions every holding traded, so the window's own session count is the horizon.
+		horizon := conf.HorizonDays
+		if horizon == 0 {
+			horizon = float64(len(returns))
+		}
+		results := calculateVaRs(returns, 0, conf, horizon)
+		switch duration {
+		case SHORTDURATION:
+			portfolio.Trade = results
+		case MEDIUMDURATION:
+			portfolio.Trend = results
+		case LONGDURATION:
+			portfolio.Tail = results
+		}
+	}
+	return portfolio
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+)
+
+var varTestReturns = []float64{-0.05, 0.01, -0.004, 0.012, 0.003, -0.008, 0.006, 0.002, -0.001, 0.009, -0.012, 0.004,
+	0.007, -0.003, 0.005, 0.0, -0.006, 0.011, 0.001, -0.002}
+
+func TestCornishFisher_NormalReturnsUnchanged(t *testing.T) {
+	for _, z := range []float64{-2.326, -1.645, 0, 1.645} {
+		if got := cornishFisher(z, 0, 0); got != z {
+			t.Errorf("cornishFisher(%v, 0, 0) = %v, want %v", z, got, z)
+		}
+	}
+	// Negative skew pushes the lower quantile further into the tail.
+	if got := cornishFisher(-1.645, -1, 0); got >= -1.645 {
+		t.Errorf("negative skew should lower the 5%% quantile, got %v", got)
+	}
+}
+
+func TestCalculateVaR(t *testing.T) {
+	tests := []struct {
+		name           string
+		returns        []float64
+		dailyVol       float64
+		confidence     float64
+		wantOK         bool
+		wantHistorical float64
+		wantParametric float64
+	}{
+		{
+			name:       "second worst return is the 95% historical quantile of twenty",
+			returns:    varTestReturns,
+			dailyVol:   0.01,
+			confidence: 0.95,
+			wantOK:     true,
+			// 1-exp(-0.012) and 1-exp(mean + z(0.05)*0.01) with mean -0.0008
+			wantHistorical: 1 - math.Exp(-0.012),
+			wantParametric: 0.017100631866852845,
+		},
+		{
+			name:       "too few returns",
+			returns:    []float64{-0.01, 0.02, 0.01},
+			confidence: 0.95,
+		},
+		{
+			name:       "no variation",
+			returns:    []float64{0.01, 0.01, 0.01, 0.01, 0.01},
+			confidence: 0.95,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := calculateVaR(tt.returns, tt.dailyVol, tt.confidence, 1)
+			if ok != tt.wantOK {
+				t.Fatalf("calculateVaR() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(got.Historical-tt.wantHistorical) > 1e-9 {
+				t.Errorf("Historical = %v, want %v", got.Historical, tt.wantHistorical)
+			}
+			if math.Abs(got.Parametric-tt.wantParametric) > 1e-9 {
+				t.Errorf("Parametric = %v, want %v", got.Parametric, tt.wantParametric)
+			}
+			if got.HistoricalES < got.Historical || got.ParametricES < got.Parametric ||
+				got.CornishFisherES < got.CornishFisher {
+				t.Errorf("expected shortfall should be at least VaR: %+v", got)
+			}
+		})
+	}
+}
+
+func TestCalculateVaR_HorizonScaling(t *testing.T) {
+	one, _ := calculateVaR(varTestReturns, 0.01, 0.99, 1)
+	twenty, _ := calculateVaR(varTestReturns, 0.01, 0.99, 20)
+	if twenty.Parametric <= one.Parametric || twenty.Historical <= one.Historical {
+		t.Errorf("longer horizon should lose more: 1 day %+v, 20 days %+v", one, twenty)
+	}
+}
+
+func TestCalculateVaR_PopulatesOnlyTargetDuration(t *testing.T) {
+	data := makeTestData("AAPL", 90)
+	data = StoreRealizedVols(data, SHORTDURATION)
+	result := CalculateVaR(data, SHORTDURATION, VaRConf{})
+	hasShort := false
+	for _, candles := range result {
+		for _, c := range candles {
+			if len(c.VaRShort) > 0 {
+				hasShort = true
+				if len(c.VaRShort) != 2 || c.VaRShort[0].Confidence != 0.95 || c.VaRShort[1].Confidence != 0.99 {
+					t.Errorf("expected default 95%% and 99%% levels, got %+v", c.VaRShort)
+				}
+			}
+			if c.VaRMed != nil || c.VaRLong != nil {
+				t.Errorf("Med/Long VaR should not be set by SHORTDURATION call")
+			}
+		}
+	}
+	if !hasShort {
+		t.Error("expected at least one candle with VaRShort populated")
+	}
+}
+
+func TestCalculatePortfolioVaR(t *testing.T) {
+	batch := map[string]map[int64]SingleStockCandle{
+		"AAPL": makeTestData("AAPL", 200)["AAPL"],
+		"MSFT": makeTestData("MSFT", 200)["MSFT"],
+	}
+	// Two holdings with identical prices behave like a single position of the combined value.
+	split := CalculatePortfolioVaR(batch, VaRConf{Holdings: map[string]float64{"AAPL": 100, "MSFT": 300, "TSLA": 50}})
+	single := CalculatePortfolioVaR(batch, VaRConf{Holdings: map[string]float64{"AAPL": 400}})
+	if split.Value != 400 {
+		t.Errorf("Value = %v, want 400", split.Value)
+	}
+	if len(split.Missing) != 1 || split.Missing[0] != "TSLA" {
+		t.Errorf("Missing = %v, want [TSLA]", split.Missing)
+	}
+	if len(split.Trade) != 2 || len(split.Tail) != 2 {
+		t.Fatalf("expected two confidence levels per window, got trade %v tail %v", split.Trade, split.Tail)
+	}
+	for i := range split.Tail {
+		if math.Abs(split.Tail[i].Parametric-single.Tail[i].Parametric) > 1e-12 {
+			t.Errorf("split portfolio %v differs from single position %v", split.Tail[i], single.Tail[i])
+		}
+	}
+
+	empty := CalculatePortfolioVaR(batch, VaRConf{Holdings: map[string]float64{"TSLA": 50}})
+	if empty.Value != 0 || empty.Trade != nil {
+		t.Errorf("portfolio without priced holdings should be empty, got %+v", empty)
+	}
+}
+
+func TestVaRConf_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    VaRConf
+		wantErr bool
+	}{
+		{name: "defaults", conf: VaRConf{}},
+		{name: "valid", conf: VaRConf{Confidence: []float64{0.9, 0.975}, HorizonDays: 10,
+			Holdings: map[string]float64{"SPY": 1000}}},
+		{name: "confidence as percent", conf: VaRConf{Confidence: []float64{95}}, wantErr: true},
+		{name: "negative horizon", conf: VaRConf{HorizonDays: -1}, wantErr: true},
+		{name: "negative holding", conf: VaRConf{Holdings: map[string]float64{"SPY": -1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+const (
+	// VOLESTIMATORVOLUMEADJ selects the probability-adjusted ranges built on volume-adjusted realized volatility.
+	VOLESTIMATORVOLUMEADJ = "volume-adjusted"
+	// VOLESTIMATORREALIZED selects the probability-adjusted ranges built on plain realized volatility.
+	VOLESTIMATORREALIZED = "realized"
+)
+
+// TickerParams are the range settings the walk-forward optimizer picked for one ticker. The batch run uses them in
+// place of the global probable-range-adj when -t selects Duration, and skips them otherwise.
+type TickerParams struct {
+	RangeAdjustment float64 `json:"probable-range-adj"`
+	VolEstimator    string  `json:"vol-estimator"`
+	Duration        int     `json:"duration"`
+}
+
+// WalkForwardConf sets up the optimizer. Objective is "calibration" (the default: the range's hit rate closest to
+// Coverage, default 0.8), "return", or "sharpe" (of a RangeStrategy backtest trading the range with the
+// DirectionDuration filter, default MEDIUMDURATION). Each fold picks the best parameters over TrainSessions sessions
+// (default 126) and scores them on the next TestSessions (default 21). Adjustments defaults to 0.05 through 0.3;
+// Estimators and Durations default to volume-adjusted and SHORTDURATION.
+type WalkForwardConf struct {
+	Objective         string       `json:"objective"`
+	TrainSessions     int          `json:"train-sessions"`
+	TestSessions      int          `json:"test-sessions"`
+	Adjustments       []float64    `json:"adjustments"`
+	Estimators        []string     `json:"estimators"`
+	Durations         []int        `json:"durations"`
+	Coverage          float64      `json:"coverage"`
+	DirectionDuration int          `json:"direction-duration"`
+	Backtest          BacktestConf `json:"backtest"`
+}
+
+// WalkForwardFold is one training window, the parameters it picked, and their score in and out of sample.
+type WalkForwardFold struct {
+	TrainStart  time.Time    `json:"train-start"`
+	TrainEnd    time.Time    `json:"train-end"`
+	TestStart   time.Time    `json:"test-start"`
+	TestEnd     time.Time    `json:"test-end"`
+	Params      TickerParams `json:"params"`
+	InSample    float64      `json:"in-sample"`
+	OutOfSample float64      `json:"out-of-sample"`
+}
+
+// WalkForwardResult is the optimizer's report for one ticker. Params were picked on the most recent training window
+// and are what the batch run should use; OutOfSample is the mean test score across Folds.
+type WalkForwardResult struct {
+	Ticker      string            `json:"ticker"`
+	Objective   string            `json:"objective"`
+	Params      TickerParams      `json:"params"`
+	Folds       []WalkForwardFold `json:"folds"`
+	OutOfSample float64           `json:"out-of-sample"`
+}
+
+// Range returns the band the parameters select from an analyzed candle.
+func (p TickerParams) Range(c SingleStockCandle) map[string]float64 {
+	return estimatorRange(p.VolEstimator)(c, p.Duration)
+}
+
+func estimatorRange(estimator string) func(c SingleStockCandle, d int) map[string]float64 {
+	if estimator == VOLESTIMATORREALIZED {
+		return getProbRiskRange
+	}
+	return getProbAdjRiskRange
+}
+
+func (c WalkForwardConf) withDefaults() WalkForwardConf {
+	if c.Objective == "" {
+		c.Objective = "calibration"
+	}
+	if c.TrainSessions <= 0 {
+		c.TrainSessions = 126
+	}
+	if c.TestSessions <= 0 {
+		c.TestSessions = 21
+	}
+	if len(c.Adjustments) == 0 {
+		c.Adjustments = []float64{0.05, 0.1, 0.15, 0.2, 0.25, 0.3}
+	}
+	if len(c.Estimators) == 0 {
+		c.Estimators = []string{VOLESTIMATORVOLUMEADJ}
+	}
+	if len(c.Durations) == 0 {
+		c.Durations = []int{SHORTDURATION}
+	}
+	if c.Coverage <= 0 || c.Coverage >= 1 {
+		c.Coverage = 0.8
+	}
+	if c.DirectionDuration == 0 {
+		c.DirectionDuration = MEDIUMDURATION
+	}
+	return c
+}
+
+// validate reports an unknown objective or estimator, or an adjustment GetProbAdjRiskRanges would not apply as given.
+func (c WalkForwardConf) validate() error {
+	switch c.Objective {
+	case "calibration", "return", "sharpe":
+	default:
+		return fmt.Errorf("unknown walk-forward objective %q", c.Objective)
+	}
+	for _, estimator := range c.Estimators {
+		if estimator != VOLESTIMATORVOLUMEADJ && estimator != VOLESTIMATORREALIZED {
+			return fmt.Errorf("unknown volatility estimator %q", estimator)
+		}
+	}
+	for _, adjustment := range c.Adjustments {
+		if adjustment <= 0 || adjustment >= 0.5 {
+			return fmt.Errorf("range adjustment %v must be between 0 and 0.5", adjustment)
+		}
+	}
+	return nil
+}
+
+// scoreParams scores params on the sessions dateKeys[from:to+1] of the candles analyzed with params'
+// RangeAdjustment. Calibration only counts outcomes up to limit. Invalid scores, such as a window without any
+// ranges, are -Inf.
+func scoreParams(ticker string, analyzed map[int64]SingleStockCandle, dateKeys []int64, params TickerParams,
+	from, to int, limit int64, conf WalkForwardConf) float64 {
+	if conf.Objective == "calibration" {
+		result := scoreRange(analyzed, dateKeys, estimatorRange(params.VolEstimator), params.Duration,
+			dateKeys[from], dateKeys[to], limit)
+		if result.Samples == 0 {
+			return math.Inf(-1)
+		}
+		result.finish()
+		return -math.Abs(result.WithinRate - conf.Coverage)
+	}
+	window := make(map[int64]SingleStockCandle, to-from+1)
+	for _, date := range dateKeys[from : to+1] {
+		window[date] = analyzed[date]
+	}
+	strategy := rangeStrategy(estimatorRange(params.VolEstimator), params.Duration, conf.DirectionDuration, 0.1, 0.1)
+	identity := func(stockPrices map[string]map[int64]SingleStockCandle) map[string]map[int64]SingleStockCandle {
+		return stockPrices
+	}
+	result, err := Backtest(ticker, window, identity, strategy, conf.Backtest)
+	if err != nil {
+		return math.Inf(-1)
+	}
+	if conf.Objective == "return" {
+		return result.Stats.TotalReturn
+	}
+	equity := make([]float64, len(result.Equity))
+	for i, point := range result.Equity {
+		equity[i] = point.Equity
+	}
+	return calculateRiskAdjustedReturns(equity, 0, annualization(ticker)).Sharpe
+}
+
+// bestParams returns the highest scoring parameters over sessions [from, to], in grid order on ties.
+func bestParams(ticker string, analyzed map[float64]map[int64]SingleStockCandle, dateKeys []int64, from, to int,
+	conf WalkForwardConf) (best TickerParams, bestScore float64) {
+	bestScore = math.Inf(-1)
+	for _, adjustment := range conf.Adjustments {
+		for _, estimator := range conf.Estimators {
+			for _, duration := range conf.Durations {
+				params := TickerParams{RangeAdjustment: adjustment, VolEstimator: estimator, Duration: duration}
+				score := scoreParams(ticker, analyzed[adjustment], dateKeys, params, from, to, dateKeys[to], conf)
+				if score > bestScore {
+					best, bestScore = params, score
+				}
+			}
+		}
+	}
+	return best, bestScore
+}
+
+// WalkForward picks ticker's range parameters over rolling training windows and scores each pick on the sessions
+// that follow it. The pipeline is run once per adjustment over the whole history, which does not leak later prices
+// because every step only looks back from each day; training windows only count range outcomes that finish inside
+// the window.
+func WalkForward(ticker string, candles map[int64]SingleStockCandle, stockDataConf StockDataConf,
+	conf WalkForwardConf) (result WalkForwardResult, err error) {
+	conf = conf.withDefaults()
+	if err = conf.validate(); err != nil {
+		return WalkForwardResult{}, err
+	}
+	dateKeys := sortedDateKeys(candles)
+	if len(dateKeys) < conf.TrainSessions+conf.TestSessions {
+		return WalkForwardResult{}, errors.New("not enough history for one training and test window")
+	}
+
+	analyzed := map[float64]map[int64]SingleStockCandle{}
+	for _, adjustment := range conf.Adjustments {
+		history := make(map[int64]SingleStockCandle, len(candles))
+		for date, c := range candles {
+			history[date] = c
+		}
+		pipelineConf := stockDataConf
+		pipelineConf.RangeAdjustment = adjustment
+		batch := map[string]map[int64]SingleStockCandle{ticker: history}
+		analyzed[adjustment] = RangePipeline(pipelineConf)(batch)[ticker]
+	}
+
+	result = WalkForwardResult{Ticker: ticker, Objective: conf.Objective}
+	last := len(dateKeys) - 1
+	var oosTotal float64
+	for start := 0; start+conf.TrainSessions+conf.TestSessions <= len(dateKeys); start += conf.TestSessions {
+		trainEnd := start + conf.TrainSessions - 1
+		testStart, testEnd := trainEnd+1, trainEnd+conf.TestSessions
+		params, inSample := bestParams(ticker, analyzed, dateKeys, start, trainEnd, conf)
+		if math.IsInf(inSample, -1) {
+			continue
+		}
+		outOfSample := scoreParams(ticker, analyzed[params.RangeAdjustment], dateKeys, params, testStart, testEnd,
+			dateKeys[last], conf)
+		if math.IsInf(outOfSample, -1) {
+			continue
+		}
+		result.Folds = append(result.Folds, WalkForwardFold{
+			TrainStart:  time.UnixMilli(dateKeys[start]).UTC(),
+			TrainEnd:    time.UnixMilli(dateKeys[trainEnd]).UTC(),
+			TestStart:   time.UnixMilli(dateKeys[testStart]).UTC(),
+			TestEnd:     time.UnixMilli(dateKeys[testEnd]).UTC(),
+			Params:      params,
+			InSample:    inSample,
+			OutOfSample: outOfSample,
+		})
+		oosTotal += outOfSample
+	}
+	if len(result.Folds) > 0 {
+		result.OutOfSample = oosTotal / float64(len(result.Folds))
+	}
+
+	params, score := bestParams(ticker, analyzed, dateKeys, len(dateKeys)-conf.TrainSessions, last, conf)
+	if math.IsInf(score, -1) {
+		return result, errors.New("no parameters could be scored on the most recent training window")
+	}
+	result.Params = params
+	return result, nil
+}
+
+// LoadTickerParams reads the per-ticker parameter file the walk-forward optimizer writes, keyed by normalized ticker.
+func LoadTickerParams(path string) (map[string]TickerParams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]TickerParams{}
+	if err = json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("error decoding ticker params %s: %v", path, err)
+	}
+	return params, nil
+}
@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeWalkData builds n consecutive daily candles oscillating around 100 with a 1% intraday range.
+func makeWalkData(n int) map[int64]SingleStockCandle {
+	candles := map[int64]SingleStockCandle{}
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		ts := start.AddDate(0, 0, i)
+		c := 100 * math.Exp(0.05*math.Sin(float64(i)/7)+0.02*math.Sin(float64(i)*1.7))
+		candles[ts.UnixMilli()] = SingleStockCandle{
+			Ticker:         "TEST",
+			Open:           c,
+			Close:          c,
+			High:           c * 1.01,
+			Low:            c * 0.99,
+			WeightedVolume: c,
+			Volume:         1_000_000 * (1 + 0.3*math.Sin(float64(i))),
+			Timestamp:      ts,
+		}
+	}
+	return candles
+}
+
+func TestWalkForwardConf_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    WalkForwardConf
+		wantErr bool
+	}{
+		{name: "defaults", conf: WalkForwardConf{}},
+		{name: "unknown objective", conf: WalkForwardConf{Objective: "profit"}, wantErr: true},
+		{name: "unknown estimator", conf: WalkForwardConf{Estimators: []string{"parkinson"}}, wantErr: true},
+		{name: "adjustment that would default", conf: WalkForwardConf{Adjustments: []float64{0}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.conf.withDefaults().validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWalkForward_Calibration(t *testing.T) {
+	candles := makeWalkData(300)
+	conf := WalkForwardConf{TrainSessions: 120, TestSessions: 30, Coverage: 0.99}
+	result, err := WalkForward("TEST", candles, StockDataConf{}, conf)
+	if err != nil {
+		t.Fatalf("WalkForward() error = %v", err)
+	}
+	if len(result.Folds) == 0 || len(result.Folds) > (300-120)/30 {
+		t.Fatalf("got %d folds, want between 1 and %d", len(result.Folds), (300-120)/30)
+	}
+	// The smallest adjustment gives the widest band, which is the closest to a 99% hit rate.
+	if result.Params.RangeAdjustment != 0.05 || result.Params.Duration != SHORTDURATION ||
+		result.Params.VolEstimator != VOLESTIMATORVOLUMEADJ {
+		t.Errorf("Params = %+v, want the widest short volume-adjusted range", result.Params)
+	}
+	for _, fold := range result.Folds {
+		if !fold.TrainEnd.Before(fold.TestStart) {
+			t.Errorf("test window %v starts before training ends %v", fold.TestStart, fold.TrainEnd)
+		}
+		if fold.InSample > 0 || fold.OutOfSample > 0 {
+			t.Errorf("calibration scores are negative distances, got %+v", fold)
+		}
+	}
+
+	if _, err := WalkForward("TEST", makeWalkData(100), StockDataConf{}, conf); err == nil {
+		t.Error("expected error when history is shorter than one training and test window")
+	}
+}
+
+func TestWalkForward_Return(t *testing.T) {
+	conf := WalkForwardConf{Objective: "return", TrainSessions: 120, TestSessions: 30,
+		Estimators: []string{VOLESTIMATORVOLUMEADJ, VOLESTIMATORREALIZED}, DirectionDuration: SHORTDURATION}
+	result, err := WalkForward("TEST", makeWalkData(300), StockDataConf{}, conf)
+	if err != nil {
+		t.Fatalf("WalkForward() error = %v", err)
+	}
+	if result.Objective != "return" || len(result.Folds) == 0 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestTickerParams_Range(t *testing.T) {
+	c := SingleStockCandle{
+		PTrendRange:    map[string]float64{"high": 1},
+		PTrendRangeAdj: map[string]float64{"high": 2},
+	}
+	realized := TickerParams{VolEstimator: VOLESTIMATORREALIZED, Duration: MEDIUMDURATION}
+	if got := realized.Range(c); got["high"] != 1 {
+		t.Errorf("realized estimator: got %v", got)
+	}
+	volumeAdjusted := TickerParams{VolEstimator: VOLESTIMATORVOLUMEADJ, Duration: MEDIUMDURATION}
+	if got := volumeAdjusted.Range(c); got["high"] != 2 {
+		t.Errorf("volume-adjusted estimator: got %v", got)
+	}
+}
+
+func TestLoadTickerParams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "params.json")
+	data := `{"AAPL": {"probable-range-adj": 0.15, "vol-estimator": "realized", "duration": 90}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	params, err := LoadTickerParams(path)
+	if err != nil {
+		t.Fatalf("LoadTickerParams() error = %v", err)
+	}
+	want := TickerParams{RangeAdjustment: 0.15, VolEstimator: VOLESTIMATORREALIZED, Duration: MEDIUMDURATION}
+	if params["AAPL"] != want {
+		t.Errorf("AAPL = %+v, want %+v", params["AAPL"], want)
+	}
+	if _, err := LoadTickerParams(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}